@@ -0,0 +1,244 @@
+// cmd/localproc/main.go
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/processor"
+)
+
+// localproc runs a log file through the same parser as the worker Lambda and
+// prints the result, without touching S3, SQS or DynamoDB.
+//
+//	localproc [flags] [file]   (reads stdin when file is omitted or "-")
+func main() {
+	format := flag.String("format", "json", "output format: json or text")
+	pretty := flag.Bool("pretty", false, "indent JSON output")
+	parserName := flag.String("parser", processor.DefaultParser, "parser to use: log or csv (as PARSER)")
+	columns := flag.String("columns", "", "comma-separated fields of each CSV column, in order (as CSV_COLUMNS)")
+	delimiter := flag.String("delimiter", "", "CSV column delimiter: a character or tab (default ,)")
+	header := flag.Bool("header", false, "skip the first CSV row of the file")
+	pattern := flag.String("pattern", "", "regex with named groups for plain-text logs (as LOG_LINE_PATTERN)")
+	layout := flag.String("timestamp-layout", "", "time.Parse layout for timestamps (default RFC3339)")
+	inputFormat := flag.String("input-format", "", "force ndjson or json_array instead of detecting it")
+	approximate := flag.Bool("approximate-uniques", false, "estimate unique users/endpoints with HyperLogLog")
+	required := flag.String("required-fields", "", "comma-separated fields every entry must set")
+	dupWindow := flag.Int("duplicate-window", 0, "count lines repeated within this many preceding lines")
+	approxDups := flag.Bool("approximate-duplicates", false, "track the duplicate window with Bloom filters")
+	zScore := flag.Float64("anomaly-z-score", processor.DefaultAnomalyZScore, "count response times beyond this many standard deviations")
+	maxUsers := flag.Int("max-tracked-users", 0, "count requests per user, keeping at most this many users")
+	maxMinutes := flag.Int("max-time-series-minutes", 0, "count requests per minute, keeping at most this many minutes")
+	maxSamples := flag.Int("max-sample-errors", 0, "keep up to this many distinct ERROR messages")
+	errorWindow := flag.Duration("error-window", 0, "report the most ERROR entries within any window this long")
+	maxLines := flag.Int("max-lines", 0, "stop after this many lines, marking the result truncated")
+	fastJSON := flag.Bool("fast-json", false, "decode flat JSON lines without reflection")
+	debugRate := flag.Float64("debug-sample-rate", 0, "fully aggregate only this fraction of DEBUG entries")
+	schemaField := flag.String("schema-field", "", "JSON key selecting each line's schema from -schemas")
+	schemas := flag.String("schemas", "", "JSON object of schema key mappings (as LOG_SCHEMAS)")
+	fieldPaths := flag.String("field-paths", "", "comma-separated field=dotted.path pairs for nested JSON (as FIELD_PATHS)")
+	rtField := flag.String("response-time-field", "", "JSON key or pattern group holding the response time (default response_time_ms)")
+	rtUnit := flag.String("response-time-unit", "", "unit of the response time: ms (default), us or s")
+	maxRT := flag.Int("max-response-time-ms", processor.DefaultMaxResponseTimeMs, "skip entries with a longer response time")
+	maxLine := flag.Int("max-line-bytes", processor.DefaultMaxLineBytes, "skip lines longer than this many bytes")
+	flag.Parse()
+
+	if *format != "json" && *format != "text" {
+		fail(fmt.Errorf("unknown -format %q, want json or text", *format))
+	}
+
+	cfg := processor.ParserConfig{
+		TimestampLayout:       *layout,
+		InputFormat:           *inputFormat,
+		ApproximateUniques:    *approximate,
+		DuplicateWindow:       *dupWindow,
+		ApproximateDuplicates: *approxDups,
+		MaxLineBytes:          *maxLine,
+		AnomalyZScore:         *zScore,
+		MaxTrackedUsers:       *maxUsers,
+		MaxTimeSeriesMinutes:  *maxMinutes,
+		MaxSampleErrors:       *maxSamples,
+		ErrorWindow:           *errorWindow,
+		FastJSON:              *fastJSON,
+		MaxLines:              *maxLines,
+		DebugSampleRate:       *debugRate,
+		ResponseTimeField:     *rtField,
+		ResponseTimeUnit:      *rtUnit,
+		MaxResponseTimeMs:     *maxRT,
+	}
+	if err := processor.ValidateResponseTimeUnit(cfg.ResponseTimeUnit); err != nil {
+		fail(fmt.Errorf("invalid -response-time-unit: %w", err))
+	}
+	newParser, err := processor.ParserFor(*parserName)
+	if err != nil {
+		fail(fmt.Errorf("invalid -parser: %w", err))
+	}
+	if *parserName == processor.CSVParser {
+		cfg.Columns, err = processor.ParseColumns(*columns)
+		if err != nil {
+			fail(fmt.Errorf("invalid -columns: %w", err))
+		}
+		if *delimiter != "" {
+			if cfg.Delimiter, err = processor.ParseDelimiter(*delimiter); err != nil {
+				fail(fmt.Errorf("invalid -delimiter: %w", err))
+			}
+		}
+		cfg.HeaderRow = *header
+	}
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			fail(fmt.Errorf("invalid -pattern: %w", err))
+		}
+		if err := processor.ValidatePattern(re); err != nil {
+			fail(fmt.Errorf("invalid -pattern: %w", err))
+		}
+		cfg.LinePattern = re
+	}
+	if *schemaField != "" {
+		parsed, err := processor.ParseSchemas(*schemas)
+		if err != nil {
+			fail(fmt.Errorf("invalid -schemas: %w", err))
+		}
+		cfg.SchemaField = *schemaField
+		cfg.Schemas = parsed
+	}
+	if *fieldPaths != "" {
+		parsed, err := processor.ParseFieldPaths(*fieldPaths)
+		if err != nil {
+			fail(fmt.Errorf("invalid -field-paths: %w", err))
+		}
+		cfg.FieldPaths = parsed
+	}
+	if *required != "" {
+		for _, name := range strings.Split(*required, ",") {
+			cfg.RequiredFields = append(cfg.RequiredFields, strings.TrimSpace(name))
+		}
+		if err := processor.ValidateRequiredFields(cfg.RequiredFields); err != nil {
+			fail(fmt.Errorf("invalid -required-fields: %w", err))
+		}
+	}
+
+	name, input, err := openInput(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	defer input.Close()
+
+	result, err := process(name, input, newParser(cfg))
+	if err != nil {
+		fail(err)
+	}
+
+	if *format == "text" {
+		printText(result)
+		return
+	}
+	enc := json.NewEncoder(os.Stdout)
+	if *pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(result); err != nil {
+		fail(err)
+	}
+}
+
+// openInput opens path, or stdin when path is empty or "-"
+func openInput(path string) (string, io.ReadCloser, error) {
+	if path == "" || path == "-" {
+		return "stdin", io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open input: %w", err)
+	}
+	return path, f, nil
+}
+
+// process parses input the way the worker does and builds its result
+func process(name string, input io.Reader, parser processor.Parser) (models.ProcessingResult, error) {
+	startTime := time.Now()
+	counter := &countingReader{r: input}
+
+	if _, err := parser.Parse(counter); err != nil {
+		return models.ProcessingResult{}, fmt.Errorf("failed to parse logs: %w", err)
+	}
+
+	result := parser.Result(name)
+	result.FileSizeBytes = counter.n
+	result.StartedAt = startTime
+	result.CompletedAt = time.Now()
+	result.SetTiming(time.Since(startTime))
+	return result, nil
+}
+
+// printText writes a human-readable summary of the result
+func printText(r models.ProcessingResult) {
+	fmt.Printf("Lines:           %d (%d malformed, %d invalid, %d duplicate, %d oversized, %d unknown schema)\n", r.LineCount, r.MalformedLineCount, r.InvalidEntryCount, r.DuplicateLineCount, r.OversizedLineCount, r.UnknownSchemaCount)
+	fmt.Printf("Levels:          ERROR=%d WARN=%d INFO=%d DEBUG=%d other=%d\n", r.ErrorCount, r.WarnCount, r.InfoCount, r.DebugCount, r.UnknownLevelCount)
+	fmt.Printf("Response time:   avg=%.1fms min=%dms max=%dms\n", r.AvgResponseTimeMs, r.MinResponseTimeMs, r.MaxResponseTimeMs)
+	fmt.Printf("Percentiles:     p50=%dms p90=%dms p95=%dms p99=%dms\n", r.P50ResponseTimeMs, r.P90ResponseTimeMs, r.P95ResponseTimeMs, r.P99ResponseTimeMs)
+	fmt.Printf("Anomalies:       %d requests (stddev %.1fms)\n", r.AnomalousRequestCount, r.StdDevResponseTimeMs)
+	fmt.Printf("HTTP errors:     %.2f%% (4xx %.2f%%, 5xx %.2f%%)\n", r.HTTPErrorRate*100, r.HTTP4xxRate*100, r.HTTP5xxRate*100)
+	fmt.Printf("Status classes:  1xx=%d 2xx=%d 3xx=%d 4xx=%d 5xx=%d other=%d\n", r.Status1xx, r.Status2xx, r.Status3xx, r.Status4xx, r.Status5xx, r.StatusOther)
+	fmt.Printf("Bytes sent:      %d total, avg %.1f per request\n", r.TotalBytesSent, r.AvgBytesSent)
+	fmt.Printf("Unique:          %d users, %d endpoints\n", r.UniqueUsers, r.UniqueEndpoints)
+	if r.EarliestTimestamp != nil {
+		fmt.Printf("Time window:     %s to %s\n", r.EarliestTimestamp.Format(time.RFC3339), r.LatestTimestamp.Format(time.RFC3339))
+	}
+	if r.PeakErrorsPerWindow > 0 {
+		fmt.Printf("Error peak:      %d ERROR entries in one window\n", r.PeakErrorsPerWindow)
+	}
+	fmt.Printf("Processed:       %d bytes in %dms (%.0f lines/s)\n", r.FileSizeBytes, r.ProcessingTimeMs, r.LinesPerSecond)
+
+	if len(r.TopEndpoints) > 0 {
+		fmt.Println("Top endpoints:")
+		for _, e := range r.TopEndpoints {
+			fmt.Printf("  %-30s %8d requests  avg %.1fms  %d errors\n", e.Endpoint, e.RequestCount, e.AvgResponseTimeMs, e.ErrorCount)
+		}
+	}
+	if len(r.SlowestEndpoints) > 0 {
+		fmt.Println("Slowest endpoints:")
+		for _, e := range r.SlowestEndpoints {
+			fmt.Printf("  %-30s avg %.1fms  max %dms  %d requests\n", e.Endpoint, e.AvgResponseTimeMs, e.MaxResponseTimeMs, e.RequestCount)
+		}
+	}
+	if len(r.TopUsers) > 0 {
+		fmt.Println("Top users:")
+		for _, u := range r.TopUsers {
+			fmt.Printf("  %-30s %8d requests\n", u.UserID, u.RequestCount)
+		}
+	}
+	if len(r.SampleErrors) > 0 {
+		fmt.Println("Sample errors:")
+		for _, message := range r.SampleErrors {
+			fmt.Printf("  %s\n", message)
+		}
+	}
+}
+
+// countingReader counts the bytes read through it, standing in for the S3
+// object size
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// fail prints err and exits non-zero
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "localproc: %v\n", err)
+	os.Exit(1)
+}
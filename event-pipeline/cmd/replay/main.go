@@ -0,0 +1,209 @@
+// cmd/replay/main.go
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"event-pipeline/internal/awsconfig"
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// replay re-enqueues jobs whose stored result is "failed", for example after
+// a parser fix. Jobs are rebuilt from the source_job the worker records on
+// failure, and skipped when the job's current result is no longer failed.
+//
+//	replay [-from 2024-01-15] [-to 2024-01-16] [-dry-run] [-concurrency 4]
+func main() {
+	table := flag.String("table", os.Getenv("DYNAMODB_TABLE"), "results table (default $DYNAMODB_TABLE)")
+	queueURL := flag.String("queue-url", os.Getenv("QUEUE_URL"), "processing queue URL (default $QUEUE_URL)")
+	from := flag.String("from", "", "only replay failures completed at or after this RFC3339 time or date")
+	to := flag.String("to", "", "only replay failures completed at or before this RFC3339 time or date")
+	dryRun := flag.Bool("dry-run", false, "log the jobs that would be queued without queuing them")
+	concurrency := flag.Int("concurrency", 4, "jobs checked and queued at once")
+	flag.Parse()
+
+	if *table == "" {
+		fail(errors.New("-table or DYNAMODB_TABLE is required"))
+	}
+	if *queueURL == "" && !*dryRun {
+		fail(errors.New("-queue-url or QUEUE_URL is required"))
+	}
+
+	start, err := parseBound(*from, time.Time{}, false)
+	if err != nil {
+		fail(fmt.Errorf("invalid -from: %w", err))
+	}
+	end, err := parseBound(*to, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), true)
+	if err != nil {
+		fail(fmt.Errorf("invalid -to: %w", err))
+	}
+
+	ctx := context.Background()
+	r, err := newReplayer(ctx, *table, *queueURL, *dryRun)
+	if err != nil {
+		fail(err)
+	}
+
+	failed, err := r.results.ListByStatusBetween(ctx, models.StatusFailed, start.UTC(), end.UTC())
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("Found %d failed results\n", len(failed))
+
+	queued := r.replayAll(ctx, failed, max(*concurrency, 1))
+	if *dryRun {
+		fmt.Printf("[DRY RUN] Would queue %d of %d jobs\n", queued, len(failed))
+		return
+	}
+	fmt.Printf("Queued %d of %d jobs\n", queued, len(failed))
+}
+
+// replayer checks failed results and re-enqueues their jobs
+type replayer struct {
+	results  *store.ResultStore
+	sqs      *sqs.Client
+	queueURL string
+	fifo     bool
+	dryRun   bool
+	runID    string // distinguishes this run's FIFO deduplication IDs
+}
+
+func newReplayer(ctx context.Context, table, queueURL string, dryRun bool) (*replayer, error) {
+	cfg, err := awsconfig.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := store.NewResultStore(ctx, table, store.EnvOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create result store: %w", err)
+	}
+
+	return &replayer{
+		results:  results,
+		sqs:      awsconfig.NewSQS(cfg),
+		queueURL: queueURL,
+		fifo:     strings.HasSuffix(queueURL, ".fifo"),
+		dryRun:   dryRun,
+		runID:    time.Now().UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+// replayAll replays each result with at most concurrency in flight and
+// returns how many jobs were (or in a dry run, would be) queued
+func (r *replayer) replayAll(ctx context.Context, failed []models.ProcessingResult, concurrency int) int {
+	var queued atomic.Int64
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, result := range failed {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, err := r.replay(ctx, result)
+			if err != nil {
+				fmt.Printf("Error replaying job %s: %v\n", result.JobID, err)
+				return
+			}
+			if ok {
+				queued.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return int(queued.Load())
+}
+
+// replay re-enqueues one failed result's job. It reports false for jobs
+// that were skipped.
+func (r *replayer) replay(ctx context.Context, result models.ProcessingResult) (bool, error) {
+	if result.SourceJob == nil {
+		fmt.Printf("Skipping job %s: result has no source_job to rebuild it from\n", result.JobID)
+		return false, nil
+	}
+
+	// The index may be stale, and with a sort key older failures remain
+	// after a later run succeeded, so check the job's latest result
+	current, err := r.results.GetResult(ctx, result.JobID)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return false, err
+	}
+	if current != nil && current.Status != models.StatusFailed {
+		fmt.Printf("Skipping job %s: current status is %s\n", result.JobID, current.Status)
+		return false, nil
+	}
+
+	job := *result.SourceJob
+	body, err := json.Marshal(job)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if r.dryRun {
+		fmt.Printf("[DRY RUN] Would queue job %s: %s\n", job.JobID, body)
+		return true, nil
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(r.queueURL),
+		MessageBody: aws.String(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"JobID": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(job.JobID),
+			},
+		},
+	}
+	// Standard queues reject these parameters, so only set them for FIFO
+	if r.fifo {
+		sum := sha256.Sum256([]byte(job.JobID + "@" + r.runID))
+		input.MessageGroupId = aws.String(job.JobID)
+		input.MessageDeduplicationId = aws.String(hex.EncodeToString(sum[:]))
+	}
+
+	if _, err := r.sqs.SendMessage(ctx, input); err != nil {
+		return false, fmt.Errorf("failed to send SQS message: %w", err)
+	}
+	fmt.Printf("Queued job %s for file %s/%s\n", job.JobID, job.Bucket, job.Key)
+	return true, nil
+}
+
+// parseBound parses an RFC3339 time or a 2006-01-02 date in UTC, returning
+// def when raw is empty. With endOfDay a date means its last instant, so
+// -to covers the whole day.
+func parseBound(raw string, def time.Time, endOfDay bool) (time.Time, error) {
+	if raw == "" {
+		return def, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err == nil && endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, err
+}
+
+// fail prints err and exits non-zero
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,131 @@
+// cmd/rollup/image.go
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// recordDeltas returns the rollup changes a stream record makes:
+//
+//   - INSERT adds the new result to its hour
+//   - MODIFY, a result replaced by a later run of the same job, takes the
+//     old result out of its hour and adds the new one, so retries that turn
+//     a failure into a success are not counted twice
+//   - REMOVE, such as a TTL expiry, changes nothing; rollups outlive results
+//
+// Deltas for the same hour are combined, and ones that change nothing are
+// dropped.
+func recordDeltas(record events.DynamoDBEventRecord) ([]store.RollupDelta, error) {
+	var deltas []store.RollupDelta
+	add := func(delta store.RollupDelta) {
+		for i := range deltas {
+			if deltas[i].Hour.Equal(delta.Hour) {
+				deltas[i].Add(delta)
+				return
+			}
+		}
+		deltas = append(deltas, delta)
+	}
+
+	switch record.EventName {
+	case "INSERT":
+		delta, err := imageDelta(record.Change.NewImage)
+		if err != nil {
+			return nil, err
+		}
+		add(delta)
+	case "MODIFY":
+		if record.Change.OldImage == nil {
+			return nil, fmt.Errorf("MODIFY record has no old image; the stream must use NEW_AND_OLD_IMAGES")
+		}
+		old, err := imageDelta(record.Change.OldImage)
+		if err != nil {
+			return nil, fmt.Errorf("old image: %w", err)
+		}
+		current, err := imageDelta(record.Change.NewImage)
+		if err != nil {
+			return nil, fmt.Errorf("new image: %w", err)
+		}
+		add(negate(old))
+		add(current)
+	default:
+		return nil, nil
+	}
+
+	kept := deltas[:0]
+	for _, delta := range deltas {
+		if !delta.IsZero() {
+			kept = append(kept, delta)
+		}
+	}
+	return kept, nil
+}
+
+// imageDelta returns what one stored result contributes to its hour, which
+// is taken from completed_at. Failed results only count as failed files.
+// Results written before total_response_time_ms was stored only carry the
+// average, which is weighted by line_count instead.
+func imageDelta(image map[string]events.DynamoDBAttributeValue) (store.RollupDelta, error) {
+	completed, err := time.Parse(time.RFC3339Nano, stringAttr(image, "completed_at"))
+	if err != nil {
+		return store.RollupDelta{}, fmt.Errorf("invalid completed_at: %w", err)
+	}
+	delta := store.RollupDelta{Hour: completed.UTC().Truncate(time.Hour)}
+
+	if models.Status(stringAttr(image, "status")) == models.StatusFailed {
+		delta.FailedFiles = 1
+		return delta, nil
+	}
+
+	lines := int(numberAttr(image, "line_count"))
+	delta.Files = 1
+	delta.Lines = lines
+	delta.Errors = int(numberAttr(image, "error_count"))
+	delta.ResponseLines = int(numberAttr(image, "aggregated_line_count"))
+	delta.TotalResponseMs = numberAttr(image, "total_response_time_ms")
+	if avg := numberAttr(image, "avg_response_time_ms"); delta.ResponseLines == 0 && avg != 0 {
+		delta.ResponseLines = lines
+		delta.TotalResponseMs = avg * float64(lines)
+	}
+	return delta, nil
+}
+
+// stringAttr returns a string attribute, or "" when it is missing or not a string
+func stringAttr(image map[string]events.DynamoDBAttributeValue, name string) string {
+	av, ok := image[name]
+	if !ok || av.DataType() != events.DataTypeString {
+		return ""
+	}
+	return av.String()
+}
+
+// numberAttr returns a number attribute, or 0 when it is missing or not a
+// number; omitempty fields are absent when zero
+func numberAttr(image map[string]events.DynamoDBAttributeValue, name string) float64 {
+	av, ok := image[name]
+	if !ok || av.DataType() != events.DataTypeNumber {
+		return 0
+	}
+	n, _ := strconv.ParseFloat(av.Number(), 64)
+	return n
+}
+
+// negate returns the delta that takes d back out
+func negate(d store.RollupDelta) store.RollupDelta {
+	return store.RollupDelta{
+		Hour:            d.Hour,
+		Files:           -d.Files,
+		FailedFiles:     -d.FailedFiles,
+		Lines:           -d.Lines,
+		Errors:          -d.Errors,
+		ResponseLines:   -d.ResponseLines,
+		TotalResponseMs: -d.TotalResponseMs,
+	}
+}
@@ -0,0 +1,177 @@
+// cmd/rollup/image_test.go
+package main
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"event-pipeline/internal/store"
+)
+
+// Package-level initializers run before init, which needs a table and a
+// region
+var _ = func() bool {
+	os.Setenv("ROLLUP_TABLE", "rollups")
+	os.Setenv("AWS_REGION", "us-east-1")
+	return true
+}()
+
+// testHour is the hour every test image completes in
+var testHour = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// image builds a stored result's stream image from its status and number
+// attributes, completed in testHour
+func image(status string, numbers map[string]float64) map[string]events.DynamoDBAttributeValue {
+	img := map[string]events.DynamoDBAttributeValue{
+		"job_id":       events.NewStringAttribute("job-1"),
+		"status":       events.NewStringAttribute(status),
+		"completed_at": events.NewStringAttribute(testHour.Add(25 * time.Minute).Format(time.RFC3339Nano)),
+	}
+	for name, n := range numbers {
+		img[name] = events.NewNumberAttribute(strconv.FormatFloat(n, 'f', -1, 64))
+	}
+	return img
+}
+
+// completed is a result of 100 lines, 10 of them sampled-out DEBUG
+// entries, whose 90 timed entries took 4500ms in total
+var completed = image("completed", map[string]float64{
+	"line_count":             100,
+	"error_count":            3,
+	"avg_response_time_ms":   50,
+	"total_response_time_ms": 4500,
+	"aggregated_line_count":  90,
+})
+
+func TestImageDelta(t *testing.T) {
+	tests := []struct {
+		name  string
+		image map[string]events.DynamoDBAttributeValue
+		want  store.RollupDelta
+	}{
+		{
+			// The stored total is used as is, not the average times line_count
+			name:  "completed",
+			image: completed,
+			want:  store.RollupDelta{Hour: testHour, Files: 1, Lines: 100, Errors: 3, ResponseLines: 90, TotalResponseMs: 4500},
+		},
+		{
+			name: "without totals",
+			image: image("completed", map[string]float64{
+				"line_count":           100,
+				"avg_response_time_ms": 50,
+			}),
+			want: store.RollupDelta{Hour: testHour, Files: 1, Lines: 100, ResponseLines: 100, TotalResponseMs: 5000},
+		},
+		{
+			name:  "no timed entries",
+			image: image("completed", map[string]float64{"line_count": 5}),
+			want:  store.RollupDelta{Hour: testHour, Files: 1, Lines: 5},
+		},
+		{
+			name:  "failed",
+			image: image("failed", map[string]float64{"line_count": 100, "total_response_time_ms": 4500}),
+			want:  store.RollupDelta{Hour: testHour, FailedFiles: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imageDelta(tt.image)
+			if err != nil {
+				t.Fatalf("imageDelta: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("delta = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDeltaRejectsBadTimestamp(t *testing.T) {
+	img := image("completed", nil)
+	img["completed_at"] = events.NewStringAttribute("yesterday")
+	if _, err := imageDelta(img); err == nil {
+		t.Error("imageDelta accepted an invalid completed_at")
+	}
+}
+
+// record is a stream record with the given images
+func record(name string, oldImage, newImage map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	var r events.DynamoDBEventRecord
+	r.EventID = "event-1"
+	r.EventName = name
+	r.Change.OldImage = oldImage
+	r.Change.NewImage = newImage
+	return r
+}
+
+func TestRecordDeltas(t *testing.T) {
+	failed := image("failed", nil)
+
+	tests := []struct {
+		name   string
+		record events.DynamoDBEventRecord
+		want   []store.RollupDelta
+	}{
+		{
+			name:   "insert",
+			record: record("INSERT", nil, completed),
+			want:   []store.RollupDelta{{Hour: testHour, Files: 1, Lines: 100, Errors: 3, ResponseLines: 90, TotalResponseMs: 4500}},
+		},
+		{
+			// A retry that succeeded takes the failure back out
+			name:   "modify",
+			record: record("MODIFY", failed, completed),
+			want:   []store.RollupDelta{{Hour: testHour, Files: 1, FailedFiles: -1, Lines: 100, Errors: 3, ResponseLines: 90, TotalResponseMs: 4500}},
+		},
+		{
+			// Replacing a success with a failure negates every counter
+			name:   "modify to failed",
+			record: record("MODIFY", completed, failed),
+			want:   []store.RollupDelta{{Hour: testHour, Files: -1, FailedFiles: 1, Lines: -100, Errors: -3, ResponseLines: -90, TotalResponseMs: -4500}},
+		},
+		{
+			name:   "unchanged",
+			record: record("MODIFY", completed, completed),
+		},
+		{
+			name:   "remove",
+			record: record("REMOVE", completed, nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := recordDeltas(tt.record)
+			if err != nil {
+				t.Fatalf("recordDeltas: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("deltas = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("delta %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestModifyNeedsOldImage(t *testing.T) {
+	if _, err := recordDeltas(record("MODIFY", nil, completed)); err == nil {
+		t.Error("recordDeltas accepted a MODIFY without an old image")
+	}
+}
+
+func TestNegate(t *testing.T) {
+	d := store.RollupDelta{Hour: testHour, Files: 1, FailedFiles: 2, Lines: 3, Errors: 4, ResponseLines: 5, TotalResponseMs: 6.5}
+	sum := d
+	sum.Add(negate(d))
+	if !sum.IsZero() {
+		t.Errorf("delta plus its negation = %+v, want zero", sum)
+	}
+}
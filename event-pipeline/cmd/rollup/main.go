@@ -0,0 +1,75 @@
+// cmd/rollup/main.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"event-pipeline/internal/store"
+)
+
+var rollupStore *store.RollupStore
+
+func init() {
+	ctx := context.Background()
+
+	table := os.Getenv("ROLLUP_TABLE")
+	if table == "" {
+		panic("ROLLUP_TABLE is not set")
+	}
+
+	var err error
+	rollupStore, err = store.NewRollupStore(ctx, table)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create rollup store: %v", err))
+	}
+}
+
+// handler folds a batch of results-table stream records into hourly
+// rollups. Records are applied in order; on the first failure the rest are
+// reported as failed from that record on, so the stream retries them and
+// already applied records are skipped by their dedup markers.
+func handler(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	var response events.DynamoDBEventResponse
+	applied, duplicates := 0, 0
+
+	for _, record := range event.Records {
+		deltas, err := recordDeltas(record)
+		if err != nil {
+			// A malformed image will never parse, so retrying can't help
+			fmt.Printf("Skipping stream record %s: %v\n", record.EventID, err)
+			continue
+		}
+		if len(deltas) == 0 {
+			continue
+		}
+
+		err = rollupStore.Apply(ctx, record.EventID, deltas, time.Now())
+		switch {
+		case err == nil:
+			applied++
+		case errors.Is(err, store.ErrDuplicateEvent):
+			duplicates++
+		default:
+			fmt.Printf("Error applying stream record %s: %v\n", record.EventID, err)
+			response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+				ItemIdentifier: record.Change.SequenceNumber,
+			})
+			fmt.Printf("Applied %d records (%d duplicates) before the failure\n", applied, duplicates)
+			return response, nil
+		}
+	}
+
+	fmt.Printf("Applied %d of %d stream records (%d duplicates)\n", applied, len(event.Records), duplicates)
+	return response, nil
+}
+
+func main() {
+	lambda.Start(handler)
+}
@@ -0,0 +1,37 @@
+// cmd/trigger/dedupe.go
+package main
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// recordKey identifies one S3 event. S3 gives every write to an object its
+// own sequencer (and, in versioned buckets, version ID), so a record that
+// matches on all four is a redelivery of the same event.
+type recordKey struct {
+	bucket, key, versionID, sequencer string
+}
+
+// dedupeRecords drops records repeating an earlier one in the batch,
+// keeping the first occurrence and the original order
+func dedupeRecords(records []events.S3EventRecord) []events.S3EventRecord {
+	seen := make(map[recordKey]struct{}, len(records))
+	unique := make([]events.S3EventRecord, 0, len(records))
+	for _, record := range records {
+		k := recordKey{
+			bucket:    record.S3.Bucket.Name,
+			key:       record.S3.Object.Key,
+			versionID: record.S3.Object.VersionID,
+			sequencer: record.S3.Object.Sequencer,
+		}
+		if _, dup := seen[k]; dup {
+			fmt.Printf("Skipping duplicate event for %s/%s (sequencer %s)\n", k.bucket, k.key, k.sequencer)
+			continue
+		}
+		seen[k] = struct{}{}
+		unique = append(unique, record)
+	}
+	return unique
+}
@@ -0,0 +1,47 @@
+// cmd/trigger/dedupe_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestDedupeRecords(t *testing.T) {
+	a := s3Record("logs-bucket", "logs/test_a_1.json")
+	b := s3Record("logs-bucket", "logs/test_b_1.json")
+	// A re-upload of the same key is a new event with a later sequencer
+	reupload := a
+	reupload.S3.Object.Sequencer = "0055AED6DCD90281F0"
+
+	got := dedupeRecords([]events.S3EventRecord{a, b, a, reupload, b})
+	if len(got) != 3 {
+		t.Fatalf("kept %d records, want 3", len(got))
+	}
+	if got[0].S3.Object.Key != a.S3.Object.Key || got[1].S3.Object.Key != b.S3.Object.Key || got[2].S3.Object.Sequencer != reupload.S3.Object.Sequencer {
+		t.Errorf("kept %+v, want a, b and the re-upload in order", got)
+	}
+}
+
+func TestHandlerSendsDuplicateRecordOnce(t *testing.T) {
+	fs3, fsqs, _ := stubTrigger(t)
+	fs3.objects["logs/test_abc_1.json"] = fakeObject{size: 100, contentType: "application/json"}
+
+	record := s3Record("logs-bucket", "logs/test_abc_1.json")
+	payload, err := json.Marshal(events.S3Event{Records: []events.S3EventRecord{record, record}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handler(context.Background(), payload); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	if sent := fsqs.sent(); len(sent) != 1 {
+		t.Errorf("sent %d messages, want 1", len(sent))
+	}
+	if len(fs3.heads) != 1 {
+		t.Errorf("HeadObject called %d times, want 1", len(fs3.heads))
+	}
+}
@@ -0,0 +1,243 @@
+// cmd/trigger/enqueue.go
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"event-pipeline/internal/metrics"
+	"event-pipeline/internal/models"
+)
+
+const (
+	// maxBatchEntries is the SQS limit for SendMessageBatch
+	maxBatchEntries = 10
+
+	// maxBatchBytes is the SQS limit on the total size of a
+	// SendMessageBatch call, counting every body and attribute
+	maxBatchBytes = 256 * 1024
+
+	// maxSendAttempts bounds how often a failed batch entry is re-sent
+	maxSendAttempts = 3
+)
+
+// sendRetryBaseDelay is the initial backoff before re-sending failed
+// batch entries, doubled on each attempt
+var sendRetryBaseDelay = 100 * time.Millisecond
+
+// queuedMessage is a job ready to send, with its message body: the job
+// itself, or a pointer to it when it was staged
+type queuedMessage struct {
+	job  models.ProcessingJob
+	body []byte
+}
+
+// size is what the message counts against maxBatchBytes: its body plus
+// the JobID attribute's name, type and value
+func (m queuedMessage) size() int {
+	return len(m.body) + len("JobID") + len("String") + len(m.job.JobID)
+}
+
+// enqueueJobs sends jobs to their queue (see queueFor) in batches of up to
+// maxBatchEntries messages and maxBatchBytes
+func enqueueJobs(ctx context.Context, jobs []models.ProcessingJob) {
+	if dryRun {
+		for _, job := range jobs {
+			jobBytes, _ := json.Marshal(job)
+			if len(jobBytes) > maxInlineJobBytes {
+				fmt.Printf("[DRY RUN] Would stage job %s (%d bytes) under %s\n", job.JobID, len(jobBytes), stagingPrefix)
+				continue
+			}
+			fmt.Printf("[DRY RUN] Would queue job %s: %s\n", job.JobID, jobBytes)
+		}
+		return
+	}
+
+	// Group by queue, keeping the event order within each
+	var queues []string
+	byQueue := make(map[string][]models.ProcessingJob)
+	for _, job := range jobs {
+		url := queueFor(job)
+		if _, ok := byQueue[url]; !ok {
+			queues = append(queues, url)
+		}
+		byQueue[url] = append(byQueue[url], job)
+	}
+
+	for _, url := range queues {
+		for _, batch := range splitBatches(prepareMessages(ctx, byQueue[url])) {
+			sendBatch(ctx, url, batch)
+		}
+	}
+}
+
+// prepareMessages marshals each job into its message body. Jobs too large
+// for SQS, such as long manifests, are staged in S3 and sent as a pointer.
+// Jobs that can't be prepared are reported and left out.
+func prepareMessages(ctx context.Context, jobs []models.ProcessingJob) []queuedMessage {
+	messages := make([]queuedMessage, 0, len(jobs))
+	for _, job := range jobs {
+		body, err := json.Marshal(job)
+		if err != nil {
+			reportSendFailure(ctx, job, fmt.Errorf("failed to marshal job: %w", err))
+			continue
+		}
+		if len(body) > maxInlineJobBytes {
+			if body, err = stageJob(ctx, job, body); err != nil {
+				reportSendFailure(ctx, job, err)
+				continue
+			}
+		}
+		messages = append(messages, queuedMessage{job: job, body: body})
+	}
+	return messages
+}
+
+// splitBatches groups messages in order into batches of at most
+// maxBatchEntries messages whose sizes add up to at most maxBatchBytes. A
+// message that would push a batch over either limit starts the next one.
+func splitBatches(messages []queuedMessage) [][]queuedMessage {
+	var batches [][]queuedMessage
+	var batch []queuedMessage
+	batchBytes := 0
+	for _, msg := range messages {
+		if len(batch) == maxBatchEntries || (len(batch) > 0 && batchBytes+msg.size() > maxBatchBytes) {
+			batches = append(batches, batch)
+			batch, batchBytes = nil, 0
+		}
+		batch = append(batch, msg)
+		batchBytes += msg.size()
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// sendBatch sends a single batch, re-sending only the entries SQS reports
+// as failed so successful entries are never duplicated
+func sendBatch(ctx context.Context, url string, messages []queuedMessage) {
+	pending := make(map[string]models.ProcessingJob, len(messages))
+	entries := make([]types.SendMessageBatchRequestEntry, 0, len(messages))
+
+	for i, msg := range messages {
+		job := msg.job
+		id := strconv.Itoa(i)
+		pending[id] = job
+		entry := types.SendMessageBatchRequestEntry{
+			Id:          aws.String(id),
+			MessageBody: aws.String(string(msg.body)),
+			MessageAttributes: map[string]types.MessageAttributeValue{
+				"JobID": {
+					DataType:    aws.String("String"),
+					StringValue: aws.String(job.JobID),
+				},
+			},
+		}
+		// Standard queues reject these parameters, so only set them for FIFO
+		if fifoQueue {
+			entry.MessageGroupId = aws.String(job.JobID)
+			entry.MessageDeduplicationId = aws.String(deduplicationID(job))
+		}
+		entries = append(entries, entry)
+	}
+
+	if metricsCollector != nil && len(entries) > 0 {
+		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+			"TriggerBatchSize": metrics.Count(float64(len(entries))),
+		})
+	}
+
+	queued := 0
+	sendErr := fmt.Errorf("failed to send SQS message after %d attempts", maxSendAttempts)
+	for attempt := 1; attempt <= maxSendAttempts && len(entries) > 0; attempt++ {
+		if attempt > 1 {
+			delay := rand.N(sendRetryBaseDelay<<(attempt-2) + 1)
+			fmt.Printf("Retrying %d SQS messages in %v\n", len(entries), delay)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+			if ctx.Err() != nil {
+				sendErr = errors.Join(fmt.Errorf("failed to send SQS message after %d attempts", attempt-1), ctx.Err())
+				break
+			}
+		}
+
+		resp, err := sqsClient.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: aws.String(url),
+			Entries:  entries,
+		})
+		if err != nil {
+			// The whole call failed, so every entry is still pending
+			fmt.Printf("SendMessageBatch attempt %d failed: %v\n", attempt, err)
+			continue
+		}
+
+		for _, ok := range resp.Successful {
+			job := pending[aws.ToString(ok.Id)]
+			delete(pending, aws.ToString(ok.Id))
+			queued++
+			fmt.Printf("Queued job %s for file %s/%s\n", job.JobID, job.Bucket, job.Key)
+		}
+
+		// Keep only entries that failed for a retryable reason
+		byID := make(map[string]types.SendMessageBatchRequestEntry, len(entries))
+		for _, entry := range entries {
+			byID[aws.ToString(entry.Id)] = entry
+		}
+		retry := make([]types.SendMessageBatchRequestEntry, 0, len(resp.Failed))
+		for _, failed := range resp.Failed {
+			id := aws.ToString(failed.Id)
+			if failed.SenderFault {
+				reportSendFailure(ctx, pending[id], fmt.Errorf("SQS rejected message: %s", aws.ToString(failed.Message)))
+				delete(pending, id)
+				continue
+			}
+			retry = append(retry, byID[id])
+		}
+		entries = retry
+	}
+
+	for _, job := range pending {
+		reportSendFailure(ctx, job, sendErr)
+	}
+
+	if metricsCollector != nil && queued > 0 {
+		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+			"TriggerInvocations": metrics.Count(float64(queued)),
+		})
+	}
+}
+
+// deduplicationID identifies the object version a job describes, so repeated
+// S3 events for the same upload are dropped by a FIFO queue. Re-uploads get a
+// new ETag and are queued again.
+func deduplicationID(job models.ProcessingJob) string {
+	sum := sha256.Sum256([]byte(job.Bucket + "/" + job.Key + "@" + job.ETag))
+	return hex.EncodeToString(sum[:])
+}
+
+// reportSendFailure logs a job that could not be queued and counts it
+func reportSendFailure(ctx context.Context, job models.ProcessingJob, err error) {
+	fmt.Printf("Error queuing job %s for file %s/%s: %v\n", job.JobID, job.Bucket, job.Key, err)
+	if metricsCollector != nil {
+		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+			"TriggerFailures": metrics.Count(1),
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// cmd/trigger/enqueue_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"event-pipeline/internal/models"
+)
+
+// withFIFO sets fifoQueue until the test ends
+func withFIFO(t *testing.T, fifo bool) {
+	t.Helper()
+	prev := fifoQueue
+	fifoQueue = fifo
+	t.Cleanup(func() { fifoQueue = prev })
+}
+
+// withSendRetryDelay sets sendRetryBaseDelay until the test ends
+func withSendRetryDelay(t *testing.T, delay time.Duration) {
+	t.Helper()
+	prev := sendRetryBaseDelay
+	sendRetryBaseDelay = delay
+	t.Cleanup(func() { sendRetryBaseDelay = prev })
+}
+
+func TestSendBatchRetries(t *testing.T) {
+	job := models.ProcessingJob{JobID: "abc", Bucket: "logs-bucket", Key: "logs/test_abc_1.json"}
+	unavailable := errors.New("service unavailable")
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantCalls    int
+		wantQueued   float64
+		wantFailures float64
+	}{
+		{name: "first attempt", failures: 0, wantCalls: 1, wantQueued: 1},
+		{name: "succeeds on retry", failures: maxSendAttempts - 1, wantCalls: maxSendAttempts, wantQueued: 1},
+		{name: "gives up", failures: maxSendAttempts, wantCalls: maxSendAttempts, wantFailures: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, fsqs, fmetrics := stubTrigger(t)
+			withSendRetryDelay(t, 0)
+			fsqs.sendFn = func(call int, params *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+				if call <= tt.failures {
+					return nil, unavailable
+				}
+				out := &sqs.SendMessageBatchOutput{}
+				for _, entry := range params.Entries {
+					out.Successful = append(out.Successful, sqstypes.SendMessageBatchResultEntry{Id: entry.Id})
+				}
+				return out, nil
+			}
+
+			sendBatch(context.Background(), testQueueURL, prepareMessages(context.Background(), []models.ProcessingJob{job}))
+
+			if len(fsqs.calls) != tt.wantCalls {
+				t.Errorf("sent %d calls, want %d", len(fsqs.calls), tt.wantCalls)
+			}
+			if got := fmetrics.value("TriggerInvocations"); got != tt.wantQueued {
+				t.Errorf("TriggerInvocations = %v, want %v", got, tt.wantQueued)
+			}
+			if got := fmetrics.value("TriggerFailures"); got != tt.wantFailures {
+				t.Errorf("TriggerFailures = %v, want %v", got, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestSendBatchBackoffStopsWhenCanceled(t *testing.T) {
+	_, fsqs, fmetrics := stubTrigger(t)
+	withSendRetryDelay(t, time.Hour)
+	fsqs.sendFn = func(call int, params *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+		return nil, errors.New("service unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	job := models.ProcessingJob{JobID: "abc", Bucket: "logs-bucket", Key: "logs/test_abc_1.json"}
+
+	start := time.Now()
+	sendBatch(ctx, testQueueURL, prepareMessages(context.Background(), []models.ProcessingJob{job}))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("sendBatch took %v, want it to stop waiting once the context ends", elapsed)
+	}
+	if len(fsqs.calls) != 1 {
+		t.Errorf("sent %d calls, want 1", len(fsqs.calls))
+	}
+	if got := fmetrics.value("TriggerFailures"); got != 1 {
+		t.Errorf("TriggerFailures = %v, want 1", got)
+	}
+}
+
+func TestSendBatchFIFOParameters(t *testing.T) {
+	job := models.ProcessingJob{JobID: "abc", Bucket: "logs-bucket", Key: "logs/test_abc_1.json", ETag: `"etag"`}
+
+	for _, fifo := range []bool{false, true} {
+		name := "standard"
+		if fifo {
+			name = "fifo"
+		}
+		t.Run(name, func(t *testing.T) {
+			_, fsqs, _ := stubTrigger(t)
+			withFIFO(t, fifo)
+
+			sendBatch(context.Background(), testQueueURL, prepareMessages(context.Background(), []models.ProcessingJob{job}))
+
+			if len(fsqs.calls) != 1 || len(fsqs.calls[0].Entries) != 1 {
+				t.Fatalf("sent %d calls, want one call with one entry", len(fsqs.calls))
+			}
+			entry := fsqs.calls[0].Entries[0]
+			if !fifo {
+				if entry.MessageGroupId != nil || entry.MessageDeduplicationId != nil {
+					t.Errorf("standard queue entry has group %q dedup %q, want neither",
+						aws.ToString(entry.MessageGroupId), aws.ToString(entry.MessageDeduplicationId))
+				}
+				return
+			}
+			if got := aws.ToString(entry.MessageGroupId); got != job.JobID {
+				t.Errorf("MessageGroupId = %q, want %q", got, job.JobID)
+			}
+			if got := aws.ToString(entry.MessageDeduplicationId); got != deduplicationID(job) {
+				t.Errorf("MessageDeduplicationId = %q, want %q", got, deduplicationID(job))
+			}
+		})
+	}
+}
+
+func TestDeduplicationIDFollowsETag(t *testing.T) {
+	job := models.ProcessingJob{JobID: "abc", Bucket: "logs-bucket", Key: "logs/test_abc_1.json", ETag: `"v1"`}
+	reupload := job
+	reupload.ETag = `"v2"`
+
+	if deduplicationID(job) != deduplicationID(job) {
+		t.Error("deduplicationID is not stable for the same object version")
+	}
+	if deduplicationID(job) == deduplicationID(reupload) {
+		t.Error("a re-upload with a new ETag got the same deduplication ID")
+	}
+}
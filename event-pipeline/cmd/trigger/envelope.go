@@ -0,0 +1,105 @@
+// cmd/trigger/envelope.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Notification transports the trigger can receive S3 events through
+const (
+	sourceAuto = "auto"
+	sourceS3   = "aws:s3"
+	sourceSNS  = "aws:sns"
+	sourceSQS  = "aws:sqs"
+)
+
+// recordSource peeks at the event source of each record. encoding/json
+// matches keys case-insensitively, so this covers both S3/SQS
+// "eventSource" and SNS "EventSource".
+type recordSource struct {
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+}
+
+// snsNotification is the body SQS receives from an SNS subscription
+// without raw message delivery
+type snsNotification struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+// unwrapS3Event extracts S3 records from a direct S3 event or one wrapped
+// in SNS or SQS envelopes. expected is one of the source constants; with
+// sourceAuto the transport is detected from the payload.
+func unwrapS3Event(payload []byte, expected string) (events.S3Event, error) {
+	var peek recordSource
+	if err := json.Unmarshal(payload, &peek); err != nil {
+		return events.S3Event{}, fmt.Errorf("failed to decode event: %w", err)
+	}
+
+	source := expected
+	if source == sourceAuto {
+		source = sourceS3
+		if len(peek.Records) > 0 {
+			source = peek.Records[0].EventSource
+		}
+	}
+
+	switch source {
+	case sourceS3:
+		var s3Event events.S3Event
+		if err := json.Unmarshal(payload, &s3Event); err != nil {
+			return events.S3Event{}, fmt.Errorf("failed to decode S3 event: %w", err)
+		}
+		return s3Event, nil
+
+	case sourceSNS:
+		var snsEvent events.SNSEvent
+		if err := json.Unmarshal(payload, &snsEvent); err != nil {
+			return events.S3Event{}, fmt.Errorf("failed to decode SNS event: %w", err)
+		}
+		messages := make([]string, 0, len(snsEvent.Records))
+		for _, record := range snsEvent.Records {
+			messages = append(messages, record.SNS.Message)
+		}
+		return mergeS3Events(messages)
+
+	case sourceSQS:
+		var sqsEvent events.SQSEvent
+		if err := json.Unmarshal(payload, &sqsEvent); err != nil {
+			return events.S3Event{}, fmt.Errorf("failed to decode SQS event: %w", err)
+		}
+		messages := make([]string, 0, len(sqsEvent.Records))
+		for _, record := range sqsEvent.Records {
+			// SNS -> SQS fan-out wraps the S3 event once more
+			var notification snsNotification
+			if json.Unmarshal([]byte(record.Body), &notification) == nil && notification.Type == "Notification" {
+				messages = append(messages, notification.Message)
+				continue
+			}
+			messages = append(messages, record.Body)
+		}
+		return mergeS3Events(messages)
+	}
+
+	return events.S3Event{}, fmt.Errorf("unsupported event source %q", source)
+}
+
+// mergeS3Events decodes JSON-encoded S3 events and concatenates their records.
+// Messages without records, such as the s3:TestEvent S3 sends when a
+// notification is configured, contribute nothing.
+func mergeS3Events(messages []string) (events.S3Event, error) {
+	var merged events.S3Event
+	for _, message := range messages {
+		var s3Event events.S3Event
+		if err := json.Unmarshal([]byte(message), &s3Event); err != nil {
+			return events.S3Event{}, fmt.Errorf("failed to decode wrapped S3 event: %w", err)
+		}
+		merged.Records = append(merged.Records, s3Event.Records...)
+	}
+	return merged, nil
+}
@@ -0,0 +1,118 @@
+// cmd/trigger/envelope_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// s3EventJSON is a direct S3 notification for logs/test_abc_1.json
+const s3EventJSON = `{"Records":[{"eventSource":"aws:s3","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"logs-bucket"},"object":{"key":"logs/test_abc_1.json","size":100,"sequencer":"0A1"}}}]}`
+
+// quoted returns s as a JSON string literal, as SNS and SQS carry it
+func quoted(t *testing.T, s string) string {
+	t.Helper()
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestUnwrapS3Event(t *testing.T) {
+	snsNotification := `{"Type":"Notification","MessageId":"m1","Message":` + quoted(t, s3EventJSON) + `}`
+
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+		wantKeys int
+		wantErr  bool
+	}{
+		{name: "direct S3", payload: s3EventJSON, expected: sourceAuto, wantKeys: 1},
+		{
+			name:     "SNS wrapped",
+			payload:  `{"Records":[{"EventSource":"aws:sns","Sns":{"Type":"Notification","Message":` + quoted(t, s3EventJSON) + `}}]}`,
+			expected: sourceAuto,
+			wantKeys: 1,
+		},
+		{
+			name:     "SQS wrapped",
+			payload:  `{"Records":[{"eventSource":"aws:sqs","messageId":"1","body":` + quoted(t, s3EventJSON) + `}]}`,
+			expected: sourceAuto,
+			wantKeys: 1,
+		},
+		{
+			name:     "SNS to SQS fan-out",
+			payload:  `{"Records":[{"eventSource":"aws:sqs","messageId":"1","body":` + quoted(t, snsNotification) + `}]}`,
+			expected: sourceAuto,
+			wantKeys: 1,
+		},
+		{
+			name:     "configured SNS source",
+			payload:  `{"Records":[{"EventSource":"aws:sns","Sns":{"Message":` + quoted(t, s3EventJSON) + `}},{"EventSource":"aws:sns","Sns":{"Message":` + quoted(t, s3EventJSON) + `}}]}`,
+			expected: sourceSNS,
+			wantKeys: 2,
+		},
+		{
+			name:     "S3 test event",
+			payload:  `{"Records":[{"EventSource":"aws:sns","Sns":{"Message":"{\"Service\":\"Amazon S3\",\"Event\":\"s3:TestEvent\"}"}}]}`,
+			expected: sourceAuto,
+		},
+		{
+			name:     "garbage wrapped message",
+			payload:  `{"Records":[{"EventSource":"aws:sns","Sns":{"Message":"not json"}}]}`,
+			expected: sourceAuto,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown source",
+			payload:  `{"Records":[{"eventSource":"aws:kinesis"}]}`,
+			expected: sourceAuto,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unwrapS3Event([]byte(tt.payload), tt.expected)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("unwrapS3Event succeeded with %d records, want error", len(got.Records))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unwrapS3Event: %v", err)
+			}
+			if len(got.Records) != tt.wantKeys {
+				t.Fatalf("got %d records, want %d", len(got.Records), tt.wantKeys)
+			}
+			for _, record := range got.Records {
+				assertTestRecord(t, record)
+			}
+		})
+	}
+}
+
+func assertTestRecord(t *testing.T, record events.S3EventRecord) {
+	t.Helper()
+	if record.S3.Bucket.Name != "logs-bucket" || record.S3.Object.Key != "logs/test_abc_1.json" {
+		t.Errorf("record for %s/%s, want logs-bucket/logs/test_abc_1.json", record.S3.Bucket.Name, record.S3.Object.Key)
+	}
+}
+
+func TestHandlerQueuesSNSWrappedEvent(t *testing.T) {
+	fs3, fsqs, _ := stubTrigger(t)
+	fs3.objects["logs/test_abc_1.json"] = fakeObject{size: 100, contentType: "application/json"}
+
+	payload := `{"Records":[{"EventSource":"aws:sns","Sns":{"Message":` + quoted(t, s3EventJSON) + `}}]}`
+	if err := handler(context.Background(), json.RawMessage(payload)); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if sent := fsqs.sent(); len(sent) != 1 {
+		t.Errorf("sent %d messages, want 1", len(sent))
+	}
+}
@@ -0,0 +1,35 @@
+// cmd/trigger/extension.go
+package main
+
+import "strings"
+
+// defaultAcceptedExtensions is used when ACCEPTED_EXTENSIONS is unset
+const defaultAcceptedExtensions = ".json,.jsonl"
+
+// parseExtensions splits a comma-separated ACCEPTED_EXTENSIONS into
+// lowercased extensions, adding a missing leading dot and dropping blanks
+func parseExtensions(raw string) []string {
+	var exts []string
+	for _, ext := range strings.Split(raw, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// extensionAccepted reports whether key ends with one of exts, ignoring case
+func extensionAccepted(key string, exts []string) bool {
+	key = strings.ToLower(key)
+	for _, ext := range exts {
+		if strings.HasSuffix(key, ext) {
+			return true
+		}
+	}
+	return false
+}
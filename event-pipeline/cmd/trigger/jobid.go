@@ -0,0 +1,61 @@
+// cmd/trigger/jobid.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// defaultJobIDPattern matches the "logs/test_{test_id}_{timestamp}.json" layout
+const defaultJobIDPattern = `^logs/test_(?P<id>[^_]+)_`
+
+// compileJobIDPattern compiles a key pattern and checks it has an "id" group
+func compileJobIDPattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.SubexpIndex("id") < 0 {
+		return nil, fmt.Errorf("pattern %q has no named \"id\" capture group", pattern)
+	}
+	return re, nil
+}
+
+// extractJobID derives the job ID from an object key using the configured
+// pattern, falling back to a hash of the key when allowed
+func extractJobID(key string) (string, error) {
+	if match := jobIDPattern.FindStringSubmatch(key); match != nil {
+		if id := match[jobIDPattern.SubexpIndex("id")]; id != "" {
+			return id, nil
+		}
+	}
+
+	if !allowFallbackJobID {
+		return "", fmt.Errorf("could not extract job ID from key: %s", key)
+	}
+
+	// Same key always yields the same ID, so reprocessing stays idempotent
+	sum := sha256.Sum256([]byte(key))
+	id := "key-" + hex.EncodeToString(sum[:8])
+	fmt.Printf("Key %s doesn't match job ID pattern, using fallback ID %s\n", key, id)
+	return id, nil
+}
+
+// uniqueJobID appends a suffix identifying this upload to id, so two files
+// whose keys yield the same token get separate results. The suffix hashes
+// the key with the event's sequencer, which S3 keeps across redeliveries of
+// one event but changes for each new upload, so duplicate deliveries still
+// share a job ID. Events without a sequencer use the event time instead.
+func uniqueJobID(id, key string, record events.S3EventRecord) string {
+	token := record.S3.Object.Sequencer
+	if token == "" {
+		token = record.EventTime.UTC().Format(time.RFC3339Nano)
+	}
+	sum := sha256.Sum256([]byte(key + "@" + token))
+	return id + "-" + hex.EncodeToString(sum[:6])
+}
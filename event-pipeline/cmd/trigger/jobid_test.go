@@ -0,0 +1,140 @@
+// cmd/trigger/jobid_test.go
+package main
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// withJobIDConfig sets the job ID pattern and fallback until the test ends
+func withJobIDConfig(t *testing.T, pattern string, fallback bool) {
+	t.Helper()
+	re, err := compileJobIDPattern(pattern)
+	if err != nil {
+		t.Fatalf("compileJobIDPattern: %v", err)
+	}
+	prevPattern, prevFallback := jobIDPattern, allowFallbackJobID
+	jobIDPattern, allowFallbackJobID = re, fallback
+	t.Cleanup(func() {
+		jobIDPattern, allowFallbackJobID = prevPattern, prevFallback
+	})
+}
+
+func TestExtractJobID(t *testing.T) {
+	const custom = `^tenants/[^/]+/runs/(?P<id>[0-9a-f-]+)/`
+
+	tests := []struct {
+		name     string
+		pattern  string
+		fallback bool
+		key      string
+		want     string
+		wantErr  bool
+	}{
+		{name: "default pattern", pattern: defaultJobIDPattern, key: "logs/test_abc123_20240115.json", want: "abc123"},
+		{name: "default pattern mismatch", pattern: defaultJobIDPattern, key: "logs/other.json", wantErr: true},
+		{name: "custom pattern", pattern: custom, key: "tenants/acme/runs/4f2a-99/app.json", want: "4f2a-99"},
+		{name: "custom pattern mismatch", pattern: custom, key: "logs/test_abc123_20240115.json", wantErr: true},
+		// The first 8 bytes of sha256("uploads/app.json")
+		{name: "fallback hash", pattern: defaultJobIDPattern, fallback: true, key: "uploads/app.json", want: "key-d89eb4505060f6ec"},
+		{name: "fallback unused on match", pattern: defaultJobIDPattern, fallback: true, key: "logs/test_abc123_1.json", want: "abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withJobIDConfig(t, tt.pattern, tt.fallback)
+
+			got, err := extractJobID(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("extractJobID(%q) = %q, want error", tt.key, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("extractJobID(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackJobIDIsStable(t *testing.T) {
+	withJobIDConfig(t, defaultJobIDPattern, true)
+
+	first, _ := extractJobID("uploads/app.json")
+	second, _ := extractJobID("uploads/app.json")
+	other, _ := extractJobID("uploads/other.json")
+	if first != second {
+		t.Errorf("same key gave %q then %q", first, second)
+	}
+	if first == other {
+		t.Errorf("different keys share fallback ID %q", first)
+	}
+	if !regexp.MustCompile(`^key-[0-9a-f]{16}$`).MatchString(first) {
+		t.Errorf("fallback ID %q is not key- and 16 hex digits", first)
+	}
+}
+
+func TestCompileJobIDPatternRequiresIDGroup(t *testing.T) {
+	if _, err := compileJobIDPattern(`^logs/test_([^_]+)_`); err == nil {
+		t.Error("pattern without an id group compiled")
+	}
+	if _, err := compileJobIDPattern(`^logs/(`); err == nil {
+		t.Error("invalid regex compiled")
+	}
+}
+
+func TestUniqueJobIDs(t *testing.T) {
+	const (
+		keyA = "logs/test_run1_a.json"
+		keyB = "logs/test_run1_b.json"
+	)
+
+	tests := []struct {
+		name         string
+		unique       bool
+		keyA, keyB   string
+		seqA, seqB   string
+		wantSameID   bool
+		wantSuffixed bool
+	}{
+		// Both keys yield the token "run1"
+		{name: "shared token", keyA: keyA, keyB: keyB, seqA: "01", seqB: "02", wantSameID: true},
+		{name: "shared token unique", unique: true, keyA: keyA, keyB: keyB, seqA: "01", seqB: "02", wantSuffixed: true},
+		// S3 redelivers an event with the same sequencer
+		{name: "redelivery unique", unique: true, keyA: keyA, keyB: keyA, seqA: "01", seqB: "01", wantSameID: true, wantSuffixed: true},
+		{name: "overwrite unique", unique: true, keyA: keyA, keyB: keyA, seqA: "01", seqB: "02", wantSuffixed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, _, _ := stubTrigger(t)
+			withJobIDConfig(t, defaultJobIDPattern, false)
+			prev := uniqueJobIDs
+			uniqueJobIDs = tt.unique
+			t.Cleanup(func() { uniqueJobIDs = prev })
+			fs3.objects[keyA] = fakeObject{size: 100, contentType: "application/json"}
+			fs3.objects[keyB] = fakeObject{size: 100, contentType: "application/json"}
+
+			var ids []string
+			for _, upload := range []struct{ key, seq string }{{tt.keyA, tt.seqA}, {tt.keyB, tt.seqB}} {
+				record := s3Record("logs-bucket", upload.key)
+				record.S3.Object.Sequencer = upload.seq
+				job, err := processRecord(context.Background(), record)
+				if err != nil || job == nil {
+					t.Fatalf("processRecord(%s) = %v, %v", upload.key, job, err)
+				}
+				ids = append(ids, job.JobID)
+			}
+
+			if (ids[0] == ids[1]) != tt.wantSameID {
+				t.Errorf("job IDs %q and %q, want equal %v", ids[0], ids[1], tt.wantSameID)
+			}
+			for _, id := range ids {
+				if strings.HasPrefix(id, "run1-") != tt.wantSuffixed || !strings.HasPrefix(id, "run1") {
+					t.Errorf("job ID %q, want token run1 suffixed %v", id, tt.wantSuffixed)
+				}
+			}
+		})
+	}
+}
@@ -4,67 +4,185 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 
+	"event-pipeline/internal/awsconfig"
+	"event-pipeline/internal/envconfig"
 	"event-pipeline/internal/metrics"
 	"event-pipeline/internal/models"
 )
 
+// s3API is the part of the S3 client the trigger uses, so tests can stub it
+type s3API interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// sqsAPI is the part of the SQS client the trigger uses
+type sqsAPI interface {
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
 var (
-	sqsClient        *sqs.Client
-	s3Client         *s3.Client
-	metricsCollector *metrics.Collector
+	sqsClient        sqsAPI
+	s3Client         s3API
+	metricsCollector metrics.Collector
 	queueURL         string
+	fifoQueue        bool // set MessageGroupId and MessageDeduplicationId
+
+	// Files larger than rangeThresholdBytes are processed by their last
+	// rangeTailBytes only; a zero threshold disables range processing
+	rangeThresholdBytes int64
+	rangeTailBytes      int64
+
+	jobIDPattern       *regexp.Regexp
+	allowFallbackJobID bool
+
+	// uniqueJobIDs suffixes each job ID per upload (see uniqueJobID)
+	uniqueJobIDs bool
+
+	// eventSource selects the notification transport (auto-detected by default)
+	eventSource string
+
+	dryRun bool
+
+	// Keys outside keyPrefixes are skipped; empty allows every key
+	keyPrefixes []string
+
+	// Keys must end with one of acceptedExtensions (lowercased)
+	acceptedExtensions []string
+
+	// Jobs that would make the worker read more than maxFileSizeBytes are
+	// not queued; zero disables the limit. Rejected objects are copied under
+	// quarantinePrefix when it is set.
+	maxFileSizeBytes int64
+	quarantinePrefix string
+)
+
+const (
+	// defaultRangeTailBytes is the tail size used when RANGE_TAIL_BYTES is unset
+	defaultRangeTailBytes = 64 * 1024 * 1024
+
+	// defaultMaxFileSizeBytes is the limit used when MAX_FILE_SIZE_BYTES is unset
+	defaultMaxFileSizeBytes = 512 * 1024 * 1024
 )
 
 func init() {
 	ctx := context.Background()
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := awsconfig.Load(ctx)
 	if err != nil {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// LocalStack support
-	endpoint := os.Getenv("AWS_ENDPOINT_URL")
-	
-	if endpoint != "" {
-		cfg.BaseEndpoint = aws.String(endpoint)
+	sqsClient = awsconfig.NewSQS(cfg)
+	s3Client = awsconfig.NewS3(cfg)
+
+	queueURL = loadPriorityConfig()
+	fifoURL := queueURL
+	if priorityRouting() {
+		fifoURL = highPriorityQueueURL
 	}
+	fifoQueue = envconfig.Bool("FIFO_QUEUE", strings.HasSuffix(fifoURL, ".fifo"))
 
-	sqsClient = sqs.NewFromConfig(cfg)
-	
-    // Create S3 client with path-style addressing for LocalStack
-	if endpoint != "" {
-		s3Client = s3.NewFromConfig(cfg, func(o *s3.Options) {
-			o.UsePathStyle = true  // CRITICAL: Forces path-style URLs
-		})
-	} else {
-		s3Client = s3.NewFromConfig(cfg)
+	pattern := os.Getenv("JOBID_KEY_PATTERN")
+	if pattern == "" {
+		pattern = defaultJobIDPattern
+	}
+	jobIDPattern, err = compileJobIDPattern(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("invalid JOBID_KEY_PATTERN: %v", err))
 	}
+	allowFallbackJobID = envconfig.Bool("ALLOW_FALLBACK_JOBID", false)
+	uniqueJobIDs = envconfig.Bool("UNIQUE_JOB_IDS", false)
 
-	queueURL = os.Getenv("QUEUE_URL")
+	eventSource = os.Getenv("TRIGGER_EVENT_SOURCE")
+	switch eventSource {
+	case "":
+		eventSource = sourceAuto
+	case sourceAuto, sourceS3, sourceSNS, sourceSQS:
+	default:
+		panic(fmt.Sprintf("invalid TRIGGER_EVENT_SOURCE %q", eventSource))
+	}
+
+	rangeThresholdBytes = int64(envconfig.Int("RANGE_THRESHOLD_BYTES", 0))
+	rangeTailBytes = int64(envconfig.Int("RANGE_TAIL_BYTES", defaultRangeTailBytes))
+	if rangeTailBytes <= 0 {
+		rangeTailBytes = defaultRangeTailBytes
+	}
+
+	if raw := os.Getenv("ALLOWED_CONTENT_TYPES"); raw != "" {
+		var allowed []string
+		for _, contentType := range strings.Split(raw, ",") {
+			if contentType = models.NormalizeContentType(contentType); contentType != "" {
+				allowed = append(allowed, contentType)
+			}
+		}
+		models.AllowedContentTypes = allowed
+	}
 
-	metricsCollector, err = metrics.NewCollector(ctx, "EventPipeline")
+	keyPrefixes = parseKeyPrefixes(os.Getenv("KEY_PREFIX_FILTER"))
+	acceptedExtensions = parseExtensions(defaultAcceptedExtensions)
+	if raw := os.Getenv("ACCEPTED_EXTENSIONS"); raw != "" {
+		acceptedExtensions = parseExtensions(raw)
+	}
+
+	maxFileSizeBytes = int64(envconfig.Int("MAX_FILE_SIZE_BYTES", defaultMaxFileSizeBytes))
+	quarantinePrefix = os.Getenv("QUARANTINE_PREFIX")
+	stagingPrefix = os.Getenv("STAGING_PREFIX")
+	if stagingPrefix == "" {
+		stagingPrefix = defaultStagingPrefix
+	}
+
+	// Dry runs validate and log jobs without queuing them, and keep their
+	// metrics out of the production namespace
+	dryRun = envconfig.Bool("DRY_RUN", false)
+	namespace := "EventPipeline"
+	if dryRun {
+		namespace += "/DryRun"
+		fmt.Println("DRY_RUN enabled: jobs will be logged, not queued")
+	}
+
+	// Dry runs never queue, so there is nothing to check
+	if !dryRun && !envconfig.Bool("SKIP_STARTUP_CHECKS", false) {
+		if err := checkQueues(ctx); err != nil {
+			panic(fmt.Sprintf("startup check failed: %v", err))
+		}
+	}
+
+	metricsCollector, err = metrics.NewFromEnv(ctx, namespace)
 	if err != nil {
 		fmt.Printf("Warning: failed to create metrics collector: %v\n", err)
 	}
 }
 
-func handler(ctx context.Context, s3Event events.S3Event) error {
-	for _, record := range s3Event.Records {
-		if err := processRecord(ctx, record); err != nil {
+func handler(ctx context.Context, payload json.RawMessage) error {
+	s3Event, err := unwrapS3Event(payload, eventSource)
+	if err != nil {
+		return err
+	}
+
+	// S3 occasionally repeats an event within one notification batch
+	records := dedupeRecords(s3Event.Records)
+
+	jobs := make([]models.ProcessingJob, 0, len(records))
+	for _, record := range records {
+		job, err := processRecord(ctx, record)
+		if err != nil {
 			fmt.Printf("Error processing record: %v\n", err)
 			if metricsCollector != nil {
 				metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
@@ -74,20 +192,44 @@ func handler(ctx context.Context, s3Event events.S3Event) error {
 			// Continue processing other records instead of failing the whole batch.
 			continue
 		}
+		if job != nil {
+			jobs = append(jobs, *job)
+		}
 	}
+
+	enqueueJobs(ctx, jobs)
 	return nil
 }
 
-func processRecord(ctx context.Context, record events.S3EventRecord) error {
+// processRecord validates an S3 record and builds its processing job.
+// It returns a nil job for records that are intentionally skipped.
+func processRecord(ctx context.Context, record events.S3EventRecord) (*models.ProcessingJob, error) {
 	startTime := time.Now()
 
 	bucket := record.S3.Bucket.Name
-	key := record.S3.Object.Key
+
+	// S3 notifications URL-encode keys (spaces arrive as '+'), so decode
+	// before using the key for HeadObject, job IDs, or the job itself
+	key, err := decodeS3Key(record.S3.Object.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Other prefixes in the bucket hold files that aren't pipeline input
+	if !keyAllowed(key, keyPrefixes) {
+		fmt.Printf("Skipping file outside KEY_PREFIX_FILTER: %s\n", key)
+		if metricsCollector != nil {
+			metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+				"TriggerSkippedPrefix": metrics.Count(1),
+			})
+		}
+		return nil, nil
+	}
 
 	// Skip non-JSON files
-	if !strings.HasSuffix(strings.ToLower(key), ".json") {
+	if !extensionAccepted(key, acceptedExtensions) {
 		fmt.Printf("Skipping non-JSON file: %s\n", key)
-		return nil
+		return nil, nil
 	}
 
 	// Get object metadata
@@ -96,49 +238,81 @@ func processRecord(ctx context.Context, record events.S3EventRecord) error {
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to head object %s/%s: %w", bucket, key, err)
+		return nil, fmt.Errorf("failed to head object %s/%s: %w", bucket, key, err)
 	}
 
-	// Extract the test_id from the S3 key if it follows the pattern
-	// "logs/test_{test_id}_{timestamp}.json"
-	var jobID string
-	parts := strings.Split(key, "_")
-	if len(parts) >= 3 && parts[0] == "logs/test" {
-		jobID = parts[1]
-		fmt.Printf("Extracted test_id '%s' from key\n", jobID)
-	} else {
-		return fmt.Errorf("could not extract test_id from key: %s", key)
+	// Extract the job ID from the S3 key (JOBID_KEY_PATTERN)
+	jobID, err := extractJobID(key)
+	if err != nil {
+		return nil, err
 	}
+	if uniqueJobIDs {
+		jobID = uniqueJobID(jobID, key, record)
+	}
+	fmt.Printf("Extracted job ID '%s' from key\n", jobID)
 
 	// Create processing job
-	job := models.ProcessingJob{
+	job := &models.ProcessingJob{
 		JobID:       jobID,
 		Bucket:      bucket,
 		Key:         key,
-		Size:        *headResp.ContentLength,
-		ContentType: aws.ToString(headResp.ContentType),
+		Size:        aws.ToInt64(headResp.ContentLength),
+		ContentType: models.NormalizeContentType(aws.ToString(headResp.ContentType)),
+		ETag:        aws.ToString(headResp.ETag),
 		ReceivedAt:  record.EventTime,
 		ValidatedAt: time.Now(),
+
+		ObjectLastModified: aws.ToTime(headResp.LastModified),
 	}
+	job.ObjectAgeMs = job.AgeAt(record.EventTime).Milliseconds()
 
-	// Serialize and send to SQS
-	jobBytes, err := json.Marshal(job)
-	if err != nil {
-		return fmt.Errorf("failed to marshal job: %w", err)
-	}
-
-	_, err = sqsClient.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    aws.String(queueURL),
-		MessageBody: aws.String(string(jobBytes)),
-		MessageAttributes: map[string]types.MessageAttributeValue{
-			"JobID": {
-				DataType:    aws.String("String"),
-				StringValue: aws.String(job.JobID),
-			},
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to send SQS message: %w", err)
+	// Don't queue jobs that are bound to fail in the worker
+	if err := job.Validate(); err != nil {
+		fmt.Printf("Rejecting %s/%s: %v\n", bucket, key, err)
+		if metricsCollector != nil {
+			rejected := map[string]metrics.MetricValue{
+				"TriggerRejected": metrics.Count(1),
+			}
+			var validationErr *models.ValidationError
+			if errors.As(err, &validationErr) && validationErr.Field == "content_type" {
+				rejected["TriggerRejectedContentType"] = metrics.Count(1)
+			}
+			metricsCollector.EmitBatch(ctx, rejected)
+		}
+		return nil, nil
+	}
+
+	// Only process the tail of very large files
+	if rangeThresholdBytes > 0 && job.Size > rangeThresholdBytes {
+		job.ByteRangeStart = max(job.Size-rangeTailBytes, 0)
+		job.ByteRangeEnd = job.Size - 1
+		fmt.Printf("File %s is %d bytes, processing last %d bytes only\n", key, job.Size, job.Size-job.ByteRangeStart)
+	}
+
+	// Oversized files make the worker time out and retry until the DLQ.
+	// Ranged jobs are judged by the bytes the worker will actually read.
+	readBytes := job.Size
+	if job.HasRange() {
+		readBytes = job.ByteRangeEnd - job.ByteRangeStart + 1
+	}
+	if maxFileSizeBytes > 0 && readBytes > maxFileSizeBytes {
+		fmt.Printf("Rejecting %s/%s: %d bytes exceeds MAX_FILE_SIZE_BYTES (%d)\n", bucket, key, readBytes, maxFileSizeBytes)
+		if metricsCollector != nil {
+			metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+				"TriggerRejected":  metrics.Count(1),
+				"TriggerOversized": metrics.Count(1),
+			})
+		}
+		if quarantinePrefix != "" && !dryRun {
+			if err := quarantineObject(ctx, bucket, key); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+		return nil, nil
+	}
+
+	if priorityRouting() {
+		job.Priority = jobPriority(*job)
 	}
 
 	// Emit metrics
@@ -146,15 +320,33 @@ func processRecord(ctx context.Context, record events.S3EventRecord) error {
 	if metricsCollector != nil {
 		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
 			"TriggerValidationLatencyMs": metrics.LatencyMs(validationLatency),
-			"TriggerFileSizeBytes":       metrics.MetricValue{Value: float64(job.Size), Unit: "Bytes"},
-			"TriggerInvocations":         metrics.Count(1),
+			"TriggerObjectAgeMs":         metrics.LatencyMs(float64(job.ObjectAgeMs)),
 		})
+		metricsCollector.EmitBatchWithDimensions(ctx, map[string]metrics.MetricValue{
+			"TriggerFileSizeBytes": {Value: float64(job.Size), Unit: "Bytes"},
+		}, map[string]string{"Bucket": bucket})
 	}
 
-	fmt.Printf("Queued job %s for file %s/%s (%.2fms)\n", job.JobID, bucket, key, validationLatency)
-	return nil
+	fmt.Printf("Validated job %s for file %s/%s (%.2fms)\n", job.JobID, bucket, key, validationLatency)
+	return job, nil
+}
+
+// decodeS3Key reverses the URL encoding S3 applies to keys in event notifications
+func decodeS3Key(raw string) (string, error) {
+	key, err := url.QueryUnescape(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode object key %q: %w", raw, err)
+	}
+	return key, nil
+}
+
+// flushMetrics drains a buffered collector when Lambda shuts the container down
+func flushMetrics() {
+	if err := metrics.Shutdown(metricsCollector, metrics.DefaultShutdownTimeout); err != nil {
+		fmt.Printf("Warning: failed to flush metrics on shutdown: %v\n", err)
+	}
 }
 
 func main() {
-	lambda.Start(handler)
-}
\ No newline at end of file
+	lambda.StartWithOptions(handler, lambda.WithEnableSIGTERM(flushMetrics))
+}
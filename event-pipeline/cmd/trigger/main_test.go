@@ -0,0 +1,362 @@
+// cmd/trigger/main_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	"event-pipeline/internal/metrics"
+)
+
+// testQueueURL is the queue init picks up from QUEUE_URL
+const testQueueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/jobs"
+
+// Package-level initializers run before init, which needs a queue and a
+// region and would otherwise check the queue exists
+var _ = func() bool {
+	for name, value := range map[string]string{
+		"SKIP_STARTUP_CHECKS": "true",
+		"QUEUE_URL":           testQueueURL,
+		"AWS_REGION":          "us-east-1",
+	} {
+		os.Setenv(name, value)
+	}
+	return true
+}()
+
+// fakeObject is what fakeS3.HeadObject reports for a key
+type fakeObject struct {
+	size        int64
+	contentType string
+}
+
+// fakeS3 answers HeadObject from objects, missing keys returning NotFound,
+// and records copies and puts
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	heads   []string
+	copies  []*s3.CopyObjectInput
+	puts    map[string][]byte
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := aws.ToString(params.Key)
+	f.heads = append(f.heads, key)
+	obj, ok := f.objects[key]
+	if !ok {
+		return nil, &s3types.NotFound{}
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(obj.size),
+		ContentType:   aws.String(obj.contentType),
+		ETag:          aws.String(`"etag-` + key + `"`),
+		LastModified:  aws.Time(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)),
+	}, nil
+}
+
+func (f *fakeS3) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.copies = append(f.copies, params)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(params.Body); err != nil {
+		return nil, err
+	}
+	if f.puts == nil {
+		f.puts = map[string][]byte{}
+	}
+	f.puts[aws.ToString(params.Key)] = body.Bytes()
+	return &s3.PutObjectOutput{}, nil
+}
+
+// fakeSQS accepts every entry sent, unless sendFn decides otherwise, and
+// records each SendMessageBatch call
+type fakeSQS struct {
+	mu     sync.Mutex
+	calls  []*sqs.SendMessageBatchInput
+	sendFn func(call int, params *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error)
+}
+
+func (f *fakeSQS) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, params)
+	if f.sendFn != nil {
+		return f.sendFn(len(f.calls), params)
+	}
+	out := &sqs.SendMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		out.Successful = append(out.Successful, sqstypes.SendMessageBatchResultEntry{Id: entry.Id})
+	}
+	return out, nil
+}
+
+func (f *fakeSQS) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
+
+// sent returns every message body sent, in order
+func (f *fakeSQS) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var bodies []string
+	for _, call := range f.calls {
+		for _, entry := range call.Entries {
+			bodies = append(bodies, aws.ToString(entry.MessageBody))
+		}
+	}
+	return bodies
+}
+
+// fakeCollector sums every emitted metric by name
+type fakeCollector struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (f *fakeCollector) add(name string, value float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[name] += value
+	return nil
+}
+
+func (f *fakeCollector) EmitLatency(ctx context.Context, name string, valueMs float64) error {
+	return f.add(name, valueMs)
+}
+
+func (f *fakeCollector) EmitCount(ctx context.Context, name string, value float64) error {
+	return f.add(name, value)
+}
+
+func (f *fakeCollector) EmitBytes(ctx context.Context, name string, value float64) error {
+	return f.add(name, value)
+}
+
+func (f *fakeCollector) EmitStatisticSet(ctx context.Context, name string, set metrics.StatisticValues, unit types.StandardUnit) error {
+	return f.add(name, set.SampleCount)
+}
+
+func (f *fakeCollector) EmitBatch(ctx context.Context, batch map[string]metrics.MetricValue) error {
+	for name, mv := range batch {
+		f.add(name, mv.Value)
+	}
+	return nil
+}
+
+func (f *fakeCollector) EmitBatchWithDimensions(ctx context.Context, batch map[string]metrics.MetricValue, extraDims map[string]string) error {
+	return f.EmitBatch(ctx, batch)
+}
+
+func (f *fakeCollector) EmitData(ctx context.Context, datums []metrics.Datum) error {
+	for _, d := range datums {
+		f.add(d.Name, d.Value.Value)
+	}
+	return nil
+}
+
+func (f *fakeCollector) value(name string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[name]
+}
+
+// stubTrigger swaps the trigger's clients and collector for fakes until
+// the test ends
+func stubTrigger(t *testing.T) (*fakeS3, *fakeSQS, *fakeCollector) {
+	t.Helper()
+	fs3 := &fakeS3{objects: map[string]fakeObject{}}
+	fsqs := &fakeSQS{}
+	fmetrics := &fakeCollector{values: map[string]float64{}}
+
+	prevS3, prevSQS, prevMetrics := s3Client, sqsClient, metricsCollector
+	s3Client, sqsClient, metricsCollector = fs3, fsqs, fmetrics
+	t.Cleanup(func() {
+		s3Client, sqsClient, metricsCollector = prevS3, prevSQS, prevMetrics
+	})
+	return fs3, fsqs, fmetrics
+}
+
+// s3Record is an ObjectCreated record for key, which S3 URL-encodes
+func s3Record(bucket, rawKey string) events.S3EventRecord {
+	var record events.S3EventRecord
+	record.EventSource = "aws:s3"
+	record.EventName = "ObjectCreated:Put"
+	record.EventTime = time.Date(2024, 1, 15, 10, 0, 1, 0, time.UTC)
+	record.S3.Bucket.Name = bucket
+	record.S3.Object.Key = rawKey
+	record.S3.Object.Sequencer = "0055AED6DCD90281E5"
+	return record
+}
+
+func TestDecodeS3Key(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "logs/test_abc_1.json", want: "logs/test_abc_1.json"},
+		{raw: "logs/test_abc_my+file.json", want: "logs/test_abc_my file.json"},
+		{raw: "logs/test_abc_a%2Bb.json", want: "logs/test_abc_a+b.json"},
+		{raw: "logs/test_abc_caf%C3%A9+%282%29.json", want: "logs/test_abc_café (2).json"},
+		{raw: "logs/test_abc_%zz.json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := decodeS3Key(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeS3Key(%q) = %q, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("decodeS3Key(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessRecordDecodesKey(t *testing.T) {
+	fs3, _, _ := stubTrigger(t)
+	fs3.objects["logs/test_run 1_2024+01.json"] = fakeObject{size: 100, contentType: "application/json"}
+
+	job, err := processRecord(context.Background(), s3Record("logs-bucket", "logs/test_run+1_2024%2B01.json"))
+	if err != nil {
+		t.Fatalf("processRecord: %v", err)
+	}
+	if job == nil {
+		t.Fatal("processRecord skipped the record")
+	}
+
+	if len(fs3.heads) != 1 || fs3.heads[0] != "logs/test_run 1_2024+01.json" {
+		t.Errorf("HeadObject keys = %q, want the decoded key", fs3.heads)
+	}
+	if job.Key != "logs/test_run 1_2024+01.json" || job.JobID != "run 1" {
+		t.Errorf("job key %q id %q, want decoded key and id \"run 1\"", job.Key, job.JobID)
+	}
+}
+
+func TestObjectAgeClampsClockSkew(t *testing.T) {
+	// fakeS3 reports every object as last modified at 10:00:00
+	tests := []struct {
+		name      string
+		eventTime time.Time
+		wantAgeMs int64
+	}{
+		{name: "event after upload", eventTime: time.Date(2024, 1, 15, 10, 0, 1, 500e6, time.UTC), wantAgeMs: 1500},
+		{name: "event clock behind S3", eventTime: time.Date(2024, 1, 15, 9, 59, 58, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, _, fmetrics := stubTrigger(t)
+			fs3.objects["logs/test_run1_a.json"] = fakeObject{size: 100, contentType: "application/json"}
+
+			record := s3Record("logs-bucket", "logs/test_run1_a.json")
+			record.EventTime = tt.eventTime
+			job, err := processRecord(context.Background(), record)
+			if err != nil || job == nil {
+				t.Fatalf("processRecord = %v, %v", job, err)
+			}
+			if job.ObjectAgeMs != tt.wantAgeMs {
+				t.Errorf("ObjectAgeMs = %d, want %d", job.ObjectAgeMs, tt.wantAgeMs)
+			}
+			if got, ok := fmetrics.values["TriggerObjectAgeMs"]; !ok || got != float64(tt.wantAgeMs) {
+				t.Errorf("TriggerObjectAgeMs = %v (sent %v), want %d", got, ok, tt.wantAgeMs)
+			}
+		})
+	}
+}
+
+func TestHandlerRejectsInvalidJobs(t *testing.T) {
+	fs3, fsqs, fmetrics := stubTrigger(t)
+	fs3.objects["logs/test_good_1.json"] = fakeObject{size: 100, contentType: "application/json"}
+	fs3.objects["logs/test_empty_1.json"] = fakeObject{size: 0, contentType: "application/json"}
+	fs3.objects["logs/test_image_1.json"] = fakeObject{size: 100, contentType: "image/png"}
+
+	payload, err := json.Marshal(events.S3Event{Records: []events.S3EventRecord{
+		s3Record("logs-bucket", "logs/test_good_1.json"),
+		s3Record("logs-bucket", "logs/test_empty_1.json"),
+		s3Record("logs-bucket", "logs/test_image_1.json"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handler(context.Background(), payload); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	sent := fsqs.sent()
+	if len(sent) != 1 || !strings.Contains(sent[0], `"job_id":"good"`) {
+		t.Errorf("sent %q, want only the good job", sent)
+	}
+	if got := fmetrics.value("TriggerRejected"); got != 2 {
+		t.Errorf("TriggerRejected = %v, want 2", got)
+	}
+	if got := fmetrics.value("TriggerRejectedContentType"); got != 1 {
+		t.Errorf("TriggerRejectedContentType = %v, want 1", got)
+	}
+}
+
+func TestProcessRecordFiltersExtensions(t *testing.T) {
+	tests := []struct {
+		name     string
+		accepted string
+		key      string
+		wantJob  bool
+	}{
+		{name: "jsonl", accepted: defaultAcceptedExtensions, key: "logs/test_run1_a.jsonl", wantJob: true},
+		{name: "uppercase JSON", accepted: defaultAcceptedExtensions, key: "logs/test_run1_a.JSON", wantJob: true},
+		{name: "txt rejected", accepted: defaultAcceptedExtensions, key: "logs/test_run1_a.txt"},
+		// Configured values are trimmed, lowercased and given a dot
+		{name: "configured without dots", accepted: " NDJSON, ,log", key: "logs/test_run1_a.ndjson", wantJob: true},
+		{name: "default dropped when configured", accepted: "ndjson", key: "logs/test_run1_a.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, _, _ := stubTrigger(t)
+			prev := acceptedExtensions
+			acceptedExtensions = parseExtensions(tt.accepted)
+			t.Cleanup(func() { acceptedExtensions = prev })
+			fs3.objects[tt.key] = fakeObject{size: 100, contentType: "application/json"}
+
+			job, err := processRecord(context.Background(), s3Record("logs-bucket", tt.key))
+			if err != nil {
+				t.Fatalf("processRecord: %v", err)
+			}
+			if (job != nil) != tt.wantJob {
+				t.Errorf("processRecord(%s) job = %v, want a job %v", tt.key, job, tt.wantJob)
+			}
+			// Rejected keys are skipped before any S3 call
+			if !tt.wantJob && len(fs3.heads) != 0 {
+				t.Errorf("HeadObject called for %q", fs3.heads)
+			}
+		})
+	}
+}
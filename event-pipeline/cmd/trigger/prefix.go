@@ -0,0 +1,30 @@
+// cmd/trigger/prefix.go
+package main
+
+import "strings"
+
+// parseKeyPrefixes splits a comma-separated KEY_PREFIX_FILTER, dropping
+// blanks. An empty result allows every key.
+func parseKeyPrefixes(raw string) []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(raw, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// keyAllowed reports whether key starts with one of prefixes, or true when
+// no prefixes are configured
+func keyAllowed(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+// cmd/trigger/prefix_test.go
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyPrefixFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		key     string
+		wantJob bool
+	}{
+		{name: "empty filter", filter: "", key: "logs/test_run1_a.json", wantJob: true},
+		{name: "blank entries only", filter: " , ,", key: "logs/test_run1_a.json", wantJob: true},
+		{name: "matching", filter: "logs/", key: "logs/test_run1_a.json", wantJob: true},
+		{name: "matching second prefix", filter: "archive/, logs/test_", key: "logs/test_run1_a.json", wantJob: true},
+		{name: "non-matching", filter: "archive/,tmp/", key: "logs/test_run1_a.json"},
+		// Prefixes are compared with the decoded key, case-sensitively
+		{name: "case differs", filter: "Logs/", key: "logs/test_run1_a.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, _, fmetrics := stubTrigger(t)
+			prev := keyPrefixes
+			keyPrefixes = parseKeyPrefixes(tt.filter)
+			t.Cleanup(func() { keyPrefixes = prev })
+			fs3.objects[tt.key] = fakeObject{size: 100, contentType: "application/json"}
+
+			job, err := processRecord(context.Background(), s3Record("logs-bucket", tt.key))
+			if err != nil {
+				t.Fatalf("processRecord: %v", err)
+			}
+			if (job != nil) != tt.wantJob {
+				t.Errorf("processRecord(%s) with filter %q job = %v, want a job %v", tt.key, tt.filter, job, tt.wantJob)
+			}
+
+			wantSkipped := 1.0
+			if tt.wantJob {
+				wantSkipped = 0
+			}
+			if got := fmetrics.value("TriggerSkippedPrefix"); got != wantSkipped {
+				t.Errorf("TriggerSkippedPrefix = %v, want %v", got, wantSkipped)
+			}
+			if !tt.wantJob && len(fs3.heads) != 0 {
+				t.Errorf("HeadObject called for %q", fs3.heads)
+			}
+		})
+	}
+}
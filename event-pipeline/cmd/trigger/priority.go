@@ -0,0 +1,75 @@
+// cmd/trigger/priority.go
+package main
+
+import (
+	"os"
+	"strings"
+
+	"event-pipeline/internal/envconfig"
+	"event-pipeline/internal/models"
+)
+
+// defaultHighPrioritySizeBytes is the size at or below which a file is high
+// priority when HIGH_PRIORITY_MAX_BYTES is unset
+const defaultHighPrioritySizeBytes = 1024 * 1024
+
+// Priority routing sends high priority jobs to highPriorityQueueURL and the
+// rest to lowPriorityQueueURL. It is enabled only when both are set.
+var (
+	highPriorityQueueURL  string
+	lowPriorityQueueURL   string
+	highPrioritySizeBytes int64
+	highPriorityPrefixes  []string
+)
+
+// loadPriorityConfig reads the priority settings and returns the queue to
+// use when routing is off: QUEUE_URL, or else whichever priority queue is set
+func loadPriorityConfig() string {
+	highPriorityQueueURL = os.Getenv("HIGH_PRIORITY_QUEUE_URL")
+	lowPriorityQueueURL = os.Getenv("LOW_PRIORITY_QUEUE_URL")
+	highPrioritySizeBytes = int64(envconfig.Int("HIGH_PRIORITY_MAX_BYTES", defaultHighPrioritySizeBytes))
+	highPriorityPrefixes = parseKeyPrefixes(os.Getenv("HIGH_PRIORITY_PREFIXES"))
+
+	if url := os.Getenv("QUEUE_URL"); url != "" || priorityRouting() {
+		return url
+	}
+	if highPriorityQueueURL != "" {
+		return highPriorityQueueURL
+	}
+	return lowPriorityQueueURL
+}
+
+// priorityRouting reports whether jobs are split across two queues
+func priorityRouting() bool {
+	return highPriorityQueueURL != "" && lowPriorityQueueURL != ""
+}
+
+// jobPriority ranks a job: small files and keys under a
+// HIGH_PRIORITY_PREFIXES prefix are high priority, everything else low.
+// Ranged jobs are judged by the bytes the worker will read.
+func jobPriority(job models.ProcessingJob) string {
+	readBytes := job.Size
+	if job.HasRange() {
+		readBytes = job.ByteRangeEnd - job.ByteRangeStart + 1
+	}
+	if readBytes <= highPrioritySizeBytes {
+		return models.PriorityHigh
+	}
+	for _, prefix := range highPriorityPrefixes {
+		if strings.HasPrefix(job.Key, prefix) {
+			return models.PriorityHigh
+		}
+	}
+	return models.PriorityLow
+}
+
+// queueFor returns the queue a job is sent to
+func queueFor(job models.ProcessingJob) string {
+	if !priorityRouting() {
+		return queueURL
+	}
+	if job.Priority == models.PriorityHigh {
+		return highPriorityQueueURL
+	}
+	return lowPriorityQueueURL
+}
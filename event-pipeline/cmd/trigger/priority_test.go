@@ -0,0 +1,86 @@
+// cmd/trigger/priority_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"event-pipeline/internal/models"
+)
+
+const (
+	highQueueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/high"
+	lowQueueURL  = "https://sqs.us-east-1.amazonaws.com/123456789012/low"
+)
+
+// withPriorityRouting routes jobs of at most maxBytes, or under prefixes,
+// to the high priority queue until the test ends
+func withPriorityRouting(t *testing.T, maxBytes int64, prefixes ...string) {
+	t.Helper()
+	prevHigh, prevLow, prevSize, prevPrefixes := highPriorityQueueURL, lowPriorityQueueURL, highPrioritySizeBytes, highPriorityPrefixes
+	highPriorityQueueURL, lowPriorityQueueURL, highPrioritySizeBytes, highPriorityPrefixes = highQueueURL, lowQueueURL, maxBytes, prefixes
+	t.Cleanup(func() {
+		highPriorityQueueURL, lowPriorityQueueURL, highPrioritySizeBytes, highPriorityPrefixes = prevHigh, prevLow, prevSize, prevPrefixes
+	})
+}
+
+func TestHandlerRoutesByPriority(t *testing.T) {
+	fs3, fsqs, _ := stubTrigger(t)
+	withPriorityRouting(t, 1024, "logs/test_urgent")
+	fs3.objects["logs/test_small_1.json"] = fakeObject{size: 1024, contentType: "application/json"}
+	fs3.objects["logs/test_large_1.json"] = fakeObject{size: 1025, contentType: "application/json"}
+	fs3.objects["logs/test_urgent_1.json"] = fakeObject{size: 50 << 20, contentType: "application/json"}
+
+	payload, err := json.Marshal(events.S3Event{Records: []events.S3EventRecord{
+		s3Record("logs-bucket", "logs/test_small_1.json"),
+		s3Record("logs-bucket", "logs/test_large_1.json"),
+		s3Record("logs-bucket", "logs/test_urgent_1.json"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := handler(context.Background(), payload); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	queues := map[string]string{}
+	for _, call := range fsqs.calls {
+		for _, entry := range call.Entries {
+			var job struct {
+				JobID    string `json:"job_id"`
+				Priority string `json:"priority"`
+			}
+			if err := json.Unmarshal([]byte(aws.ToString(entry.MessageBody)), &job); err != nil {
+				t.Fatalf("message body: %v", err)
+			}
+			queues[job.JobID] = aws.ToString(call.QueueUrl) + " " + job.Priority
+		}
+	}
+	want := map[string]string{
+		"small":  highQueueURL + " high",
+		"large":  lowQueueURL + " low",
+		"urgent": highQueueURL + " high",
+	}
+	for jobID, wantQueue := range want {
+		if queues[jobID] != wantQueue {
+			t.Errorf("job %s sent to %q, want %q", jobID, queues[jobID], wantQueue)
+		}
+	}
+}
+
+func TestQueueForWithoutRouting(t *testing.T) {
+	prevHigh := highPriorityQueueURL
+	highPriorityQueueURL = highQueueURL
+	t.Cleanup(func() { highPriorityQueueURL = prevHigh })
+
+	// With only one priority queue set, every job goes to QUEUE_URL
+	for _, priority := range []string{models.PriorityHigh, models.PriorityLow, ""} {
+		if got := queueFor(models.ProcessingJob{Priority: priority}); got != queueURL {
+			t.Errorf("queueFor(%q) = %q, want %q", priority, got, queueURL)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// cmd/trigger/quarantine.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// quarantineObject copies a rejected object under quarantinePrefix in the
+// same bucket for follow-up. The original is left in place.
+func quarantineObject(ctx context.Context, bucket, key string) error {
+	// A prefix inside the notification filter would re-trigger on the copy
+	if strings.HasPrefix(key, quarantinePrefix) {
+		return nil
+	}
+
+	dest := quarantinePrefix + key
+	_, err := s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(bucket),
+		Key:        aws.String(dest),
+		CopySource: aws.String(url.PathEscape(bucket + "/" + key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to quarantine %s/%s: %w", bucket, key, err)
+	}
+	fmt.Printf("Quarantined %s/%s to %s\n", bucket, key, dest)
+	return nil
+}
@@ -0,0 +1,72 @@
+// cmd/trigger/staging.go
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"event-pipeline/internal/models"
+)
+
+const (
+	// maxInlineJobBytes is the largest job sent as the message body. SQS
+	// limits a message, attributes included, to 256 KiB, so leave room for
+	// the attributes.
+	maxInlineJobBytes = 255 * 1024
+
+	// defaultStagingPrefix is used when STAGING_PREFIX is unset. It lies
+	// outside the notification filter so staged jobs never re-trigger.
+	defaultStagingPrefix = "staging/"
+)
+
+// stagingPrefix is where oversized jobs are stored in the job's bucket
+var stagingPrefix string
+
+// stageJob stores an oversized job body as gzipped JSON under stagingPrefix
+// and returns the pointer message to queue in its place, in the manner of
+// the SQS extended client. The key ends in a hash of the body, so jobs
+// that share an ID but differ, such as a re-uploaded manifest, never
+// overwrite each other's staged copy.
+func stageJob(ctx context.Context, job models.ProcessingJob, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to compress job: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress job: %w", err)
+	}
+
+	staged := models.StagedJob{
+		Bucket: job.Bucket,
+		Key:    stagingKey(job, body),
+	}
+	_, err := s3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:          aws.String(staged.Bucket),
+		Key:             aws.String(staged.Key),
+		Body:            bytes.NewReader(buf.Bytes()),
+		ContentType:     aws.String("application/json"),
+		ContentEncoding: aws.String("gzip"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stage job at %s/%s: %w", staged.Bucket, staged.Key, err)
+	}
+	fmt.Printf("Staged job %s (%d bytes) at %s/%s\n", job.JobID, len(body), staged.Bucket, staged.Key)
+
+	return json.Marshal(models.StagedJobMessage{StagedJob: &staged})
+}
+
+// stagingKey names the staged copy of a job: its ID and the first 16 hex
+// digits of the body's SHA-256, e.g. staging/run42-1f3a9c0e5b7d2468.json.gz
+func stagingKey(job models.ProcessingJob, body []byte) string {
+	sum := sha256.Sum256(body)
+	return stagingPrefix + job.JobID + "-" + hex.EncodeToString(sum[:8]) + ".json.gz"
+}
@@ -0,0 +1,127 @@
+// cmd/trigger/staging_test.go
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// sizedJob is a manifest job whose JSON is a little over size bytes
+func sizedJob(jobID string, size int) models.ProcessingJob {
+	return models.ProcessingJob{
+		JobID:  jobID,
+		Bucket: "logs-bucket",
+		Key:    "logs/" + jobID + "/",
+		Keys:   []string{"logs/" + jobID + "/" + strings.Repeat("x", size)},
+	}
+}
+
+// gunzip decompresses a staged object
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestStagedJobRoundTrip(t *testing.T) {
+	fs3, fsqs, _ := stubTrigger(t)
+	job := sizedJob("run42", maxInlineJobBytes)
+
+	enqueueJobs(context.Background(), []models.ProcessingJob{job})
+
+	sent := fsqs.sent()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	var pointer models.StagedJobMessage
+	if err := json.Unmarshal([]byte(sent[0]), &pointer); err != nil || pointer.StagedJob == nil {
+		t.Fatalf("message %.100q is not a staged job pointer (%v)", sent[0], err)
+	}
+	if pointer.StagedJob.Bucket != job.Bucket || !strings.HasPrefix(pointer.StagedJob.Key, stagingPrefix+"run42-") {
+		t.Errorf("pointer = %+v, want the job's bucket under %s", pointer.StagedJob, stagingPrefix)
+	}
+
+	staged, ok := fs3.puts[pointer.StagedJob.Key]
+	if !ok {
+		t.Fatalf("nothing staged at %s", pointer.StagedJob.Key)
+	}
+	var got models.ProcessingJob
+	if err := json.Unmarshal(gunzip(t, staged), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.JobID != job.JobID || got.Key != job.Key || len(got.Keys) != 1 || got.Keys[0] != job.Keys[0] {
+		t.Errorf("staged job %q with %d keys, want the original", got.JobID, len(got.Keys))
+	}
+}
+
+func TestStagingKeysDifferForRepeatedJobID(t *testing.T) {
+	fs3, _, _ := stubTrigger(t)
+	first := sizedJob("run42", maxInlineJobBytes)
+	second := sizedJob("run42", maxInlineJobBytes)
+	second.Keys[0] += "-reuploaded"
+
+	enqueueJobs(context.Background(), []models.ProcessingJob{first, second})
+
+	if len(fs3.puts) != 2 {
+		t.Errorf("staged %d objects, want one per job", len(fs3.puts))
+	}
+}
+
+func TestBatchesStayUnderSizeLimit(t *testing.T) {
+	_, fsqs, _ := stubTrigger(t)
+
+	// Each message is about 60 KiB, so four fit in a batch but not five
+	var jobs []models.ProcessingJob
+	for i := range 10 {
+		jobs = append(jobs, sizedJob(string(rune('a'+i)), 60*1024))
+	}
+	enqueueJobs(context.Background(), jobs)
+
+	var sizes []int
+	total := 0
+	for _, call := range fsqs.calls {
+		sizes = append(sizes, len(call.Entries))
+		batchBytes := 0
+		for _, entry := range call.Entries {
+			batchBytes += len(*entry.MessageBody)
+			for name, attr := range entry.MessageAttributes {
+				batchBytes += len(name) + len(*attr.DataType) + len(*attr.StringValue)
+			}
+		}
+		if batchBytes > maxBatchBytes {
+			t.Errorf("batch of %d messages is %d bytes, over %d", len(call.Entries), batchBytes, maxBatchBytes)
+		}
+		total += len(call.Entries)
+	}
+	if total != len(jobs) {
+		t.Errorf("sent %d messages, want %d", total, len(jobs))
+	}
+	if len(sizes) != 3 || sizes[0] != 4 || sizes[1] != 4 || sizes[2] != 2 {
+		t.Errorf("batch sizes = %v, want [4 4 2]", sizes)
+	}
+}
+
+func TestSplitBatchesByCount(t *testing.T) {
+	messages := make([]queuedMessage, 25)
+	for i := range messages {
+		messages[i] = queuedMessage{job: models.ProcessingJob{JobID: "j"}, body: []byte("{}")}
+	}
+	batches := splitBatches(messages)
+	if len(batches) != 3 || len(batches[0]) != maxBatchEntries || len(batches[2]) != 5 {
+		t.Errorf("got %d batches, want 10, 10 and 5 messages", len(batches))
+	}
+}
@@ -0,0 +1,39 @@
+// cmd/trigger/startup.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// checkQueues confirms every configured queue exists, so a wrong QUEUE_URL
+// fails at cold start instead of on the first SendMessageBatch
+func checkQueues(ctx context.Context) error {
+	urls := []string{queueURL}
+	if priorityRouting() {
+		urls = []string{highPriorityQueueURL, lowPriorityQueueURL}
+	}
+
+	for _, url := range urls {
+		if url == "" {
+			return errors.New("no queue configured: set QUEUE_URL or HIGH_PRIORITY_QUEUE_URL and LOW_PRIORITY_QUEUE_URL")
+		}
+		_, err := sqsClient.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+			QueueUrl:       aws.String(url),
+			AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameQueueArn},
+		})
+		var missing *types.QueueDoesNotExist
+		if errors.As(err, &missing) {
+			return fmt.Errorf("SQS queue %s does not exist", url)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check SQS queue %s: %w", url, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// cmd/worker/attributes.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"event-pipeline/internal/models"
+)
+
+// attributeOnlyBody is the placeholder body of a message whose job is
+// carried entirely in message attributes. SQS rejects empty bodies, so
+// producers send this instead; an empty body is accepted as well.
+const attributeOnlyBody = "-"
+
+// decodeJob reads the job from the message body, the usual form, from S3
+// when the body is a staged job pointer, or from its JobID, Bucket, Key and
+// Size attributes (plus optional ContentType and ETag) when the body is
+// empty or attributeOnlyBody
+func decodeJob(ctx context.Context, record events.SQSMessage) (models.ProcessingJob, error) {
+	var job models.ProcessingJob
+	body := strings.TrimSpace(record.Body)
+	if body != "" && body != attributeOnlyBody {
+		var msg struct {
+			models.ProcessingJob
+			StagedJob *models.StagedJob `json:"staged_job"`
+		}
+		if err := json.Unmarshal([]byte(body), &msg); err != nil {
+			return job, fmt.Errorf("failed to unmarshal job: %w", err)
+		}
+		if msg.StagedJob != nil {
+			return fetchStagedJob(ctx, *msg.StagedJob)
+		}
+		return msg.ProcessingJob, nil
+	}
+
+	attr := func(name string) string {
+		if v := record.MessageAttributes[name].StringValue; v != nil {
+			return *v
+		}
+		return ""
+	}
+
+	job = models.ProcessingJob{
+		JobID:       attr("JobID"),
+		Bucket:      attr("Bucket"),
+		Key:         attr("Key"),
+		ContentType: models.NormalizeContentType(attr("ContentType")),
+		ETag:        attr("ETag"),
+	}
+	for _, name := range []string{"JobID", "Bucket", "Key"} {
+		if attr(name) == "" {
+			return job, fmt.Errorf("attribute-only message is missing the %s attribute", name)
+		}
+	}
+
+	size, err := strconv.ParseInt(attr("Size"), 10, 64)
+	if err != nil || size <= 0 {
+		return job, fmt.Errorf("attribute-only message has invalid Size %q", attr("Size"))
+	}
+	job.Size = size
+	return job, nil
+}
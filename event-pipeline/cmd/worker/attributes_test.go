@@ -0,0 +1,98 @@
+// cmd/worker/attributes_test.go
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// attributeMessage is an SQS message with body and string attributes attrs
+func attributeMessage(body string, attrs map[string]string) events.SQSMessage {
+	msg := events.SQSMessage{MessageId: "msg-1", Body: body, MessageAttributes: map[string]events.SQSMessageAttribute{}}
+	for name, value := range attrs {
+		msg.MessageAttributes[name] = events.SQSMessageAttribute{StringValue: &value, DataType: "String"}
+	}
+	return msg
+}
+
+func TestDecodeJobFromAttributes(t *testing.T) {
+	full := map[string]string{
+		"JobID":       "job-1",
+		"Bucket":      "logs",
+		"Key":         "app.json",
+		"Size":        "2048",
+		"ContentType": "application/x-ndjson",
+		"ETag":        `"abc"`,
+	}
+	without := func(name string) map[string]string {
+		attrs := map[string]string{}
+		for k, v := range full {
+			if k != name {
+				attrs[k] = v
+			}
+		}
+		return attrs
+	}
+	with := func(name, value string) map[string]string {
+		attrs := without(name)
+		attrs[name] = value
+		return attrs
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		attrs   map[string]string
+		wantErr bool
+	}{
+		{name: "placeholder body", body: attributeOnlyBody, attrs: full},
+		{name: "empty body", body: "", attrs: full},
+		{name: "blank body", body: " \n", attrs: full},
+		{name: "missing JobID", body: attributeOnlyBody, attrs: without("JobID"), wantErr: true},
+		{name: "missing Bucket", body: attributeOnlyBody, attrs: without("Bucket"), wantErr: true},
+		{name: "missing Key", body: attributeOnlyBody, attrs: without("Key"), wantErr: true},
+		{name: "missing Size", body: attributeOnlyBody, attrs: without("Size"), wantErr: true},
+		{name: "non-numeric Size", body: attributeOnlyBody, attrs: with("Size", "2kB"), wantErr: true},
+		{name: "zero Size", body: attributeOnlyBody, attrs: with("Size", "0"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := decodeJob(context.Background(), attributeMessage(tt.body, tt.attrs))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeJob = %+v, want error", job)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeJob: %v", err)
+			}
+			if job.JobID != "job-1" || job.Bucket != "logs" || job.Key != "app.json" || job.Size != 2048 {
+				t.Errorf("job %q %s/%s size %d, want job-1 logs/app.json size 2048", job.JobID, job.Bucket, job.Key, job.Size)
+			}
+			if job.ContentType != "application/x-ndjson" || job.ETag != `"abc"` {
+				t.Errorf("content type %q etag %q, want the optional attributes", job.ContentType, job.ETag)
+			}
+		})
+	}
+}
+
+func TestDecodeJobPrefersBody(t *testing.T) {
+	// Attributes are only read when the body carries no job
+	msg := attributeMessage(`{"job_id":"from-body","bucket":"logs","key":"body.json","size":10}`, map[string]string{
+		"JobID": "from-attributes", "Bucket": "other", "Key": "attr.json", "Size": "20",
+	})
+	job, err := decodeJob(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("decodeJob: %v", err)
+	}
+	if job.JobID != "from-body" || job.Key != "body.json" || job.Size != 10 {
+		t.Errorf("job %q key %q size %d, want the body's job", job.JobID, job.Key, job.Size)
+	}
+
+	if _, err := decodeJob(context.Background(), attributeMessage("{not json", nil)); err == nil {
+		t.Error("malformed body decoded without error")
+	}
+}
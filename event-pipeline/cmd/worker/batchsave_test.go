@@ -0,0 +1,78 @@
+// cmd/worker/batchsave_test.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// fakeBatchSink is a fakeSink that also writes batches, returning the
+// next of batchErrs for each
+type fakeBatchSink struct {
+	fakeSink
+	batchMu   sync.Mutex
+	batches   [][]models.ProcessingResult
+	batchErrs []error
+}
+
+func (f *fakeBatchSink) SaveAll(ctx context.Context, results []models.ProcessingResult) error {
+	f.batchMu.Lock()
+	defer f.batchMu.Unlock()
+	f.batches = append(f.batches, results)
+	if len(f.batchErrs) > 0 {
+		err := f.batchErrs[0]
+		f.batchErrs = f.batchErrs[1:]
+		return err
+	}
+	return nil
+}
+
+// manifestResults returns a result per file plus the aggregate
+func manifestResults(files int) []models.ProcessingResult {
+	results := []models.ProcessingResult{{JobID: "job-1", Status: models.StatusCompleted}}
+	for i := range files {
+		results = append(results, models.ProcessingResult{JobID: fmt.Sprintf("job-1#%d", i), Status: models.StatusCompleted})
+	}
+	return results
+}
+
+func TestSaveResultsBatches(t *testing.T) {
+	_, _, fmetrics := stubWorker(t)
+	withThrottleAttempts(t, 3)
+	batch := &fakeBatchSink{batchErrs: []error{throttled}}
+	resultSink = batch
+
+	results := manifestResults(29)
+	if err := saveResults(context.Background(), results); err != nil {
+		t.Fatalf("saveResults: %v", err)
+	}
+	// The throttled batch is written again in full
+	if len(batch.batches) != 2 || len(batch.batches[1]) != len(results) {
+		t.Errorf("wrote %d batches, want the throttled one retried", len(batch.batches))
+	}
+	if len(batch.results()) != 0 {
+		t.Errorf("saved %d results one at a time, want none", len(batch.results()))
+	}
+	if got := fmetrics.value("WorkerDdbThrottled"); got != 1 {
+		t.Errorf("WorkerDdbThrottled = %v, want 1", got)
+	}
+}
+
+func TestSaveResultsWithoutBatchSink(t *testing.T) {
+	_, fsink, _ := stubWorker(t)
+
+	results := manifestResults(2)
+	if err := saveResults(context.Background(), results); err != nil {
+		t.Fatalf("saveResults: %v", err)
+	}
+	saved := fsink.results()
+	for _, result := range results {
+		if _, ok := saved[result.JobID]; !ok {
+			t.Errorf("%s was not saved", result.JobID)
+		}
+	}
+}
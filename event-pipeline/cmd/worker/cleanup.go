@@ -0,0 +1,45 @@
+// cmd/worker/cleanup.go
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"event-pipeline/internal/metrics"
+	"event-pipeline/internal/models"
+)
+
+// deleteSources removes a completed job's objects once its result is
+// saved. Failures are logged and counted under WorkerDeleteFailures but
+// don't fail the job, since the result is already stored; the object is
+// simply left behind.
+func deleteSources(ctx context.Context, job models.ProcessingJob) {
+	// Only the tail of a ranged job was read, so the rest was never processed
+	if job.HasRange() {
+		fmt.Printf("Job %s: keeping %s/%s, only a byte range was processed\n", job.JobID, job.Bucket, job.Key)
+		return
+	}
+
+	failures := 0
+	for _, key := range job.ObjectKeys() {
+		_, err := s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(job.Bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to delete %s/%s after processing: %v\n", job.Bucket, key, err)
+			failures++
+			continue
+		}
+		fmt.Printf("Deleted %s/%s after processing\n", job.Bucket, key)
+	}
+
+	if failures > 0 && metricsCollector != nil {
+		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+			"WorkerDeleteFailures": metrics.Count(float64(failures)),
+		})
+	}
+}
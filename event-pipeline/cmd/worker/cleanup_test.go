@@ -0,0 +1,90 @@
+// cmd/worker/cleanup_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// withDeleteOnSuccess sets deleteOnSuccess until the test ends
+func withDeleteOnSuccess(t *testing.T, enabled bool) {
+	t.Helper()
+	prev := deleteOnSuccess
+	deleteOnSuccess = enabled
+	t.Cleanup(func() { deleteOnSuccess = prev })
+}
+
+func TestDeleteOnSuccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		deleteErr   error
+		saveErr     error
+		wantErr     bool
+		wantDeletes int
+		wantFailed  float64
+	}{
+		{name: "disabled", enabled: false},
+		{name: "deleted after save", enabled: true, wantDeletes: 1},
+		{
+			// The result is already stored, so the job still succeeds
+			name:        "delete fails",
+			enabled:     true,
+			deleteErr:   errors.New("access denied"),
+			wantDeletes: 1,
+			wantFailed:  1,
+		},
+		{
+			// Nothing is deleted unless the completed result was stored
+			name:    "save fails",
+			enabled: true,
+			saveErr: errors.New("boom"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, fsink, fmetrics := stubWorker(t)
+			withDeleteOnSuccess(t, tt.enabled)
+			fs3.objects["app.json"] = sampleLogs
+			fs3.deleteErr = tt.deleteErr
+			if tt.saveErr != nil {
+				fsink.errs = []error{tt.saveErr}
+			}
+
+			err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json")))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("processMessage = %v, want error %v", err, tt.wantErr)
+			}
+			if len(fs3.deletes) != tt.wantDeletes {
+				t.Errorf("deleted %q, want %d deletes", fs3.deletes, tt.wantDeletes)
+			}
+			if tt.wantDeletes > 0 && fs3.deletes[0] != "app.json" {
+				t.Errorf("deleted %q, want app.json", fs3.deletes[0])
+			}
+			if got := fmetrics.value("WorkerDeleteFailures"); got != tt.wantFailed {
+				t.Errorf("WorkerDeleteFailures = %v, want %v", got, tt.wantFailed)
+			}
+			if !tt.wantErr && fsink.results()["job-1"].Status != models.StatusCompleted {
+				t.Errorf("saved %+v, want a completed result", fsink.results()["job-1"])
+			}
+		})
+	}
+}
+
+func TestDeleteOnSuccessKeepsRangedObject(t *testing.T) {
+	fs3, _, _ := stubWorker(t)
+	withDeleteOnSuccess(t, true)
+
+	job := testJob("job-1", "app.json")
+	job.ByteRangeStart = 10
+	job.ByteRangeEnd = 100
+	deleteSources(context.Background(), job)
+
+	if len(fs3.deletes) != 0 {
+		t.Errorf("deleted %q, want the ranged object kept", fs3.deletes)
+	}
+}
@@ -0,0 +1,56 @@
+// cmd/worker/contenthash.go
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// contentHashes collects a SHA-256 digest of each object body as the parser
+// reads it, so the hash costs no second pass over the file
+type contentHashes struct {
+	sums       [][]byte
+	incomplete bool // an object was only partly read
+}
+
+// tee returns a reader that feeds everything read from r into a new digest
+func (h *contentHashes) tee(r io.Reader) (io.Reader, hash.Hash) {
+	digest := sha256.New()
+	return io.TeeReader(r, digest), digest
+}
+
+// add records the digest of an object once the parser is done with it.
+// Parsers may stop short of EOF, e.g. after a JSON array's closing bracket,
+// so the rest of the stream is hashed too, unless reading was truncated.
+func (h *contentHashes) add(r io.Reader, digest hash.Hash, truncated bool) error {
+	if truncated {
+		h.incomplete = true
+		return nil
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to hash remaining content: %w", err)
+	}
+	h.sums = append(h.sums, digest.Sum(nil))
+	return nil
+}
+
+// hex returns the content hash: the digest of a single object, or for a
+// manifest the digest of its objects' digests in order, so moving bytes
+// between shards changes the hash. It is empty when nothing was hashed or
+// an object was only partly read.
+func (h *contentHashes) hex() string {
+	if h == nil || h.incomplete || len(h.sums) == 0 {
+		return ""
+	}
+	if len(h.sums) == 1 {
+		return hex.EncodeToString(h.sums[0])
+	}
+	combined := sha256.New()
+	for _, sum := range h.sums {
+		combined.Write(sum)
+	}
+	return hex.EncodeToString(combined.Sum(nil))
+}
@@ -0,0 +1,69 @@
+// cmd/worker/contenthash_test.go
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// Known SHA-256 digests of the shard contents below
+const (
+	hashedShardA = `{"level":"INFO","endpoint":"/a","response_time_ms":10}` + "\n"
+	hashedShardB = `{"level":"ERROR","endpoint":"/b","response_time_ms":30}` + "\n"
+
+	shardAHash = "14fb081340965bd88f1f83c6d61f0f364be12f828118417e0032f1c0ef9ef868"
+	// SHA-256 of hashedShardA's digest followed by hashedShardB's
+	manifestHash = "54b361ac14a0aca09724c759a46b6214041828e57a377d4252edbf48b072802d"
+)
+
+// withContentHash sets contentHash until the test ends
+func withContentHash(t *testing.T, enabled bool) {
+	t.Helper()
+	prev := contentHash
+	contentHash = enabled
+	t.Cleanup(func() { contentHash = prev })
+}
+
+func TestContentHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		keys     []string
+		maxLines int
+		want     string
+	}{
+		{name: "disabled", keys: []string{"shard-a.json"}},
+		{name: "single object", enabled: true, keys: []string{"shard-a.json"}, want: shardAHash},
+		{name: "manifest", enabled: true, keys: []string{"shard-a.json", "shard-b.json"}, want: manifestHash},
+		{
+			// Stopping at MAX_LINES leaves part of the input unhashed
+			name:     "truncated",
+			enabled:  true,
+			keys:     []string{"shard-a.json", "shard-b.json"},
+			maxLines: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, fsink, _ := stubWorker(t)
+			withContentHash(t, tt.enabled)
+			prev := parserConfig
+			parserConfig.MaxLines = tt.maxLines
+			t.Cleanup(func() { parserConfig = prev })
+			fs3.objects["shard-a.json"] = hashedShardA
+			fs3.objects["shard-b.json"] = hashedShardB
+
+			job := testJob("job-1", tt.keys[0])
+			if len(tt.keys) > 1 {
+				job.Key = "manifest"
+				job.Keys = tt.keys
+			}
+			if err := processMessage(context.Background(), jobMessage(t, "msg-1", job)); err != nil {
+				t.Fatalf("processMessage: %v", err)
+			}
+			if got := fsink.results()["job-1"].ContentHash; got != tt.want {
+				t.Errorf("ContentHash = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
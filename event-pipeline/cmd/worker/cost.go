@@ -0,0 +1,31 @@
+// cmd/worker/cost.go
+package main
+
+import "event-pipeline/internal/models"
+
+const (
+	// defaultLambdaPricePerMs is arm64 Lambda at the default 256MB:
+	// $0.0000133334 per GB-second
+	defaultLambdaPricePerMs = 0.0000133334 / 1000 * 256 / 1024
+
+	// defaultS3PricePerGB is the S3 Select data-scanned price
+	defaultS3PricePerGB = 0.002
+
+	bytesPerGB = 1 << 30
+)
+
+// Prices used by estimateCost, from LAMBDA_PRICE_PER_MS and S3_PRICE_PER_GB
+var (
+	lambdaPricePerMs float64
+	s3PricePerGB     float64
+)
+
+// estimateCost approximates what processing a file cost in USD: compute
+// time at lambdaPricePerMs plus the bytes read at s3PricePerGB. Requests,
+// queue and DynamoDB charges are ignored, so use it for trends rather than
+// billing.
+func estimateCost(result models.ProcessingResult) float64 {
+	compute := float64(result.ProcessingTimeMs) * lambdaPricePerMs
+	scanned := float64(result.FileSizeBytes) / bytesPerGB * s3PricePerGB
+	return compute + scanned
+}
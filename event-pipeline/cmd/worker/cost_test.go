@@ -0,0 +1,44 @@
+// cmd/worker/cost_test.go
+package main
+
+import (
+	"math"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// withPrices sets the cost estimate prices until the test ends
+func withPrices(t *testing.T, perMs, perGB float64) {
+	t.Helper()
+	prevMs, prevGB := lambdaPricePerMs, s3PricePerGB
+	lambdaPricePerMs, s3PricePerGB = perMs, perGB
+	t.Cleanup(func() { lambdaPricePerMs, s3PricePerGB = prevMs, prevGB })
+}
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		perMs  float64
+		perGB  float64
+		timeMs int64
+		bytes  int64
+		want   float64
+	}{
+		// 256MB is a quarter GB: 2s at $0.0000133334/GB-s is 0.5 GB-s,
+		// plus a GiB scanned at $0.002
+		{name: "default prices", perMs: defaultLambdaPricePerMs, perGB: defaultS3PricePerGB, timeMs: 2000, bytes: 1 << 30, want: 0.0000066667 + 0.002},
+		{name: "compute only", perMs: defaultLambdaPricePerMs, perGB: defaultS3PricePerGB, timeMs: 1000, want: 0.00000333335},
+		{name: "custom prices", perMs: 0.000001, perGB: 0.01, timeMs: 500, bytes: 512 << 20, want: 0.0005 + 0.005},
+		{name: "nothing done", perMs: defaultLambdaPricePerMs, perGB: defaultS3PricePerGB},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPrices(t, tt.perMs, tt.perGB)
+			got := estimateCost(models.ProcessingResult{ProcessingTimeMs: tt.timeMs, FileSizeBytes: tt.bytes})
+			if math.Abs(got-tt.want) > 1e-15 {
+				t.Errorf("estimateCost = %.12g, want %.12g", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// cmd/worker/ddbthrottle.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/smithy-go"
+
+	"event-pipeline/internal/metrics"
+)
+
+// ddbThrottleBaseDelay is the initial backoff after DynamoDB throttles a
+// write, doubled on each attempt. It starts higher than the S3 backoff
+// because throttling means the table needs time to recover capacity.
+const ddbThrottleBaseDelay = 250 * time.Millisecond
+
+// writeWithThrottleRetry runs write, each attempt under its own ddbTimeout,
+// and retries it with backoff while DynamoDB reports throttling. Every
+// throttled attempt counts under WorkerDdbThrottled. Throttling that
+// outlasts ddbThrottleAttempts is returned so the message is retried by SQS.
+func writeWithThrottleRetry(ctx context.Context, write func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= ddbThrottleAttempts; attempt++ {
+		err = withDDBTimeout(ctx, write)
+		if !isThrottlingError(err) {
+			return err
+		}
+
+		if metricsCollector != nil {
+			metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+				"WorkerDdbThrottled": metrics.Count(1),
+			})
+		}
+		if attempt == ddbThrottleAttempts {
+			break
+		}
+
+		delay := rand.N(ddbThrottleBaseDelay<<(attempt-1) + 1)
+		fmt.Printf("DynamoDB write throttled on attempt %d, retrying in %v: %v\n", attempt, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return fmt.Errorf("still throttled after %d attempts: %w", ddbThrottleAttempts, err)
+}
+
+// withDDBTimeout runs fn under ddbTimeout when one is set
+func withDDBTimeout(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ddbTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ddbTimeout)
+		defer cancel()
+	}
+	return fn(ctx)
+}
+
+// isThrottlingError reports whether DynamoDB rejected a request for lack of
+// capacity. The SDK's own retries have already run out by the time it
+// surfaces here.
+func isThrottlingError(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		return true
+	}
+	return false
+}
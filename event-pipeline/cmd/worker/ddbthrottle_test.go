@@ -0,0 +1,114 @@
+// cmd/worker/ddbthrottle_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/smithy-go"
+
+	"event-pipeline/internal/models"
+)
+
+// throttled is the error DynamoDB returns once the SDK's retries run out
+var throttled = &smithy.GenericAPIError{
+	Code:    "ProvisionedThroughputExceededException",
+	Message: "The level of configured provisioned throughput for the table was exceeded",
+}
+
+// withThrottleAttempts sets ddbThrottleAttempts until the test ends
+func withThrottleAttempts(t *testing.T, attempts int) {
+	t.Helper()
+	prev := ddbThrottleAttempts
+	ddbThrottleAttempts = attempts
+	t.Cleanup(func() { ddbThrottleAttempts = prev })
+}
+
+func TestThrottledSaveSucceedsOnRetry(t *testing.T) {
+	fs3, fsink, fmetrics := stubWorker(t)
+	withThrottleAttempts(t, 3)
+	fs3.objects["app.json"] = sampleLogs
+	fsink.errs = []error{throttled}
+
+	if err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json"))); err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+	if got := fsink.results()["job-1"].Status; got != models.StatusCompleted {
+		t.Errorf("saved status %q, want completed", got)
+	}
+	if got := fmetrics.value("WorkerDdbThrottled"); got != 1 {
+		t.Errorf("WorkerDdbThrottled = %v, want 1", got)
+	}
+}
+
+func TestWriteWithThrottleRetry(t *testing.T) {
+	tests := []struct {
+		name          string
+		errs          []error
+		wantErr       bool
+		wantCalls     int
+		wantThrottled float64
+	}{
+		{name: "succeeds first time", errs: []error{nil}, wantCalls: 1},
+		{name: "succeeds on retry", errs: []error{throttled, nil}, wantCalls: 2, wantThrottled: 1},
+		{
+			// Throttling past the attempt limit goes back to SQS
+			name:          "still throttled",
+			errs:          []error{throttled, throttled},
+			wantErr:       true,
+			wantCalls:     2,
+			wantThrottled: 2,
+		},
+		{
+			// Other errors are not the table running short of capacity
+			name:      "not throttling",
+			errs:      []error{&smithy.GenericAPIError{Code: "ValidationException"}, nil},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, fmetrics := stubWorker(t)
+			withThrottleAttempts(t, 2)
+
+			calls := 0
+			err := writeWithThrottleRetry(context.Background(), func(ctx context.Context) error {
+				calls++
+				return tt.errs[calls-1]
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeWithThrottleRetry = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, tt.errs[calls-1]) {
+				t.Errorf("error %v does not wrap the last write error", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("wrote %d times, want %d", calls, tt.wantCalls)
+			}
+			if got := fmetrics.value("WorkerDdbThrottled"); got != tt.wantThrottled {
+				t.Errorf("WorkerDdbThrottled = %v, want %v", got, tt.wantThrottled)
+			}
+		})
+	}
+}
+
+func TestThrottleRetryStopsWhenCanceled(t *testing.T) {
+	stubWorker(t)
+	withThrottleAttempts(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := writeWithThrottleRetry(ctx, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return throttled
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, throttled) {
+		t.Errorf("writeWithThrottleRetry = %v, want the throttle and the cancellation", err)
+	}
+	if calls != 1 {
+		t.Errorf("wrote %d times after cancellation, want 1", calls)
+	}
+}
@@ -0,0 +1,37 @@
+// cmd/worker/failure.go
+package main
+
+import (
+	"context"
+	"errors"
+
+	"event-pipeline/internal/models"
+)
+
+// stageError tags an error with the ErrorCategory of the step that failed
+type stageError struct {
+	category string
+	err      error
+}
+
+func (e *stageError) Error() string { return e.err.Error() }
+func (e *stageError) Unwrap() error { return e.err }
+
+// withCategory wraps err so classifyError reports category for it
+func withCategory(category string, err error) error {
+	return &stageError{category: category, err: err}
+}
+
+// classifyError derives a failed result's ErrorCategory. Deadlines win over
+// the failing step, since the fix is usually a timeout setting rather than
+// the step itself.
+func classifyError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrorCategoryTimeout
+	}
+	var stageErr *stageError
+	if errors.As(err, &stageErr) {
+		return stageErr.category
+	}
+	return models.ErrorCategoryUnknown
+}
@@ -0,0 +1,91 @@
+// cmd/worker/failure_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"event-pipeline/internal/models"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "s3 fetch", err: withCategory(models.ErrorCategoryS3Fetch, errors.New("connection refused")), want: models.ErrorCategoryS3Fetch},
+		{name: "parse", err: withCategory(models.ErrorCategoryParse, errors.New("bad json")), want: models.ErrorCategoryParse},
+		{name: "ddb write", err: withCategory(models.ErrorCategoryDDBWrite, errors.New("validation")), want: models.ErrorCategoryDDBWrite},
+		{name: "wrapped category", err: fmt.Errorf("job 1: %w", withCategory(models.ErrorCategoryParse, errors.New("bad json"))), want: models.ErrorCategoryParse},
+		{
+			// The deadline is reported over the step it interrupted
+			name: "timeout",
+			err:  withCategory(models.ErrorCategoryDDBWrite, fmt.Errorf("failed to save result: %w", context.DeadlineExceeded)),
+			want: models.ErrorCategoryTimeout,
+		},
+		{name: "untagged", err: errors.New("boom"), want: models.ErrorCategoryUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailedResultCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(*fakeS3, *fakeSink)
+		want    string
+		timeout time.Duration
+	}{
+		{
+			name:  "s3 fetch",
+			setup: func(fs3 *fakeS3, _ *fakeSink) { fs3.getErrs["app.json"] = errors.New("connection refused") },
+			want:  models.ErrorCategoryS3Fetch,
+		},
+		{
+			name:  "parse",
+			setup: func(fs3 *fakeS3, _ *fakeSink) { fs3.objects["app.json"] = "[" },
+			want:  models.ErrorCategoryParse,
+		},
+		{
+			name: "save timeout",
+			setup: func(fs3 *fakeS3, fsink *fakeSink) {
+				fs3.objects["app.json"] = sampleLogs
+				fsink.blockSaves = 1
+			},
+			timeout: 20 * time.Millisecond,
+			want:    models.ErrorCategoryTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, fsink, fmetrics := stubWorker(t)
+			tt.setup(fs3, fsink)
+			if tt.timeout > 0 {
+				prevTimeout := ddbTimeout
+				ddbTimeout = tt.timeout
+				t.Cleanup(func() { ddbTimeout = prevTimeout })
+			}
+
+			if err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json"))); err == nil {
+				t.Fatal("processMessage succeeded, want an error")
+			}
+
+			result, ok := fsink.results()["job-1"]
+			if !ok || result.Status != models.StatusFailed || result.ErrorCategory != tt.want {
+				t.Errorf("saved status %q category %q (%v), want failed %q", result.Status, result.ErrorCategory, ok, tt.want)
+			}
+			if got := fmetrics.value("WorkerFailureCount"); got != 1 {
+				t.Errorf("WorkerFailureCount = %v, want 1", got)
+			}
+		})
+	}
+}
@@ -4,178 +4,733 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
 
+	"event-pipeline/internal/awsconfig"
+	"event-pipeline/internal/envconfig"
 	"event-pipeline/internal/metrics"
 	"event-pipeline/internal/models"
 	"event-pipeline/internal/processor"
+	"event-pipeline/internal/sink"
+	"event-pipeline/internal/store"
 )
 
+// s3API is the part of the S3 client the worker uses, so tests can stub it
+type s3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	SelectObjectContent(ctx context.Context, params *s3.SelectObjectContentInput, optFns ...func(*s3.Options)) (*s3.SelectObjectContentOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+}
+
+// sqsAPI is the part of the SQS client the worker uses
+type sqsAPI interface {
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
+}
+
 var (
-	s3Client         *s3.Client
-	ddbClient        *dynamodb.Client
-	metricsCollector *metrics.Collector
-	tableName        string
+	s3Client         s3API
+	sqsClient        sqsAPI // for the self-test and visibility heartbeats
+	resultSink       sink.ResultSink
+	trendStore       *store.TrendStore // nil unless TREND_TABLE is set
+	metricsCollector metrics.Collector
+	idempotentWrites bool
+	parserConfig     processor.ParserConfig
+	newParser        processor.ParserFactory
+	resultTTL        time.Duration
+	failedResultTTL  time.Duration
+
+	// savePartialResults keeps the counts gathered before a parse failure
+	// as a "partial" result instead of an empty "failed" one
+	savePartialResults bool
+
+	// Files that fail to parse are copied under quarantinePrefix in their
+	// bucket when it is set
+	quarantinePrefix string
+
+	// deleteOnSuccess removes a job's objects once its completed result is saved
+	deleteOnSuccess bool
+
+	// contentHash stores a SHA-256 of each job's content on its result
+	contentHash bool
+
+	// workerConcurrency bounds how many SQS records are processed at once
+	workerConcurrency int
+
+	// maxRetries is how many redeliveries SQS allows before the DLQ; it
+	// should be one less than the queue's maxReceiveCount
+	maxRetries int
+
+	// s3GetAttempts bounds GetObject attempts on transient errors
+	s3GetAttempts int
+
+	// ddbThrottleAttempts bounds result writes while DynamoDB is throttling
+	ddbThrottleAttempts int
+
+	// selectLevels, when set, pre-filters eligible files with S3 Select so
+	// only entries at these levels are downloaded and aggregated
+	selectLevels []string
+
+	// Per-operation deadlines so one hung call can't consume the whole
+	// Lambda timeout; zero disables a deadline
+	s3ReadTimeout time.Duration
+	ddbTimeout    time.Duration
+)
+
+const (
+	// defaultResultTTLHours is how long results are kept when RESULT_TTL_HOURS is unset
+	defaultResultTTLHours = 7 * 24
+
+	// Default per-operation deadlines, sized for the 30s Lambda timeout
+	defaultS3ReadTimeout = 20 * time.Second
+	defaultDDBTimeout    = 3 * time.Second
 )
 
 func init() {
 	ctx := context.Background()
 
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := awsconfig.Load(ctx)
 	if err != nil {
 		panic(fmt.Sprintf("failed to load config: %v", err))
 	}
 
-	// LocalStack support
-	endpoint := os.Getenv("AWS_ENDPOINT_URL")
+	sqsClient = awsconfig.NewSQS(cfg)
+	s3Client = awsconfig.NewS3(cfg)
 
-	if endpoint != "" {
-		cfg.BaseEndpoint = aws.String(endpoint)
+	// Guard against SQS redelivery overwriting a completed result.
+	// Set IDEMPOTENT_WRITES=false to restore unconditional overwrites.
+	idempotentWrites = envconfig.Bool("IDEMPOTENT_WRITES", true)
+
+	resultSink, err = sink.NewFromEnv(ctx, idempotentWrites)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create result sink: %v", err))
+	}
+	if !envconfig.Bool("SKIP_STARTUP_CHECKS", false) {
+		if err := checkSink(ctx); err != nil {
+			panic(fmt.Sprintf("startup check failed: %v", err))
+		}
 	}
 
-	// Create S3 client with path-style addressing for LocalStack
-	if endpoint != "" {
-		s3Client = s3.NewFromConfig(cfg, func(o *s3.Options) {
-			o.UsePathStyle = true  // CRITICAL: Forces path-style URLs
-		})
-	} else {
-		s3Client = s3.NewFromConfig(cfg)
+	if table := os.Getenv("TREND_TABLE"); table != "" {
+		trendStore, err = store.NewTrendStore(ctx, table, envconfig.Float("TREND_ALPHA", store.DefaultTrendAlpha))
+		if err != nil {
+			panic(fmt.Sprintf("failed to create trend store: %v", err))
+		}
+	}
+
+	savePartialResults = envconfig.Bool("SAVE_PARTIAL_RESULTS", false)
+	quarantinePrefix = os.Getenv("QUARANTINE_PREFIX")
+	deleteOnSuccess = envconfig.Bool("DELETE_ON_SUCCESS", false)
+	contentHash = envconfig.Bool("CONTENT_HASH", false)
+	lambdaPricePerMs = envconfig.Float("LAMBDA_PRICE_PER_MS", defaultLambdaPricePerMs)
+	s3PricePerGB = envconfig.Float("S3_PRICE_PER_GB", defaultS3PricePerGB)
+
+	workerConcurrency = envconfig.Int("WORKER_CONCURRENCY", 1)
+	if workerConcurrency < 1 {
+		workerConcurrency = 1
 	}
-	
-	ddbClient = dynamodb.NewFromConfig(cfg)
-	tableName = os.Getenv("DYNAMODB_TABLE")
 
-	metricsCollector, err = metrics.NewCollector(ctx, "EventPipeline")
+	maxRetries = envconfig.Int("MAX_RETRIES", 2)
+	s3GetAttempts = max(envconfig.Int("S3_GET_MAX_ATTEMPTS", 3), 1)
+	ddbThrottleAttempts = max(envconfig.Int("DDB_THROTTLE_MAX_ATTEMPTS", 3), 1)
+
+	// Keep slow files' messages hidden from other workers; SQS allows at
+	// most 12 hours in flight
+	visibilityHeartbeat = envconfig.Duration("VISIBILITY_HEARTBEAT", 0)
+	visibilityExtension = envconfig.Duration("VISIBILITY_EXTENSION", defaultVisibilityExtension)
+	maxVisibilityExtension = min(envconfig.Duration("MAX_VISIBILITY_EXTENSION", defaultMaxVisibilityExtension), 12*time.Hour)
+	if visibilityHeartbeat > 0 && visibilityExtension <= visibilityHeartbeat {
+		fmt.Printf("Warning: VISIBILITY_EXTENSION %s is not longer than VISIBILITY_HEARTBEAT, using %s\n", visibilityExtension, 2*visibilityHeartbeat)
+		visibilityExtension = 2 * visibilityHeartbeat
+	}
+	s3ReadTimeout = envconfig.Duration("S3_READ_TIMEOUT", defaultS3ReadTimeout)
+	ddbTimeout = envconfig.Duration("DYNAMODB_TIMEOUT", defaultDDBTimeout)
+
+	// Retention for completed and failed results; failed defaults to the same
+	resultTTL = ttlFromEnv("RESULT_TTL_HOURS", defaultResultTTLHours)
+	failedResultTTL = ttlFromEnv("FAILED_RESULT_TTL_HOURS", int(resultTTL/time.Hour))
+
+	parserConfig = processor.ParserConfig{
+		TimestampLayout:       os.Getenv("TIMESTAMP_LAYOUT"),
+		ApproximateUniques:    envconfig.Bool("APPROXIMATE_UNIQUES", false),
+		MaxMalformedRatio:     envconfig.Float("MAX_MALFORMED_RATIO", 0),
+		MalformedSampleSize:   envconfig.Int("MALFORMED_SAMPLE_SIZE", processor.DefaultMalformedSampleSize),
+		InputFormat:           os.Getenv("INPUT_FORMAT"),
+		DuplicateWindow:       envconfig.Int("DUPLICATE_WINDOW", 0),
+		ApproximateDuplicates: envconfig.Bool("APPROXIMATE_DUPLICATES", false),
+		MaxLineBytes:          envconfig.Int("MAX_LINE_BYTES", processor.DefaultMaxLineBytes),
+		AnomalyZScore:         envconfig.Float("ANOMALY_Z_SCORE", processor.DefaultAnomalyZScore),
+		MaxTrackedUsers:       envconfig.Int("MAX_TRACKED_USERS", 0),
+		MaxTimeSeriesMinutes:  envconfig.Int("MAX_TIME_SERIES_MINUTES", 0),
+		MaxSampleErrors:       envconfig.Int("MAX_SAMPLE_ERRORS", 0),
+		ErrorWindow:           envconfig.Duration("ERROR_WINDOW", 0),
+		MaxLines:              envconfig.Int("MAX_LINES", 0),
+		FastJSON:              envconfig.Bool("FAST_JSON", false),
+		DebugSampleRate:       envconfig.Float("DEBUG_SAMPLE_RATE", 0),
+		ResponseTimeField:     os.Getenv("RESPONSE_TIME_FIELD"),
+		ResponseTimeUnit:      os.Getenv("RESPONSE_TIME_UNIT"),
+		MaxResponseTimeMs:     envconfig.Int("MAX_RESPONSE_TIME_MS", processor.DefaultMaxResponseTimeMs),
+	}
+	if err := processor.ValidateResponseTimeUnit(parserConfig.ResponseTimeUnit); err != nil {
+		panic(fmt.Sprintf("invalid RESPONSE_TIME_UNIT: %v", err))
+	}
+
+	// Optional regex for non-JSON log formats
+	if pattern := os.Getenv("LOG_LINE_PATTERN"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			panic(fmt.Sprintf("invalid LOG_LINE_PATTERN: %v", err))
+		}
+		if err := processor.ValidatePattern(re); err != nil {
+			panic(fmt.Sprintf("invalid LOG_LINE_PATTERN: %v", err))
+		}
+		parserConfig.LinePattern = re
+	}
+
+	// Alternative parsers register themselves with processor.RegisterParser
+	newParser, err = processor.ParserFor(os.Getenv("PARSER"))
 	if err != nil {
+		panic(fmt.Sprintf("invalid PARSER: %v", err))
+	}
+
+	// Column layout of CSV/TSV exports
+	if os.Getenv("PARSER") == processor.CSVParser {
+		columns, err := processor.ParseColumns(os.Getenv("CSV_COLUMNS"))
+		if err != nil {
+			panic(fmt.Sprintf("invalid CSV_COLUMNS: %v", err))
+		}
+		parserConfig.Columns = columns
+		if raw := os.Getenv("CSV_DELIMITER"); raw != "" {
+			if parserConfig.Delimiter, err = processor.ParseDelimiter(raw); err != nil {
+				panic(fmt.Sprintf("invalid CSV_DELIMITER: %v", err))
+			}
+		}
+		parserConfig.HeaderRow = envconfig.Bool("CSV_HEADER", false)
+	}
+
+	// Optional discriminator for files mixing several JSON schemas
+	if field := os.Getenv("LOG_SCHEMA_FIELD"); field != "" {
+		schemas, err := processor.ParseSchemas(os.Getenv("LOG_SCHEMAS"))
+		if err != nil {
+			panic(fmt.Sprintf("invalid LOG_SCHEMAS: %v", err))
+		}
+		parserConfig.SchemaField = field
+		parserConfig.Schemas = schemas
+	}
+
+	// Optional paths for logs that nest fields, e.g. under "request"
+	if raw := os.Getenv("FIELD_PATHS"); raw != "" {
+		paths, err := processor.ParseFieldPaths(raw)
+		if err != nil {
+			panic(fmt.Sprintf("invalid FIELD_PATHS: %v", err))
+		}
+		parserConfig.FieldPaths = paths
+	}
+
+	if fields := os.Getenv("REQUIRED_FIELDS"); fields != "" {
+		for _, name := range strings.Split(fields, ",") {
+			parserConfig.RequiredFields = append(parserConfig.RequiredFields, strings.TrimSpace(name))
+		}
+		if err := processor.ValidateRequiredFields(parserConfig.RequiredFields); err != nil {
+			panic(fmt.Sprintf("invalid REQUIRED_FIELDS: %v", err))
+		}
+	}
+
+	if raw := os.Getenv("RESPONSE_TIME_BUCKETS"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			bound, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				panic(fmt.Sprintf("invalid RESPONSE_TIME_BUCKETS: %v", err))
+			}
+			parserConfig.ResponseTimeBuckets = append(parserConfig.ResponseTimeBuckets, bound)
+		}
+	}
+
+	if envconfig.Bool("USE_S3_SELECT", false) {
+		raw := os.Getenv("S3_SELECT_LEVELS")
+		if raw == "" {
+			raw = "ERROR,WARN"
+		}
+		selectLevels, err = parseLevels(raw)
+		if err != nil {
+			panic(fmt.Sprintf("invalid S3_SELECT_LEVELS: %v", err))
+		}
+	}
+
+	// Retried on the next invocation if this fails (see ensureMetrics)
+	if err := initMetrics(ctx); err != nil {
 		fmt.Printf("Warning: failed to create metrics collector: %v\n", err)
 	}
 }
 
-func handler(ctx context.Context, sqsEvent events.SQSEvent) error {
-	for _, record := range sqsEvent.Records {
-		if err := processMessage(ctx, record); err != nil {
-			fmt.Printf("Error processing message: %v\n", err)
-			// Return error to trigger retry/DLQ
-			return err
+// serveMetrics exposes prom at /metrics on METRICS_ADDR (default :9090)
+func serveMetrics(prom *metrics.PrometheusCollector) {
+	addr := os.Getenv("METRICS_ADDR")
+	if addr == "" {
+		addr = ":9090"
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prom.Handler())
+	fmt.Printf("Serving Prometheus metrics on %s/metrics\n", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Printf("Warning: metrics server stopped: %v\n", err)
+	}
+}
+
+func handler(ctx context.Context, payload json.RawMessage) (events.SQSEventResponse, error) {
+	ensureMetrics(ctx)
+
+	// Warmer pings only bring the container and its clients up
+	if isWarmerPing(payload) {
+		fmt.Println("Warmer ping, nothing to process")
+		return events.SQSEventResponse{}, nil
+	}
+
+	var sqsEvent events.SQSEvent
+	if err := json.Unmarshal(payload, &sqsEvent); err != nil {
+		return events.SQSEventResponse{}, fmt.Errorf("failed to decode SQS event: %w", err)
+	}
+
+	// Each goroutine writes only its own slot, so no locking is needed
+	errs := make([]error, len(sqsEvent.Records))
+
+	sem := make(chan struct{}, workerConcurrency)
+	var wg sync.WaitGroup
+	for i, record := range sqsEvent.Records {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = processMessage(ctx, record)
+		}()
+	}
+	wg.Wait()
+
+	var response events.SQSEventResponse
+	for i, err := range errs {
+		if err == nil {
+			continue
 		}
+		record := sqsEvent.Records[i]
+		fmt.Printf("Error processing message %s: %v\n", record.MessageId, err)
+		// Report only this message so SQS retries it (and eventually sends it
+		// to the DLQ) without redelivering the rest of the batch.
+		response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+			ItemIdentifier: record.MessageId,
+		})
 	}
-	return nil
+	return response, nil
 }
 
 func processMessage(ctx context.Context, record events.SQSMessage) error {
 	startTime := time.Now()
 
+	// A ping sent through the queue is acknowledged without any work
+	if isWarmerPing([]byte(record.Body)) {
+		fmt.Printf("Warmer ping in message %s, skipping\n", record.MessageId)
+		return nil
+	}
+
+	// Slow files could outlast the visibility timeout and be picked up
+	// again by another worker while this one is still parsing
+	if visibilityHeartbeat > 0 {
+		stop := startHeartbeat(ctx, record)
+		defer stop()
+	}
+
+	// Backpressure signal: how long jobs wait before a worker takes them
+	if delay, ok := queueDelay(record, startTime); ok && metricsCollector != nil {
+		metricsCollector.EmitLatency(ctx, "WorkerQueueDelayMs", float64(delay.Milliseconds()))
+	}
+
 	// Parse job from SQS message
-	var job models.ProcessingJob
-	if err := json.Unmarshal([]byte(record.Body), &job); err != nil {
-		return fmt.Errorf("failed to unmarshal job: %w", err)
+	job, err := decodeJob(ctx, record)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Processing job %s: %s/%s\n", job.JobID, job.Bucket, job.Key)
+	receiveCount := approximateReceiveCount(record)
 
-	// Fetch file from S3
-	getResp, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(job.Bucket),
-		Key:    aws.String(job.Key),
-	})
-	if err != nil {
-		return saveFailedResult(ctx, job, startTime, fmt.Errorf("failed to get S3 object: %w", err))
+	cfg := parserConfig
+	if job.HasRange() {
+		// A range that doesn't start at the beginning likely starts mid-line
+		cfg.SkipFirstLine = job.ByteRangeStart > 0
 	}
-	defer getResp.Body.Close()
+	parser := newParser(cfg)
 
-	// Process the log file
-	parser := processor.NewLogParser()
-	aggregation, err := parser.Parse(getResp.Body)
-	if err != nil {
-		return saveFailedResult(ctx, job, startTime, fmt.Errorf("failed to parse logs: %w", err))
+	var aggregation *models.LogAggregation
+	var fileSize int64
+	var sourceETag string
+	var levelFilter string
+
+	// Let S3 drop unwanted levels before download when possible
+	if len(selectLevels) > 0 && canSelect(job, cfg) {
+		agg, err := parseSelected(ctx, parser, job)
+		switch {
+		case err == nil:
+			aggregation = agg
+			levelFilter = strings.Join(selectLevels, ",")
+		case errors.Is(err, errS3Select):
+			// The parser may hold part of the filtered stream, so start over
+			fmt.Printf("Job %s: %v, falling back to full download\n", job.JobID, err)
+			parser = newParser(cfg)
+		default:
+			return saveParseFailure(ctx, job, parser, agg, receiveCount, startTime, withCategory(models.ErrorCategoryParse, fmt.Errorf("failed to parse logs: %w", err)))
+		}
 	}
 
-	// Build result
-	result := models.ProcessingResult{
-		JobID:             job.JobID,
-		Status:            "completed",
-		LineCount:         aggregation.TotalLines,
-		ErrorCount:        aggregation.ErrorCount,
-		WarnCount:         aggregation.WarnCount,
-		InfoCount:         aggregation.InfoCount,
-		AvgResponseTimeMs: parser.GetAverageResponseTime(),
-		MaxResponseTimeMs: aggregation.MaxResponseMs,
-		UniqueUsers:       len(aggregation.UniqueUsers),
-		UniqueEndpoints:   len(aggregation.UniqueEndpoints),
-		ProcessingTimeMs:  time.Since(startTime).Milliseconds(),
-		FileSizeBytes:     job.Size,
-		StartedAt:         startTime,
-		CompletedAt:       time.Now(),
-		ExpiresAt:         time.Now().Add(7 * 24 * time.Hour).Unix(), // 7-day TTL
+	// Manifest jobs parse every listed object into the same aggregation, so
+	// counts and uniques combine across files
+	var hashes *contentHashes
+	if aggregation == nil {
+		if contentHash {
+			hashes = &contentHashes{}
+		}
+		for _, key := range job.ObjectKeys() {
+			agg, getResp, err := parseObject(ctx, parser, job, key, hashes)
+			if err != nil {
+				return saveParseFailure(ctx, job, parser, agg, receiveCount, startTime, err)
+			}
+			aggregation = agg
+			fileSize += aws.ToInt64(getResp.ContentLength)
+			sourceETag = aws.ToString(getResp.ETag)
+
+			// The line limit covers the whole job, so skip the rest
+			if agg.Truncated {
+				fmt.Printf("Job %s: stopped at MAX_LINES=%d in %s\n", job.JobID, cfg.MaxLines, key)
+				break
+			}
+		}
 	}
+	if !job.IsManifest() {
+		// Keep the trigger's size, which covers the whole object even when
+		// only a range was fetched
+		fileSize = job.Size
+
+		// The object may have been overwritten since the trigger saw it.
+		// S3 Select doesn't report an ETag.
+		if job.ETag != "" && sourceETag != "" && sourceETag != job.ETag {
+			fmt.Printf("Job %s: object changed since it was queued (ETag %s, now %s)\n", job.JobID, job.ETag, sourceETag)
+		}
+	} else {
+		// No single ETag describes a set of objects
+		sourceETag = ""
+	}
+
+	// Build result
+	result := parser.Result(job.JobID)
+	result.FileSizeBytes = fileSize
+	result.SourceETag = sourceETag
+	result.LevelFilter = levelFilter
+	result.ContentHash = hashes.hex()
+	result.StartedAt = startTime
+	result.CompletedAt = time.Now()
+	result.ExpiresAt = time.Now().Add(resultTTL).Unix()
+	result.SetTiming(time.Since(startTime))
 
 	// Save to DynamoDB
 	if err := saveResult(ctx, result); err != nil {
+		if errors.Is(err, store.ErrAlreadyCompleted) {
+			fmt.Printf("Job %s already completed, skipping duplicate delivery\n", job.JobID)
+			return nil
+		}
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			// Only the save timed out; leave a failed result behind if we can
+			return saveFailedResult(ctx, job, receiveCount, startTime, withCategory(models.ErrorCategoryDDBWrite, fmt.Errorf("failed to save result: %w", err)))
+		}
 		return fmt.Errorf("failed to save result: %w", err)
 	}
 
+	// Only after the completed result is safely stored, and never for
+	// files that were only partly read
+	if deleteOnSuccess && !aggregation.Truncated {
+		deleteSources(ctx, job)
+	}
+	if trendStore != nil {
+		updateTrends(ctx, result)
+	}
+
 	// Emit metrics
 	if metricsCollector != nil {
-		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
-			"WorkerProcessingLatencyMs": metrics.LatencyMs(float64(result.ProcessingTimeMs)),
-			"WorkerLinesProcessed":      metrics.Count(float64(result.LineCount)),
+		// With METRICS_SKIP_ZERO, zero latencies and empty files are still
+		// real observations, so those two are always sent
+		workerMetrics := map[string]metrics.MetricValue{
+			"WorkerProcessingLatencyMs": metrics.Always(metrics.LatencyMs(float64(result.ProcessingTimeMs))),
+			"WorkerLinesProcessed":      metrics.Always(metrics.Count(float64(result.LineCount))),
 			"WorkerErrorsFound":         metrics.Count(float64(result.ErrorCount)),
+			"WorkerMalformedLines":      metrics.Count(float64(result.MalformedLineCount)),
+			"WorkerInvalidEntries":      metrics.Count(float64(result.InvalidEntryCount)),
+			"WorkerDuplicateLines":      metrics.Count(float64(result.DuplicateLineCount)),
+			"WorkerOversizedLines":      metrics.Count(float64(result.OversizedLineCount)),
+			"WorkerResponseTimeP95":     metrics.LatencyMs(float64(result.P95ResponseTimeMs)),
+			"WorkerResponseTimeP99":     metrics.LatencyMs(float64(result.P99ResponseTimeMs)),
+			"WorkerAnomalousRequests":   metrics.Count(float64(result.AnomalousRequestCount)),
+			"WorkerTotalBytesSent":      {Value: float64(result.TotalBytesSent), Unit: cwtypes.StandardUnitBytes},
+			"WorkerHttpErrorRate":       {Value: result.HTTPErrorRate, Unit: cwtypes.StandardUnitNone},
 			"WorkerSuccessCount":        metrics.Count(1),
-		})
+			"WorkerLinesPerSecond":      {Value: result.LinesPerSecond, Unit: cwtypes.StandardUnitCountSecond},
+			"WorkerBytesPerSecond":      {Value: result.BytesPerSecond, Unit: cwtypes.StandardUnitBytesSecond},
+			"WorkerEstimatedCostUSD":    {Value: estimateCost(result), Unit: cwtypes.StandardUnitNone},
+		}
+
+		// Manifest jobs and jobs queued before the trigger recorded
+		// LastModified have no single creation time
+		if !job.ObjectLastModified.IsZero() {
+			workerMetrics["WorkerEndToEndLatencyMs"] = metrics.LatencyMs(float64(job.AgeAt(result.CompletedAt).Milliseconds()))
+		}
+
+		if aggregation.Truncated {
+			workerMetrics["WorkerTruncatedFiles"] = metrics.Count(1)
+		}
+
+		// Memory proxies for right-sizing the Lambda: the longest line is
+		// the largest read buffer, and the exact unique sets grow by key
+		// (both are empty with APPROXIMATE_UNIQUES)
+		workerMetrics["WorkerMaxLineBytes"] = metrics.MetricValue{Value: float64(aggregation.MaxLineBytes), Unit: cwtypes.StandardUnitBytes}
+		workerMetrics["WorkerTrackedUsers"] = metrics.Count(float64(len(aggregation.UniqueUsers)))
+		workerMetrics["WorkerTrackedEndpoints"] = metrics.Count(float64(len(aggregation.UniqueEndpoints)))
+
+		metricsCollector.EmitBatch(ctx, workerMetrics)
+
+		// Publish the whole response-time distribution as one datum
+		if aggregation.AggregatedLines() > 0 {
+			metricsCollector.EmitStatisticSet(ctx, "WorkerResponseTimeMs", metrics.StatisticValues{
+				SampleCount: float64(aggregation.AggregatedLines()),
+				Sum:         float64(aggregation.TotalResponseMs),
+				Minimum:     float64(aggregation.MinResponseMs),
+				Maximum:     float64(aggregation.MaxResponseMs),
+			}, cwtypes.StandardUnitMilliseconds)
+		}
+
+		// One count per status code, split by a StatusCode dimension
+		statusData := make([]metrics.Datum, 0, len(aggregation.StatusCodeCounts))
+		for code, count := range aggregation.StatusCodeCounts {
+			statusData = append(statusData, metrics.Datum{
+				Name:       "WorkerRequestsByStatus",
+				Value:      metrics.Count(float64(count)),
+				Dimensions: map[string]string{"StatusCode": strconv.Itoa(code)},
+			})
+		}
+		metricsCollector.EmitData(ctx, statusData)
 	}
 
 	fmt.Printf("Completed job %s: %d lines in %dms\n", job.JobID, result.LineCount, result.ProcessingTimeMs)
 	return nil
 }
 
-func saveResult(ctx context.Context, result models.ProcessingResult) error {
-	item, err := attributevalue.MarshalMap(result)
+// parseObject fetches one object of the job and feeds it to parser. When
+// parsing fails the partial aggregation is returned with the error.
+func parseObject(ctx context.Context, parser processor.Parser, job models.ProcessingJob, key string, hashes *contentHashes) (*models.LogAggregation, *s3.GetObjectOutput, error) {
+	// The deadline covers reading the body too, since a stalled stream is
+	// as bad as a stalled request
+	if s3ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s3ReadTimeout)
+		defer cancel()
+	}
+
+	getInput := &s3.GetObjectInput{
+		Bucket: aws.String(job.Bucket),
+		Key:    aws.String(key),
+	}
+	if job.HasRange() {
+		getInput.Range = aws.String(job.RangeHeader())
+		fmt.Printf("Fetching range %s of %s/%s\n", *getInput.Range, job.Bucket, key)
+	}
+
+	getResp, err := getObjectWithRetry(ctx, getInput)
+	if err != nil {
+		return nil, nil, withCategory(models.ErrorCategoryS3Fetch, fmt.Errorf("failed to get S3 object %s: %w", key, err))
+	}
+	defer getResp.Body.Close()
+
+	body := io.Reader(getResp.Body)
+	var digest hash.Hash
+	if hashes != nil {
+		body, digest = hashes.tee(body)
+	}
+
+	aggregation, err := parser.Parse(body)
 	if err != nil {
-		return fmt.Errorf("failed to marshal result: %w", err)
+		return aggregation, nil, withCategory(models.ErrorCategoryParse, &objectError{key: key, err: fmt.Errorf("failed to parse logs in %s: %w", key, err)})
+	}
+	if digest != nil {
+		if err := hashes.add(body, digest, aggregation.Truncated); err != nil {
+			return aggregation, nil, withCategory(models.ErrorCategoryS3Fetch, fmt.Errorf("failed to read %s: %w", key, err))
+		}
 	}
+	return aggregation, getResp, nil
+}
+
+// saveResult writes the result to the sink, which refuses to replace a
+// completed one when idempotent writes are enabled. Throttled writes are
+// retried with backoff.
+func saveResult(ctx context.Context, result models.ProcessingResult) error {
+	return writeWithThrottleRetry(ctx, func(ctx context.Context) error {
+		return resultSink.Save(ctx, result)
+	})
+}
 
-	_, err = ddbClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(tableName),
-		Item:      item,
+// saveResults writes several results for one job in batches, such as
+// per-file results alongside a manifest aggregate. DynamoDB batch writes
+// can't be conditional, so completed results may be overwritten regardless
+// of IDEMPOTENT_WRITES. Throttling is retried like saveResult; rewriting
+// the whole set is safe since every write is unconditional.
+func saveResults(ctx context.Context, results []models.ProcessingResult) error {
+	return writeWithThrottleRetry(ctx, func(ctx context.Context) error {
+		return sink.SaveAll(ctx, resultSink, results)
 	})
-	return err
 }
 
-func saveFailedResult(ctx context.Context, job models.ProcessingJob, startTime time.Time, processErr error) error {
+// saveParseFailure records a job whose parse failed midway, as a partial
+// result when enabled and something was aggregated, else as a failed one
+func saveParseFailure(ctx context.Context, job models.ProcessingJob, parser processor.Parser, agg *models.LogAggregation, receiveCount int, startTime time.Time, processErr error) error {
+	if !savePartialResults || agg == nil || agg.ProcessedLines == 0 {
+		return saveFailedResult(ctx, job, receiveCount, startTime, processErr)
+	}
+
+	result := parser.Result(job.JobID)
+	result.Status = models.StatusPartial
+	result.FileSizeBytes = job.Size
+	result.StartedAt = startTime
+	result.CompletedAt = time.Now()
+	result.SetTiming(time.Since(startTime))
+
+	fmt.Printf("Job %s: saving partial result after %d lines\n", job.JobID, result.LineCount)
+	if metricsCollector != nil {
+		metricsCollector.EmitCount(ctx, "WorkerPartialResults", 1)
+	}
+	return recordFailure(ctx, job, result, receiveCount, processErr)
+}
+
+func saveFailedResult(ctx context.Context, job models.ProcessingJob, receiveCount int, startTime time.Time, processErr error) error {
 	result := models.ProcessingResult{
 		JobID:            job.JobID,
-		Status:           "failed",
+		Status:           models.StatusFailed,
 		ProcessingTimeMs: time.Since(startTime).Milliseconds(),
 		FileSizeBytes:    job.Size,
 		StartedAt:        startTime,
 		CompletedAt:      time.Now(),
-		ErrorMessage:     processErr.Error(),
-		ExpiresAt:        time.Now().Add(7 * 24 * time.Hour).Unix(),
 	}
+	return recordFailure(ctx, job, result, receiveCount, processErr)
+}
+
+// recordFailure saves a failed or partial result with the error and retry
+// details filled in, emits failure metrics and returns processErr so SQS
+// redelivers the message
+func recordFailure(ctx context.Context, job models.ProcessingJob, result models.ProcessingResult, receiveCount int, processErr error) error {
+	result.ErrorMessage = processErr.Error()
+	result.ErrorCategory = classifyError(processErr)
+	result.ExpiresAt = time.Now().Add(failedResultTTL).Unix()
+	result.RetryCount = max(receiveCount-1, 0)
+	result.SourceJob = &job
+	// SQS moves the message to the DLQ after this delivery fails
+	result.Terminal = receiveCount > maxRetries
 
 	if err := saveResult(ctx, result); err != nil {
+		if errors.Is(err, store.ErrAlreadyCompleted) {
+			// An earlier delivery already succeeded, so this failure is moot
+			fmt.Printf("Job %s already completed, ignoring failure: %v\n", job.JobID, processErr)
+			return nil
+		}
 		fmt.Printf("Failed to save error result: %v\n", err)
 	}
 
+	// Keep files that can't be parsed where operators can find them
+	if quarantinePrefix != "" && result.ErrorCategory == models.ErrorCategoryParse {
+		if err := quarantineFailed(ctx, job, processErr); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
 	if metricsCollector != nil {
-		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+		failureMetrics := map[string]metrics.MetricValue{
 			"WorkerFailureCount": metrics.Count(1),
-		})
+		}
+		if result.Terminal {
+			failureMetrics["WorkerTerminalFailure"] = metrics.Count(1)
+		}
+		metricsCollector.EmitBatch(ctx, failureMetrics)
+		metricsCollector.EmitData(ctx, []metrics.Datum{{
+			Name:       "WorkerFailureByCategory",
+			Value:      metrics.Count(1),
+			Dimensions: map[string]string{"Category": result.ErrorCategory},
+		}})
+	}
+
+	if result.Terminal {
+		fmt.Printf("Job %s failed permanently after %d deliveries\n", job.JobID, receiveCount)
 	}
 
 	return processErr
 }
 
+// approximateReceiveCount returns how many times SQS has delivered the message,
+// defaulting to 1 when the attribute is missing or malformed
+func approximateReceiveCount(record events.SQSMessage) int {
+	count, err := strconv.Atoi(record.Attributes["ApproximateReceiveCount"])
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// queueDelay returns how long the message waited in the queue before
+// startTime, from its SentTimestamp attribute (epoch milliseconds). It
+// reports false when the attribute is missing or malformed. Clock skew
+// making the delay negative is clamped to zero.
+func queueDelay(record events.SQSMessage, startTime time.Time) (time.Duration, bool) {
+	sentMs, err := strconv.ParseInt(record.Attributes["SentTimestamp"], 10, 64)
+	if err != nil || sentMs <= 0 {
+		return 0, false
+	}
+	return max(startTime.Sub(time.UnixMilli(sentMs)), 0), true
+}
+
+// ttlFromEnv reads a TTL in hours, falling back to defHours for values that
+// would produce an already-expired item
+func ttlFromEnv(name string, defHours int) time.Duration {
+	hours := envconfig.Int(name, defHours)
+	if hours <= 0 {
+		fmt.Printf("Warning: %s must be positive, using default %d\n", name, defHours)
+		hours = defHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// invoke is the Lambda entry point. Self-test requests get a connectivity
+// report; everything else goes to handler.
+func invoke(ctx context.Context, payload json.RawMessage) (any, error) {
+	if req, ok := parseSelfTest(payload); ok {
+		ensureMetrics(ctx)
+		return runSelfTest(ctx, req), nil
+	}
+	return handler(ctx, payload)
+}
+
 func main() {
-	lambda.Start(handler)
-}
\ No newline at end of file
+	lambda.StartWithOptions(invoke, lambda.WithEnableSIGTERM(flushMetrics))
+}
@@ -0,0 +1,573 @@
+// cmd/worker/main_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"event-pipeline/internal/metrics"
+	"event-pipeline/internal/models"
+)
+
+// Package-level initializers run before init, which needs a table and a
+// region and would otherwise describe the table
+var _ = func() bool {
+	os.Setenv("SKIP_STARTUP_CHECKS", "true")
+	os.Setenv("DYNAMODB_TABLE", "test-results")
+	os.Setenv("AWS_REGION", "us-east-1")
+	return true
+}()
+
+// sampleLogs is a small NDJSON file with one error
+const sampleLogs = `{"timestamp":"2024-01-15T10:00:00Z","level":"INFO","endpoint":"/api/users","response_time_ms":120,"status_code":200,"user_id":"u1"}
+{"timestamp":"2024-01-15T10:00:01Z","level":"ERROR","endpoint":"/api/orders","response_time_ms":480,"status_code":500,"user_id":"u2"}
+{"timestamp":"2024-01-15T10:00:02Z","level":"INFO","endpoint":"/api/users","response_time_ms":90,"status_code":200,"user_id":"u1"}
+`
+
+// fakeS3 serves objects from memory by key. Keys in getErrs fail GetObject
+// and missing keys return NoSuchKey.
+type fakeS3 struct {
+	s3API
+
+	mu        sync.Mutex
+	objects   map[string]string
+	getErrs   map[string]error
+	deleteErr error
+	gets      []string
+	copies    []*s3.CopyObjectInput
+	deletes   []string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string]string{}, getErrs: map[string]error{}}
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := aws.ToString(params.Key)
+	f.gets = append(f.gets, key)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := f.getErrs[key]; err != nil {
+		return nil, err
+	}
+	body, ok := f.objects[key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: aws.Int64(int64(len(body))),
+		ETag:          aws.String(`"etag-` + key + `"`),
+	}, nil
+}
+
+func (f *fakeS3) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.copies = append(f.copies, params)
+	return &s3.CopyObjectOutput{}, nil
+}
+
+func (f *fakeS3) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deletes = append(f.deletes, aws.ToString(params.Key))
+	if f.deleteErr != nil {
+		return nil, f.deleteErr
+	}
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// fakeSink records saved results. Each Save returns the next of errs, then
+// nil. The first blockSaves calls hang until their context is done.
+type fakeSink struct {
+	mu         sync.Mutex
+	saved      []models.ProcessingResult
+	errs       []error
+	blockSaves int
+}
+
+func (f *fakeSink) Save(ctx context.Context, result models.ProcessingResult) error {
+	f.mu.Lock()
+	if f.blockSaves > 0 {
+		f.blockSaves--
+		f.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	defer f.mu.Unlock()
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	f.saved = append(f.saved, result)
+	return nil
+}
+
+// results returns the saved results by job ID
+func (f *fakeSink) results() map[string]models.ProcessingResult {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	byJob := make(map[string]models.ProcessingResult, len(f.saved))
+	for _, result := range f.saved {
+		byJob[result.JobID] = result
+	}
+	return byJob
+}
+
+// fakeCollector sums every emitted metric by name
+type fakeCollector struct {
+	mu     sync.Mutex
+	values map[string]float64
+	sets   map[string]metrics.StatisticValues
+	data   []metrics.Datum
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{values: map[string]float64{}, sets: map[string]metrics.StatisticValues{}}
+}
+
+func (f *fakeCollector) add(name string, value float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[name] += value
+	return nil
+}
+
+func (f *fakeCollector) EmitLatency(ctx context.Context, name string, valueMs float64) error {
+	return f.add(name, valueMs)
+}
+
+func (f *fakeCollector) EmitCount(ctx context.Context, name string, value float64) error {
+	return f.add(name, value)
+}
+
+func (f *fakeCollector) EmitBytes(ctx context.Context, name string, value float64) error {
+	return f.add(name, value)
+}
+
+func (f *fakeCollector) EmitStatisticSet(ctx context.Context, name string, set metrics.StatisticValues, unit types.StandardUnit) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sets[name] = set
+	return nil
+}
+
+func (f *fakeCollector) EmitBatch(ctx context.Context, batch map[string]metrics.MetricValue) error {
+	for name, mv := range batch {
+		f.add(name, mv.Value)
+	}
+	return nil
+}
+
+func (f *fakeCollector) EmitBatchWithDimensions(ctx context.Context, batch map[string]metrics.MetricValue, extraDims map[string]string) error {
+	return f.EmitBatch(ctx, batch)
+}
+
+func (f *fakeCollector) EmitData(ctx context.Context, datums []metrics.Datum) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data = append(f.data, datums...)
+	return nil
+}
+
+// value returns the sum emitted under name
+func (f *fakeCollector) value(name string) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[name]
+}
+
+// stubWorker swaps the worker's clients, sink and collector for fakes
+// until the test ends
+func stubWorker(t *testing.T) (*fakeS3, *fakeSink, *fakeCollector) {
+	t.Helper()
+	fs3, fsink, fmetrics := newFakeS3(), &fakeSink{}, newFakeCollector()
+
+	prevS3, prevSink, prevMetrics := s3Client, resultSink, metricsCollector
+	s3Client, resultSink, metricsCollector = fs3, fsink, fmetrics
+	t.Cleanup(func() {
+		s3Client, resultSink, metricsCollector = prevS3, prevSink, prevMetrics
+	})
+	return fs3, fsink, fmetrics
+}
+
+// jobMessage wraps job in an SQS message with the given ID
+func jobMessage(t *testing.T, messageID string, job models.ProcessingJob) events.SQSMessage {
+	t.Helper()
+	body, err := json.Marshal(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events.SQSMessage{
+		MessageId:  messageID,
+		Body:       string(body),
+		Attributes: map[string]string{"ApproximateReceiveCount": "1"},
+	}
+}
+
+// sqsPayload encodes records as the SQS event handler receives
+func sqsPayload(t *testing.T, records ...events.SQSMessage) json.RawMessage {
+	t.Helper()
+	payload, err := json.Marshal(events.SQSEvent{Records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+// testJob is a job for key in the test bucket
+func testJob(jobID, key string) models.ProcessingJob {
+	return models.ProcessingJob{
+		JobID:       jobID,
+		Bucket:      "logs",
+		Key:         key,
+		Size:        int64(len(sampleLogs)),
+		ContentType: "application/x-ndjson",
+	}
+}
+
+func TestHandlerReportsOnlyFailedMessages(t *testing.T) {
+	fs3, fsink, _ := stubWorker(t)
+	fs3.objects["good-1.json"] = sampleLogs
+	fs3.objects["good-2.json"] = sampleLogs
+	fs3.getErrs["broken.json"] = errors.New("connection refused")
+
+	payload := sqsPayload(t,
+		jobMessage(t, "msg-1", testJob("job-1", "good-1.json")),
+		jobMessage(t, "msg-2", testJob("job-2", "broken.json")),
+		jobMessage(t, "msg-3", testJob("job-3", "good-2.json")),
+	)
+
+	resp, err := handler(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("handler returned error: %v", err)
+	}
+
+	if len(resp.BatchItemFailures) != 1 || resp.BatchItemFailures[0].ItemIdentifier != "msg-2" {
+		t.Fatalf("BatchItemFailures = %+v, want only msg-2", resp.BatchItemFailures)
+	}
+
+	results := fsink.results()
+	for _, jobID := range []string{"job-1", "job-3"} {
+		if got := results[jobID]; got.Status != models.StatusCompleted || got.LineCount != 3 {
+			t.Errorf("%s: status %q with %d lines, want completed with 3", jobID, got.Status, got.LineCount)
+		}
+	}
+	if got := results["job-2"]; got.Status != models.StatusFailed || got.ErrorCategory != models.ErrorCategoryS3Fetch {
+		t.Errorf("job-2: status %q category %q, want failed s3_fetch", got.Status, got.ErrorCategory)
+	}
+}
+
+func TestProcessMessageEmitsResponseTimeStatistics(t *testing.T) {
+	fs3, _, fmetrics := stubWorker(t)
+	fs3.objects["app.json"] = sampleLogs
+
+	if err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json"))); err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+
+	want := metrics.StatisticValues{SampleCount: 3, Sum: 690, Minimum: 90, Maximum: 480}
+	if got, ok := fmetrics.sets["WorkerResponseTimeMs"]; !ok || got != want {
+		t.Errorf("WorkerResponseTimeMs statistic set = %+v (sent %v), want %+v", got, ok, want)
+	}
+	if fmetrics.value("WorkerResponseTimeMs") != 0 {
+		t.Error("WorkerResponseTimeMs was also sent as a single value")
+	}
+}
+
+func TestMaxLinesMarksResultTruncated(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxLines      int
+		wantStatus    models.Status
+		wantLines     int
+		wantTruncated float64
+	}{
+		{name: "no cap", wantStatus: models.StatusCompleted, wantLines: 3},
+		{name: "over the cap", maxLines: 2, wantStatus: models.StatusTruncated, wantLines: 2, wantTruncated: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, fsink, fmetrics := stubWorker(t)
+			prev := parserConfig
+			parserConfig.MaxLines = tt.maxLines
+			t.Cleanup(func() { parserConfig = prev })
+			fs3.objects["app.json"] = sampleLogs
+
+			if err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json"))); err != nil {
+				t.Fatalf("processMessage: %v", err)
+			}
+			if got := fsink.results()["job-1"]; got.Status != tt.wantStatus || got.LineCount != tt.wantLines {
+				t.Errorf("status %q with %d lines, want %q with %d", got.Status, got.LineCount, tt.wantStatus, tt.wantLines)
+			}
+			if got := fmetrics.value("WorkerTruncatedFiles"); got != tt.wantTruncated {
+				t.Errorf("WorkerTruncatedFiles = %v, want %v", got, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestParseFailureSavesPartialResult(t *testing.T) {
+	// Two complete array elements, then the file breaks off
+	const truncated = `[{"level":"INFO","endpoint":"/a","response_time_ms":10},{"level":"ERROR","endpoint":"/b","response_time_ms":30},{"level":"WA`
+
+	tests := []struct {
+		name         string
+		save         bool
+		wantStatus   models.Status
+		wantLines    int
+		wantErrors   int
+		wantPartials float64
+	}{
+		{name: "partials disabled", wantStatus: models.StatusFailed},
+		{name: "partials enabled", save: true, wantStatus: models.StatusPartial, wantLines: 2, wantErrors: 1, wantPartials: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, fsink, fmetrics := stubWorker(t)
+			prev := savePartialResults
+			savePartialResults = tt.save
+			t.Cleanup(func() { savePartialResults = prev })
+			fs3.objects["app.json"] = truncated
+
+			if err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json"))); err == nil {
+				t.Fatal("processMessage succeeded on a truncated file")
+			}
+			got := fsink.results()["job-1"]
+			if got.Status != tt.wantStatus || got.LineCount != tt.wantLines || got.ErrorCount != tt.wantErrors {
+				t.Errorf("status %q with %d lines and %d errors, want %q with %d and %d",
+					got.Status, got.LineCount, got.ErrorCount, tt.wantStatus, tt.wantLines, tt.wantErrors)
+			}
+			if got.ErrorCategory != models.ErrorCategoryParse {
+				t.Errorf("ErrorCategory = %q, want %q", got.ErrorCategory, models.ErrorCategoryParse)
+			}
+			if got := fmetrics.value("WorkerPartialResults"); got != tt.wantPartials {
+				t.Errorf("WorkerPartialResults = %v, want %v", got, tt.wantPartials)
+			}
+		})
+	}
+}
+
+func TestEndToEndLatencyClampsClockSkew(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastModified time.Time
+		wantMin      time.Duration
+		wantMax      time.Duration
+	}{
+		{name: "uploaded earlier", lastModified: time.Now().Add(-time.Hour), wantMin: time.Hour, wantMax: time.Hour + time.Minute},
+		// S3's clock ahead of the worker's would make the latency negative
+		{name: "uploaded in the future", lastModified: time.Now().Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, _, fmetrics := stubWorker(t)
+			fs3.objects["app.json"] = sampleLogs
+			job := testJob("job-1", "app.json")
+			job.ObjectLastModified = tt.lastModified
+
+			if err := processMessage(context.Background(), jobMessage(t, "msg-1", job)); err != nil {
+				t.Fatalf("processMessage: %v", err)
+			}
+			fmetrics.mu.Lock()
+			got, ok := fmetrics.values["WorkerEndToEndLatencyMs"]
+			fmetrics.mu.Unlock()
+			if !ok || got < float64(tt.wantMin.Milliseconds()) || got > float64(tt.wantMax.Milliseconds()) {
+				t.Errorf("WorkerEndToEndLatencyMs = %v (sent %v), want within [%v, %v]", got, ok, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestQueueDelay(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 5, 0, time.UTC)
+	sent := strconv.FormatInt(start.Add(-2500*time.Millisecond).UnixMilli(), 10)
+
+	tests := []struct {
+		name   string
+		sent   string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "known timestamp", sent: sent, want: 2500 * time.Millisecond, wantOK: true},
+		// The sender's clock ahead of the worker's
+		{name: "sent after start", sent: strconv.FormatInt(start.Add(time.Second).UnixMilli(), 10), wantOK: true},
+		{name: "missing", sent: ""},
+		{name: "malformed", sent: "yesterday"},
+		{name: "zero", sent: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := events.SQSMessage{Attributes: map[string]string{}}
+			if tt.sent != "" {
+				record.Attributes["SentTimestamp"] = tt.sent
+			}
+			got, ok := queueDelay(record, start)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("queueDelay = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProcessMessageEmitsQueueDelay(t *testing.T) {
+	fs3, _, fmetrics := stubWorker(t)
+	fs3.objects["app.json"] = sampleLogs
+
+	msg := jobMessage(t, "msg-1", testJob("job-1", "app.json"))
+	msg.Attributes["SentTimestamp"] = strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)
+	if err := processMessage(context.Background(), msg); err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+
+	// The worker measures from its own start time, a moment after the minute
+	if got := fmetrics.value("WorkerQueueDelayMs"); got < 60000 || got > 61000 {
+		t.Errorf("WorkerQueueDelayMs = %v, want about 60000", got)
+	}
+}
+
+func TestThirdDeliveryIsTerminal(t *testing.T) {
+	prevRetries := maxRetries
+	maxRetries = 2
+	t.Cleanup(func() { maxRetries = prevRetries })
+
+	tests := []struct {
+		receiveCount   string
+		wantRetryCount int
+		wantTerminal   bool
+	}{
+		{"1", 0, false},
+		{"2", 1, false},
+		{"3", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run("delivery "+tt.receiveCount, func(t *testing.T) {
+			fs3, fsink, fmetrics := stubWorker(t)
+			fs3.getErrs["poison.json"] = errors.New("connection refused")
+
+			record := jobMessage(t, "msg-1", testJob("job-1", "poison.json"))
+			record.Attributes["ApproximateReceiveCount"] = tt.receiveCount
+
+			// Every delivery fails so SQS redelivers, or moves it to the DLQ
+			if err := processMessage(context.Background(), record); err == nil {
+				t.Fatal("processMessage succeeded, want the fetch error")
+			}
+
+			result := fsink.results()["job-1"]
+			if result.Status != models.StatusFailed || result.RetryCount != tt.wantRetryCount || result.Terminal != tt.wantTerminal {
+				t.Errorf("status %q retry_count %d terminal %v, want failed %d %v", result.Status, result.RetryCount, result.Terminal, tt.wantRetryCount, tt.wantTerminal)
+			}
+			if result.SourceJob == nil || result.SourceJob.Key != "poison.json" {
+				t.Errorf("source_job = %+v, want the failed job", result.SourceJob)
+			}
+
+			wantTerminalMetric := 0.0
+			if tt.wantTerminal {
+				wantTerminalMetric = 1
+			}
+			if got := fmetrics.value("WorkerTerminalFailure"); got != wantTerminalMetric {
+				t.Errorf("WorkerTerminalFailure = %v, want %v", got, wantTerminalMetric)
+			}
+			if got := fmetrics.value("WorkerFailureCount"); got != 1 {
+				t.Errorf("WorkerFailureCount = %v, want 1", got)
+			}
+		})
+	}
+}
+
+func TestManifestJobCombinesFiles(t *testing.T) {
+	fs3, fsink, _ := stubWorker(t)
+	shardA := `{"level":"INFO","endpoint":"/a","response_time_ms":10,"user_id":"u1"}
+{"level":"INFO","endpoint":"/a","response_time_ms":20,"user_id":"u2"}
+`
+	shardB := `{"level":"ERROR","endpoint":"/b","response_time_ms":30,"user_id":"u2"}
+{"level":"INFO","endpoint":"/a","response_time_ms":40,"user_id":"u3"}
+{"level":"INFO","endpoint":"/c","response_time_ms":50,"user_id":"u1"}
+`
+	fs3.objects["run/shard-a.json"] = shardA
+	fs3.objects["run/shard-b.json"] = shardB
+
+	job := testJob("job-1", "run/manifest")
+	job.Keys = []string{"run/shard-a.json", "run/shard-b.json"}
+	if err := processMessage(context.Background(), jobMessage(t, "msg-1", job)); err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+
+	result := fsink.results()["job-1"]
+	if result.LineCount != 5 || result.ErrorCount != 1 {
+		t.Errorf("lines %d errors %d, want 5 and 1", result.LineCount, result.ErrorCount)
+	}
+	// u1 and u2 appear in both shards
+	if result.UniqueUsers != 3 || result.UniqueEndpoints != 3 {
+		t.Errorf("unique users %d endpoints %d, want 3 and 3", result.UniqueUsers, result.UniqueEndpoints)
+	}
+	if want := int64(len(shardA) + len(shardB)); result.FileSizeBytes != want {
+		t.Errorf("FileSizeBytes = %d, want %d", result.FileSizeBytes, want)
+	}
+	if result.SourceETag != "" {
+		t.Errorf("SourceETag = %q, want none for a manifest", result.SourceETag)
+	}
+	if len(fs3.gets) != 2 {
+		t.Errorf("fetched %q, want both shards", fs3.gets)
+	}
+}
+
+func TestProcessMessageWithCanceledContext(t *testing.T) {
+	fs3, _, _ := stubWorker(t)
+	fs3.objects["app.json"] = sampleLogs
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := processMessage(ctx, jobMessage(t, "msg-1", testJob("job-1", "app.json")))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("processMessage = %v, want context.Canceled", err)
+	}
+	// Cancellation is not a transient S3 error, so there is no retry
+	if len(fs3.gets) != 1 {
+		t.Errorf("GetObject called %d times, want 1", len(fs3.gets))
+	}
+}
+
+func TestSaveTimeoutStillWritesFailedResult(t *testing.T) {
+	fs3, fsink, _ := stubWorker(t)
+	fs3.objects["app.json"] = sampleLogs
+	fsink.blockSaves = 1
+
+	prevTimeout := ddbTimeout
+	ddbTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { ddbTimeout = prevTimeout })
+
+	err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json")))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("processMessage = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The hung save of the completed result is followed by a failed one
+	result, ok := fsink.results()["job-1"]
+	if !ok || result.Status != models.StatusFailed || result.ErrorCategory != models.ErrorCategoryTimeout {
+		t.Errorf("saved %+v (%v), want a failed result with category timeout", result, ok)
+	}
+}
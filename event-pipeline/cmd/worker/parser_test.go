@@ -0,0 +1,91 @@
+// cmd/worker/parser_test.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/processor"
+)
+
+// stubParser counts the bytes it is given and reports a fixed result, so
+// tests see exactly what the worker adds around a parser
+type stubParser struct {
+	read bytes.Buffer
+	agg  *models.LogAggregation
+}
+
+func (p *stubParser) Parse(reader io.Reader) (*models.LogAggregation, error) {
+	if _, err := p.read.ReadFrom(reader); err != nil {
+		return p.agg, err
+	}
+	p.agg.TotalLines += bytes.Count(p.read.Bytes(), []byte("\n"))
+	return p.agg, nil
+}
+
+func (p *stubParser) GetAverageResponseTime() float64 { return 42 }
+
+func (p *stubParser) Result(jobID string) models.ProcessingResult {
+	return models.ProcessingResult{
+		JobID:             jobID,
+		Status:            models.StatusCompleted,
+		LineCount:         p.agg.TotalLines,
+		AvgResponseTimeMs: p.GetAverageResponseTime(),
+	}
+}
+
+// withStubParser makes the worker parse with stubParser until the test
+// ends, returning each parser it creates
+func withStubParser(t *testing.T) *[]*stubParser {
+	t.Helper()
+	var created []*stubParser
+	prev := newParser
+	newParser = func(cfg processor.ParserConfig) processor.Parser {
+		p := &stubParser{agg: models.NewLogAggregation()}
+		created = append(created, p)
+		return p
+	}
+	t.Cleanup(func() { newParser = prev })
+	return &created
+}
+
+func TestResultBuiltAroundParser(t *testing.T) {
+	fs3, fsink, _ := stubWorker(t)
+	parsers := withStubParser(t)
+	fs3.objects["app.json"] = sampleLogs
+
+	before := time.Now()
+	if err := processMessage(context.Background(), jobMessage(t, "msg-1", testJob("job-1", "app.json"))); err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+
+	if len(*parsers) != 1 {
+		t.Fatalf("created %d parsers, want 1", len(*parsers))
+	}
+	if got := (*parsers)[0].read.String(); got != sampleLogs {
+		t.Errorf("parser read %q, want the object body", got)
+	}
+
+	result, ok := fsink.results()["job-1"]
+	if !ok {
+		t.Fatal("no result saved")
+	}
+	// From the parser
+	if result.Status != models.StatusCompleted || result.LineCount != 3 || result.AvgResponseTimeMs != 42 {
+		t.Errorf("status %q lines %d avg %v, want the parser's completed 3 and 42", result.Status, result.LineCount, result.AvgResponseTimeMs)
+	}
+	// From the worker
+	if result.FileSizeBytes != int64(len(sampleLogs)) || result.SourceETag != `"etag-app.json"` {
+		t.Errorf("size %d etag %q, want the job's size and the object's ETag", result.FileSizeBytes, result.SourceETag)
+	}
+	if result.StartedAt.Before(before) || result.CompletedAt.Before(result.StartedAt) {
+		t.Errorf("started %v completed %v, want both after %v in order", result.StartedAt, result.CompletedAt, before)
+	}
+	if result.ExpiresAt <= time.Now().Unix() {
+		t.Errorf("ExpiresAt = %d, want a future expiry", result.ExpiresAt)
+	}
+}
@@ -0,0 +1,55 @@
+// cmd/worker/quarantine.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"event-pipeline/internal/models"
+)
+
+// objectError records which of a job's objects an error came from
+type objectError struct {
+	key string
+	err error
+}
+
+func (e *objectError) Error() string { return e.err.Error() }
+func (e *objectError) Unwrap() error { return e.err }
+
+// quarantineFailed copies the object that failed to parse under
+// quarantinePrefix in the same bucket, keeping its key as the suffix. The
+// original is left in place. It is best effort: the caller logs the error
+// and still reports the parse failure.
+func quarantineFailed(ctx context.Context, job models.ProcessingJob, processErr error) error {
+	key := job.Key
+	var objErr *objectError
+	if errors.As(processErr, &objErr) {
+		key = objErr.key
+	} else if job.IsManifest() {
+		return fmt.Errorf("no failed object recorded for manifest job %s", job.JobID)
+	}
+
+	// Don't nest copies of files that were already quarantined
+	if strings.HasPrefix(key, quarantinePrefix) {
+		return nil
+	}
+
+	dest := quarantinePrefix + key
+	_, err := s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(job.Bucket),
+		Key:        aws.String(dest),
+		CopySource: aws.String(url.PathEscape(job.Bucket + "/" + key)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to quarantine %s/%s: %w", job.Bucket, key, err)
+	}
+	fmt.Printf("Quarantined %s/%s to %s\n", job.Bucket, key, dest)
+	return nil
+}
@@ -0,0 +1,93 @@
+// cmd/worker/quarantine_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// withQuarantine sets quarantinePrefix until the test ends
+func withQuarantine(t *testing.T, prefix string) {
+	t.Helper()
+	prev := quarantinePrefix
+	quarantinePrefix = prefix
+	t.Cleanup(func() { quarantinePrefix = prev })
+}
+
+func TestParseFailureIsQuarantined(t *testing.T) {
+	tests := []struct {
+		name     string
+		objects  map[string]string
+		getErrs  map[string]error
+		key      string
+		manifest []string
+		wantCopy string // failed key, empty when nothing is copied
+	}{
+		{
+			name:     "unparseable file",
+			objects:  map[string]string{"logs/app 1.json": "["},
+			key:      "logs/app 1.json",
+			wantCopy: "logs/app 1.json",
+		},
+		{
+			// Only the shard that failed is copied
+			name:     "manifest shard",
+			objects:  map[string]string{"run/a.json": sampleLogs, "run/b.json": "["},
+			key:      "run/manifest",
+			manifest: []string{"run/a.json", "run/b.json"},
+			wantCopy: "run/b.json",
+		},
+		{
+			name:    "fetch failure",
+			getErrs: map[string]error{"logs/app.json": errors.New("connection refused")},
+			key:     "logs/app.json",
+		},
+		{
+			name:    "already quarantined",
+			objects: map[string]string{"quarantine/logs/app.json": "["},
+			key:     "quarantine/logs/app.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, _, _ := stubWorker(t)
+			withQuarantine(t, "quarantine/")
+			for key, body := range tt.objects {
+				fs3.objects[key] = body
+			}
+			for key, err := range tt.getErrs {
+				fs3.getErrs[key] = err
+			}
+
+			job := testJob("job-1", tt.key)
+			job.Keys = tt.manifest
+			if err := processMessage(context.Background(), jobMessage(t, "msg-1", job)); err == nil {
+				t.Fatal("processMessage succeeded, want an error")
+			}
+
+			if tt.wantCopy == "" {
+				if len(fs3.copies) != 0 {
+					t.Errorf("copied %d objects, want none", len(fs3.copies))
+				}
+				return
+			}
+			if len(fs3.copies) != 1 {
+				t.Fatalf("copied %d objects, want 1", len(fs3.copies))
+			}
+			copied := fs3.copies[0]
+			if got, want := aws.ToString(copied.Key), "quarantine/"+tt.wantCopy; got != want {
+				t.Errorf("copied to %q, want %q", got, want)
+			}
+			if got, want := aws.ToString(copied.CopySource), url.PathEscape("logs/"+tt.wantCopy); got != want {
+				t.Errorf("copy source %q, want %q", got, want)
+			}
+			if got := aws.ToString(copied.Bucket); got != "logs" {
+				t.Errorf("copied into bucket %q, want logs", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// cmd/worker/s3fetch.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+)
+
+// s3RetryBaseDelay is the initial GetObject backoff, doubled on each attempt
+const s3RetryBaseDelay = 200 * time.Millisecond
+
+// getObjectWithRetry wraps GetObject with bounded exponential backoff for
+// transient failures. Missing objects and permission errors fail immediately.
+func getObjectWithRetry(ctx context.Context, input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	var err error
+	for attempt := 1; attempt <= s3GetAttempts; attempt++ {
+		var resp *s3.GetObjectOutput
+		resp, err = s3Client.GetObject(ctx, input)
+		if err == nil {
+			return resp, nil
+		}
+
+		// Never leak a connection from a partially successful response
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+
+		if !isRetryableS3Error(err) || attempt == s3GetAttempts {
+			break
+		}
+
+		delay := rand.N(s3RetryBaseDelay<<(attempt-1) + 1)
+		fmt.Printf("GetObject attempt %d failed, retrying in %v: %v\n", attempt, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	return nil, err
+}
+
+// isRetryableS3Error reports whether a GetObject error is likely transient
+func isRetryableS3Error(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NoSuchBucket", "AccessDenied", "InvalidRange", "InvalidObjectState":
+			return false
+		case "SlowDown", "InternalError", "ServiceUnavailable", "RequestTimeout", "Throttling":
+			return true
+		}
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() >= 500
+	}
+
+	// Connection resets and network timeouts surface without an HTTP response
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
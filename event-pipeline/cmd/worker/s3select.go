@@ -0,0 +1,109 @@
+// cmd/worker/s3select.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/processor"
+)
+
+// errS3Select marks failures of S3 Select itself, as opposed to parse
+// errors, so the caller knows a full download may still succeed
+var errS3Select = errors.New("s3 select failed")
+
+// selectLevelPattern keeps configured levels safe to embed in the SQL
+var selectLevelPattern = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// parseLevels splits a comma-separated level list and validates each level
+func parseLevels(raw string) ([]string, error) {
+	var levels []string
+	for _, level := range strings.Split(raw, ",") {
+		level = strings.ToUpper(strings.TrimSpace(level))
+		if !selectLevelPattern.MatchString(level) {
+			return nil, fmt.Errorf("invalid level %q", level)
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// canSelect reports whether the job's input suits S3 Select: a single whole
+// NDJSON object with a top-level level. Ranges, manifests, plain-text logs
+// and CSV rows always download.
+func canSelect(job models.ProcessingJob, cfg processor.ParserConfig) bool {
+	return !job.IsManifest() && !job.HasRange() && cfg.LinePattern == nil && cfg.InputFormat != processor.FormatJSONArray &&
+		cfg.FieldPaths["level"] == "" && len(cfg.Columns) == 0
+}
+
+// parseSelected streams only the lines whose level is in selectLevels
+// through parser. Failures of the select stream wrap errS3Select.
+func parseSelected(ctx context.Context, parser processor.Parser, job models.ProcessingJob) (*models.LogAggregation, error) {
+	if s3ReadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s3ReadTimeout)
+		defer cancel()
+	}
+
+	quoted := make([]string, len(selectLevels))
+	for i, level := range selectLevels {
+		quoted[i] = "'" + level + "'"
+	}
+	query := fmt.Sprintf("SELECT * FROM S3Object s WHERE UPPER(s.\"level\") IN (%s)", strings.Join(quoted, ", "))
+
+	out, err := s3Client.SelectObjectContent(ctx, &s3.SelectObjectContentInput{
+		Bucket:         aws.String(job.Bucket),
+		Key:            aws.String(job.Key),
+		Expression:     aws.String(query),
+		ExpressionType: types.ExpressionTypeSql,
+		InputSerialization: &types.InputSerialization{
+			JSON: &types.JSONInput{Type: types.JSONTypeLines},
+		},
+		OutputSerialization: &types.OutputSerialization{
+			JSON: &types.JSONOutput{RecordDelimiter: aws.String("\n")},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errS3Select, err)
+	}
+
+	stream := out.GetStream()
+	defer stream.Close()
+
+	// Feed record payloads to the parser as one continuous NDJSON stream
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		ended := false
+		for event := range stream.Events() {
+			switch e := event.(type) {
+			case *types.SelectObjectContentEventStreamMemberRecords:
+				if _, err := pw.Write(e.Value.Payload); err != nil {
+					return // the parser stopped reading
+				}
+			case *types.SelectObjectContentEventStreamMemberEnd:
+				ended = true
+			}
+		}
+		switch {
+		case stream.Err() != nil:
+			pw.CloseWithError(fmt.Errorf("%w: %w", errS3Select, stream.Err()))
+		case !ended:
+			// Without an End event the results may be incomplete
+			pw.CloseWithError(fmt.Errorf("%w: stream ended early", errS3Select))
+		default:
+			pw.Close()
+		}
+	}()
+
+	return parser.Parse(pr)
+}
@@ -0,0 +1,140 @@
+// cmd/worker/selftest.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+
+	"event-pipeline/internal/sink"
+)
+
+// selfTestRequest invokes the worker's connectivity check instead of
+// processing work. Bucket and QueueURL default to UPLOAD_BUCKET and QUEUE_URL.
+//
+//	{"selftest": true, "bucket": "...", "queue_url": "..."}
+type selfTestRequest struct {
+	SelfTest bool   `json:"selftest"`
+	Bucket   string `json:"bucket"`
+	QueueURL string `json:"queue_url"`
+}
+
+// selfTestCheck is the outcome of one harmless call
+type selfTestCheck struct {
+	Service string `json:"service"`
+	Action  string `json:"action"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"` // nothing configured to check against
+
+	// AccessDenied marks failures caused by a missing IAM permission rather
+	// than, say, a wrong name or an unreachable endpoint
+	AccessDenied bool   `json:"access_denied,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// selfTestReport is returned as the invocation result
+type selfTestReport struct {
+	OK     bool            `json:"ok"`
+	Checks []selfTestCheck `json:"checks"`
+}
+
+// parseSelfTest returns the request when payload asks for a self-test
+func parseSelfTest(payload []byte) (selfTestRequest, bool) {
+	var req selfTestRequest
+	if json.Unmarshal(payload, &req) != nil || !req.SelfTest {
+		return req, false
+	}
+	if req.Bucket == "" {
+		req.Bucket = os.Getenv("UPLOAD_BUCKET")
+	}
+	if req.QueueURL == "" {
+		req.QueueURL = os.Getenv("QUEUE_URL")
+	}
+	return req, true
+}
+
+// runSelfTest makes one read-only call per service the worker depends on
+// (plus a zero-valued metric) and reports which ones the role can't make
+func runSelfTest(ctx context.Context, req selfTestRequest) selfTestReport {
+	var checks []selfTestCheck
+
+	checks = append(checks, runCheck("s3", "s3:ListBucket (HeadBucket)", req.Bucket != "", func() error {
+		_, err := s3Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(req.Bucket)})
+		return err
+	}))
+
+	checks = append(checks, runCheck("sqs", "sqs:GetQueueAttributes", req.QueueURL != "", func() error {
+		_, err := sqsClient.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+			QueueUrl:       aws.String(req.QueueURL),
+			AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+		})
+		return err
+	}))
+
+	// Sinks without a Checker, such as http, are reported as skipped
+	checker, ok := resultSink.(sink.Checker)
+	action := sink.Kind()
+	if ok {
+		action = checker.CheckAction()
+	}
+	checks = append(checks, runCheck(sink.Kind(), action, ok, func() error {
+		return checker.Check(ctx)
+	}))
+
+	// Prometheus never calls CloudWatch, so there is nothing to check
+	pinger, ok := metricsCollector.(interface{ Ping(context.Context) error })
+	checks = append(checks, runCheck("cloudwatch", "cloudwatch:PutMetricData", ok, func() error {
+		return pinger.Ping(ctx)
+	}))
+
+	report := selfTestReport{OK: true, Checks: checks}
+	for _, check := range checks {
+		if !check.OK && !check.Skipped {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+// runCheck runs call unless enabled is false, in which case the check is
+// reported as skipped
+func runCheck(service, action string, enabled bool, call func() error) selfTestCheck {
+	check := selfTestCheck{Service: service, Action: action}
+	if !enabled {
+		check.Skipped = true
+		return check
+	}
+
+	if err := call(); err != nil {
+		check.Error = err.Error()
+		check.AccessDenied = isAccessDenied(err)
+		fmt.Printf("Self-test %s failed: %v\n", action, err)
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// isAccessDenied reports whether err is an authorization failure
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "AccessDenied", "AccessDeniedException", "AuthorizationError", "UnauthorizedOperation", "Forbidden":
+			return true
+		}
+	}
+
+	// HeadBucket has no body, so a denial only shows as a 403
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == 403
+}
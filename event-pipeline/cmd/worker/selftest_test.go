@@ -0,0 +1,185 @@
+// cmd/worker/selftest_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// selfTestS3 answers HeadBucket with err
+type selfTestS3 struct {
+	s3API
+	err error
+}
+
+func (f *selfTestS3) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	return &s3.HeadBucketOutput{}, f.err
+}
+
+// selfTestSQS answers GetQueueAttributes with err
+type selfTestSQS struct {
+	sqsAPI
+	err error
+}
+
+func (f *selfTestSQS) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{}, f.err
+}
+
+// checkingSink is a sink.Checker whose Check returns err
+type checkingSink struct {
+	fakeSink
+	err error
+}
+
+func (f *checkingSink) Check(ctx context.Context) error { return f.err }
+func (f *checkingSink) CheckAction() string             { return "dynamodb:DescribeTable" }
+
+// pingingCollector is a collector that can Ping CloudWatch, returning err
+type pingingCollector struct {
+	*fakeCollector
+	err error
+}
+
+func (f *pingingCollector) Ping(ctx context.Context) error { return f.err }
+
+// forbidden is how HeadBucket reports a denial: a bare 403 without a code
+func forbidden() error {
+	return &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: http.StatusForbidden}},
+		Err:      errors.New("forbidden"),
+	}}
+}
+
+func TestRunSelfTest(t *testing.T) {
+	denied := &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+	missingQueue := &smithy.GenericAPIError{Code: "AWS.SimpleQueueService.NonExistentQueue"}
+
+	// Per check, in report order (s3, sqs, dynamodb, cloudwatch): "ok",
+	// "skipped", "denied" or "failed"
+	tests := []struct {
+		name       string
+		req        selfTestRequest
+		s3Err      error
+		sqsErr     error
+		sinkErr    error
+		pingErr    error
+		noChecker  bool
+		noPing     bool
+		wantChecks [4]string
+		wantOK     bool
+	}{
+		{
+			name:       "all reachable",
+			req:        selfTestRequest{Bucket: "uploads", QueueURL: "https://sqs/queue"},
+			wantChecks: [4]string{"ok", "ok", "ok", "ok"},
+			wantOK:     true,
+		},
+		{
+			name:       "HeadBucket forbidden",
+			req:        selfTestRequest{Bucket: "uploads", QueueURL: "https://sqs/queue"},
+			s3Err:      forbidden(),
+			wantChecks: [4]string{"denied", "ok", "ok", "ok"},
+		},
+		{
+			// A wrong name is a failure but not a missing permission
+			name:       "queue missing",
+			req:        selfTestRequest{Bucket: "uploads", QueueURL: "https://sqs/queue"},
+			sqsErr:     missingQueue,
+			wantChecks: [4]string{"ok", "failed", "ok", "ok"},
+		},
+		{
+			name:       "table and metrics denied",
+			req:        selfTestRequest{Bucket: "uploads", QueueURL: "https://sqs/queue"},
+			sinkErr:    denied,
+			pingErr:    denied,
+			wantChecks: [4]string{"ok", "ok", "denied", "denied"},
+		},
+		{
+			// Nothing configured to check against fails nothing
+			name:       "nothing configured",
+			noChecker:  true,
+			noPing:     true,
+			wantChecks: [4]string{"skipped", "skipped", "skipped", "skipped"},
+			wantOK:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, fmetrics := stubWorker(t)
+			prevSQS := sqsClient
+			t.Cleanup(func() { sqsClient = prevSQS })
+			s3Client = &selfTestS3{err: tt.s3Err}
+			sqsClient = &selfTestSQS{err: tt.sqsErr}
+			if !tt.noChecker {
+				resultSink = &checkingSink{err: tt.sinkErr}
+			}
+			if !tt.noPing {
+				metricsCollector = &pingingCollector{fakeCollector: fmetrics, err: tt.pingErr}
+			}
+
+			report := runSelfTest(context.Background(), tt.req)
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Fatalf("got %d checks, want %d", len(report.Checks), len(tt.wantChecks))
+			}
+			for i, check := range report.Checks {
+				got := "failed"
+				switch {
+				case check.Skipped:
+					got = "skipped"
+				case check.OK:
+					got = "ok"
+				case check.AccessDenied:
+					got = "denied"
+				}
+				if got != tt.wantChecks[i] {
+					t.Errorf("%s check %s = %s (%s), want %s", check.Service, check.Action, got, check.Error, tt.wantChecks[i])
+				}
+				if !check.OK && !check.Skipped && check.Error == "" {
+					t.Errorf("%s check failed without an error message", check.Service)
+				}
+			}
+			if report.OK != tt.wantOK {
+				t.Errorf("report OK = %v, want %v", report.OK, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseSelfTest(t *testing.T) {
+	t.Setenv("UPLOAD_BUCKET", "uploads")
+	t.Setenv("QUEUE_URL", "https://sqs/queue")
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantOK     bool
+		wantBucket string
+		wantQueue  string
+	}{
+		{name: "defaults from env", payload: `{"selftest":true}`, wantOK: true, wantBucket: "uploads", wantQueue: "https://sqs/queue"},
+		{name: "overrides", payload: `{"selftest":true,"bucket":"other","queue_url":"https://sqs/other"}`, wantOK: true, wantBucket: "other", wantQueue: "https://sqs/other"},
+		{name: "flag off", payload: `{"selftest":false}`},
+		{name: "SQS event", payload: `{"Records":[]}`},
+		{name: "not JSON", payload: `selftest`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, ok := parseSelfTest([]byte(tt.payload))
+			if ok != tt.wantOK {
+				t.Fatalf("parseSelfTest(%s) ok = %v, want %v", tt.payload, ok, tt.wantOK)
+			}
+			if ok && (req.Bucket != tt.wantBucket || req.QueueURL != tt.wantQueue) {
+				t.Errorf("bucket %q queue %q, want %q and %q", req.Bucket, req.QueueURL, tt.wantBucket, tt.wantQueue)
+			}
+		})
+	}
+}
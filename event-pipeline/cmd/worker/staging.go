@@ -0,0 +1,44 @@
+// cmd/worker/staging.go
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"event-pipeline/internal/models"
+)
+
+// fetchStagedJob reads a job the trigger staged in S3 because it was too
+// large for an SQS message. The staged object is left for the bucket's
+// lifecycle rule, so a redelivered message can still find it.
+func fetchStagedJob(ctx context.Context, staged models.StagedJob) (models.ProcessingJob, error) {
+	var job models.ProcessingJob
+	if staged.Bucket == "" || staged.Key == "" {
+		return job, fmt.Errorf("staged job pointer is missing its bucket or key")
+	}
+
+	resp, err := getObjectWithRetry(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(staged.Bucket),
+		Key:    aws.String(staged.Key),
+	})
+	if err != nil {
+		return job, fmt.Errorf("failed to get staged job %s/%s: %w", staged.Bucket, staged.Key, err)
+	}
+	defer resp.Body.Close()
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return job, fmt.Errorf("failed to decompress staged job %s/%s: %w", staged.Bucket, staged.Key, err)
+	}
+	defer zr.Close()
+
+	if err := json.NewDecoder(zr).Decode(&job); err != nil {
+		return job, fmt.Errorf("failed to unmarshal staged job %s/%s: %w", staged.Bucket, staged.Key, err)
+	}
+	return job, nil
+}
@@ -0,0 +1,19 @@
+// cmd/worker/startup.go
+package main
+
+import (
+	"context"
+
+	"event-pipeline/internal/sink"
+)
+
+// checkSink confirms the result sink's destination exists, so a wrong
+// DYNAMODB_TABLE or RESULT_SINK_BUCKET fails at cold start instead of after
+// a file has been parsed. Sinks without a Checker are not checked.
+func checkSink(ctx context.Context) error {
+	checker, ok := resultSink.(sink.Checker)
+	if !ok {
+		return nil
+	}
+	return checker.Check(ctx)
+}
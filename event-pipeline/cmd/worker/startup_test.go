@@ -0,0 +1,54 @@
+// cmd/worker/startup_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// checkedSink is a fakeSink whose destination check returns checkErr
+type checkedSink struct {
+	fakeSink
+	checkErr error
+	checks   int
+}
+
+func (c *checkedSink) Check(ctx context.Context) error {
+	c.checks++
+	return c.checkErr
+}
+
+func (c *checkedSink) CheckAction() string { return "test:Check" }
+
+func TestCheckSink(t *testing.T) {
+	missing := errors.New("DynamoDB table test-results does not exist")
+	tests := []struct {
+		name    string
+		sink    *checkedSink
+		wantErr error
+	}{
+		{name: "destination exists", sink: &checkedSink{}},
+		{name: "destination missing", sink: &checkedSink{checkErr: missing}, wantErr: missing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubWorker(t)
+			resultSink = tt.sink
+
+			if err := checkSink(context.Background()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("checkSink = %v, want %v", err, tt.wantErr)
+			}
+			if tt.sink.checks != 1 {
+				t.Errorf("Check called %d times, want 1", tt.sink.checks)
+			}
+		})
+	}
+}
+
+func TestCheckSinkSkipsSinksWithoutChecker(t *testing.T) {
+	stubWorker(t)
+	if err := checkSink(context.Background()); err != nil {
+		t.Errorf("checkSink = %v, want nil for a sink without a check", err)
+	}
+}
@@ -0,0 +1,43 @@
+// cmd/worker/trends.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"event-pipeline/internal/metrics"
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/processor"
+)
+
+// updateTrends folds a completed result's top endpoints into their
+// long-term latency averages. Like deleteSources it is best effort:
+// failures are logged and counted under WorkerTrendUpdateFailures, and the
+// job still succeeds.
+func updateTrends(ctx context.Context, result models.ProcessingResult) {
+	updateCtx := ctx
+	if ddbTimeout > 0 {
+		var cancel context.CancelFunc
+		updateCtx, cancel = context.WithTimeout(ctx, ddbTimeout)
+		defer cancel()
+	}
+
+	failures := 0
+	for _, endpoint := range result.TopEndpoints {
+		// The overflow bucket mixes whatever endpoints didn't fit
+		if endpoint.Endpoint == processor.OtherEndpoint {
+			continue
+		}
+		if _, err := trendStore.Update(updateCtx, endpoint.Endpoint, endpoint.AvgResponseTimeMs, time.Now()); err != nil {
+			fmt.Printf("Warning: failed to update latency trend for %s: %v\n", endpoint.Endpoint, err)
+			failures++
+		}
+	}
+
+	if failures > 0 && metricsCollector != nil {
+		metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+			"WorkerTrendUpdateFailures": metrics.Count(float64(failures)),
+		})
+	}
+}
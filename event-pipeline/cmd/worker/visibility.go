@@ -0,0 +1,135 @@
+// cmd/worker/visibility.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+
+	"event-pipeline/internal/metrics"
+)
+
+const (
+	// defaultVisibilityExtension is how far each heartbeat pushes the
+	// visibility timeout when VISIBILITY_EXTENSION is unset
+	defaultVisibilityExtension = 60 * time.Second
+
+	// defaultMaxVisibilityExtension matches the longest Lambda timeout
+	defaultMaxVisibilityExtension = 15 * time.Minute
+)
+
+var (
+	// visibilityHeartbeat is how often a message being processed has its
+	// visibility timeout extended; zero disables the heartbeat
+	visibilityHeartbeat time.Duration
+
+	// visibilityExtension is the visibility timeout each heartbeat sets,
+	// counted from the moment of the call
+	visibilityExtension time.Duration
+
+	// maxVisibilityExtension bounds how long after processing starts a
+	// heartbeat can keep the message hidden
+	maxVisibilityExtension time.Duration
+
+	// queueURLs caches queue URLs by queue ARN, so each event source is
+	// only looked up once per container
+	queueURLs sync.Map
+)
+
+// startHeartbeat extends record's visibility timeout every
+// visibilityHeartbeat while a slow file is processed, so SQS doesn't hand
+// the message to a second worker mid-parse. The returned stop ends the
+// heartbeat and waits for it to exit; call it once processing finishes.
+func startHeartbeat(ctx context.Context, record events.SQSMessage) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runHeartbeat(ctx, record, time.Now().Add(maxVisibilityExtension))
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// runHeartbeat extends the visibility timeout on each tick until ctx is
+// canceled or deadline, after which the message may become visible again.
+// The last extension is shortened so it ends at deadline.
+func runHeartbeat(ctx context.Context, record events.SQSMessage, deadline time.Time) {
+	ticker := time.NewTicker(visibilityHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		timeout := min(visibilityExtension, time.Until(deadline)).Truncate(time.Second)
+		if timeout < time.Second {
+			fmt.Printf("Message %s: reached the %s visibility extension limit\n", record.MessageId, maxVisibilityExtension)
+			return
+		}
+
+		// A failed extension is retried on the next tick; the message
+		// stays hidden until its current timeout runs out regardless
+		if err := extendVisibility(ctx, record, timeout); err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("Warning: failed to extend visibility of message %s: %v\n", record.MessageId, err)
+			}
+			continue
+		}
+		if metricsCollector != nil {
+			metricsCollector.EmitBatch(ctx, map[string]metrics.MetricValue{
+				"WorkerVisibilityExtended": metrics.Count(1),
+			})
+		}
+	}
+}
+
+// extendVisibility hides record for timeout from now
+func extendVisibility(ctx context.Context, record events.SQSMessage, timeout time.Duration) error {
+	queueURL, err := queueURLFor(ctx, record.EventSourceARN)
+	if err != nil {
+		return err
+	}
+	_, err = sqsClient.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     aws.String(record.ReceiptHandle),
+		VisibilityTimeout: int32(timeout / time.Second),
+	})
+	return err
+}
+
+// queueURLFor resolves the URL of the queue a record came from, such as
+// either priority queue, from the record's event source ARN
+// (arn:aws:sqs:region:account:name)
+func queueURLFor(ctx context.Context, arn string) (string, error) {
+	if url, ok := queueURLs.Load(arn); ok {
+		return url.(string), nil
+	}
+
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[2] != "sqs" {
+		return "", fmt.Errorf("event source %q is not an SQS queue ARN", arn)
+	}
+	out, err := sqsClient.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName:              aws.String(parts[5]),
+		QueueOwnerAWSAccountId: aws.String(parts[4]),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up queue %s: %w", parts[5], err)
+	}
+
+	url := aws.ToString(out.QueueUrl)
+	queueURLs.Store(arn, url)
+	return url, nil
+}
@@ -0,0 +1,200 @@
+// cmd/worker/visibility_test.go
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// testQueueARN is the event source of heartbeat test messages
+const testQueueARN = "arn:aws:sqs:us-east-1:123456789012:jobs-high"
+
+// fakeSQS resolves every queue name to a URL, failing with lookupErr when
+// set, and records visibility changes
+type fakeSQS struct {
+	mu        sync.Mutex
+	lookupErr error
+	lookups   []*sqs.GetQueueUrlInput
+	changes   []*sqs.ChangeMessageVisibilityInput
+}
+
+func (f *fakeSQS) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.changes = append(f.changes, params)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups = append(f.lookups, params)
+	if f.lookupErr != nil {
+		return nil, f.lookupErr
+	}
+	url := "https://sqs.us-east-1.amazonaws.com/" + aws.ToString(params.QueueOwnerAWSAccountId) + "/" + aws.ToString(params.QueueName)
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(url)}, nil
+}
+
+// changeCount returns how many visibility changes were made
+func (f *fakeSQS) changeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.changes)
+}
+
+// withHeartbeat swaps in a fake SQS client and heartbeat settings, with an
+// empty queue URL cache, until the test ends
+func withHeartbeat(t *testing.T, interval, extension, limit time.Duration) *fakeSQS {
+	t.Helper()
+	fsqs := &fakeSQS{}
+	prevClient := sqsClient
+	prevInterval, prevExtension, prevLimit := visibilityHeartbeat, visibilityExtension, maxVisibilityExtension
+	sqsClient = fsqs
+	visibilityHeartbeat, visibilityExtension, maxVisibilityExtension = interval, extension, limit
+	queueURLs.Clear()
+	t.Cleanup(func() {
+		sqsClient = prevClient
+		visibilityHeartbeat, visibilityExtension, maxVisibilityExtension = prevInterval, prevExtension, prevLimit
+		queueURLs.Clear()
+	})
+	return fsqs
+}
+
+// heartbeatRecord is a message received from testQueueARN
+func heartbeatRecord() events.SQSMessage {
+	return events.SQSMessage{
+		MessageId:      "msg-1",
+		ReceiptHandle:  "receipt-1",
+		EventSourceARN: testQueueARN,
+	}
+}
+
+func TestHeartbeatExtendsUntilStopped(t *testing.T) {
+	_, _, fmetrics := stubWorker(t)
+	fsqs := withHeartbeat(t, 5*time.Millisecond, 30*time.Second, 15*time.Minute)
+
+	stop := startHeartbeat(context.Background(), heartbeatRecord())
+	deadline := time.Now().Add(5 * time.Second)
+	for fsqs.changeCount() < 3 {
+		if time.Now().After(deadline) {
+			stop()
+			t.Fatalf("made %d visibility changes in 5s, want at least 3", fsqs.changeCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+
+	// stop waits for the heartbeat to exit, so nothing follows it
+	stopped := fsqs.changeCount()
+	time.Sleep(50 * time.Millisecond)
+	if got := fsqs.changeCount(); got != stopped {
+		t.Errorf("made %d visibility changes after stop", got-stopped)
+	}
+
+	for _, change := range fsqs.changes {
+		if aws.ToString(change.QueueUrl) != "https://sqs.us-east-1.amazonaws.com/123456789012/jobs-high" ||
+			aws.ToString(change.ReceiptHandle) != "receipt-1" || change.VisibilityTimeout != 30 {
+			t.Errorf("changed %s %s to %ds, want the record's queue and receipt for 30s",
+				aws.ToString(change.QueueUrl), aws.ToString(change.ReceiptHandle), change.VisibilityTimeout)
+		}
+	}
+	// The queue URL is looked up once and cached
+	if len(fsqs.lookups) != 1 {
+		t.Errorf("looked up the queue URL %d times, want once", len(fsqs.lookups))
+	}
+	if got := fmetrics.value("WorkerVisibilityExtended"); got != float64(stopped) {
+		t.Errorf("WorkerVisibilityExtended = %v, want %d", got, stopped)
+	}
+}
+
+func TestHeartbeatStopsAtLimit(t *testing.T) {
+	stubWorker(t)
+	fsqs := withHeartbeat(t, 5*time.Millisecond, time.Minute, 0)
+
+	// The last extension is cut short to end at the deadline, and the
+	// heartbeat exits by itself once less than a second remains
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runHeartbeat(context.Background(), heartbeatRecord(), time.Now().Add(1200*time.Millisecond))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeat still running 5s after its deadline")
+	}
+
+	if len(fsqs.changes) == 0 {
+		t.Fatal("made no visibility changes before the deadline")
+	}
+	for _, change := range fsqs.changes {
+		if change.VisibilityTimeout != 1 {
+			t.Errorf("VisibilityTimeout = %d, want 1s, what was left before the deadline", change.VisibilityTimeout)
+		}
+	}
+}
+
+func TestQueueURLFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		arn       string
+		lookupErr error
+		want      string
+		wantName  string
+		wantOwner string
+	}{
+		{
+			name:      "queue ARN",
+			arn:       testQueueARN,
+			want:      "https://sqs.us-east-1.amazonaws.com/123456789012/jobs-high",
+			wantName:  "jobs-high",
+			wantOwner: "123456789012",
+		},
+		{name: "not SQS", arn: "arn:aws:sns:us-east-1:123456789012:jobs"},
+		{name: "too short", arn: "arn:aws:sqs:us-east-1:jobs"},
+		{name: "empty", arn: ""},
+		{name: "lookup fails", arn: testQueueARN, lookupErr: errors.New("AWS.SimpleQueueService.NonExistentQueue")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsqs := withHeartbeat(t, 0, 0, 0)
+			fsqs.lookupErr = tt.lookupErr
+
+			got, err := queueURLFor(context.Background(), tt.arn)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("queueURLFor(%q) = %q, want error", tt.arn, got)
+				}
+				if _, cached := queueURLs.Load(tt.arn); cached {
+					t.Error("a failed lookup was cached")
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("queueURLFor(%q) = %q, %v; want %q", tt.arn, got, err, tt.want)
+			}
+			lookup := fsqs.lookups[0]
+			if aws.ToString(lookup.QueueName) != tt.wantName || aws.ToString(lookup.QueueOwnerAWSAccountId) != tt.wantOwner {
+				t.Errorf("looked up %s owned by %s, want %s owned by %s",
+					aws.ToString(lookup.QueueName), aws.ToString(lookup.QueueOwnerAWSAccountId), tt.wantName, tt.wantOwner)
+			}
+
+			// A second call is served from the cache
+			if again, err := queueURLFor(context.Background(), tt.arn); err != nil || again != got || len(fsqs.lookups) != 1 {
+				t.Errorf("second call = %q, %v after %d lookups; want the cached URL", again, err, len(fsqs.lookups))
+			}
+		})
+	}
+}
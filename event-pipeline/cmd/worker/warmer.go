@@ -0,0 +1,59 @@
+// cmd/worker/warmer.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"event-pipeline/internal/metrics"
+)
+
+// warmerPing is the no-op payload sent to keep containers warm, either as
+// the whole invocation payload or as an SQS message body:
+//
+//	{"warmer": true}
+type warmerPing struct {
+	Warmer bool `json:"warmer"`
+}
+
+// isWarmerPing reports whether payload is a warmer ping rather than work
+func isWarmerPing(payload []byte) bool {
+	var ping warmerPing
+	return json.Unmarshal(payload, &ping) == nil && ping.Warmer
+}
+
+// initMetrics creates the metrics collector and, for Prometheus, starts
+// serving it
+func initMetrics(ctx context.Context) error {
+	collector, err := metrics.NewFromEnv(ctx, "EventPipeline")
+	if err != nil {
+		return err
+	}
+	metricsCollector = collector
+
+	// Long-lived containers are scraped rather than pushing to CloudWatch
+	if prom, ok := collector.(*metrics.PrometheusCollector); ok {
+		go serveMetrics(prom)
+	}
+	return nil
+}
+
+// ensureMetrics retries collector creation when it failed during init, so
+// a transient cold-start error doesn't silence the container for its whole
+// life. It must run before records are processed concurrently.
+func ensureMetrics(ctx context.Context) {
+	if metricsCollector != nil {
+		return
+	}
+	if err := initMetrics(ctx); err != nil {
+		fmt.Printf("Warning: failed to create metrics collector: %v\n", err)
+	}
+}
+
+// flushMetrics drains a buffered collector when Lambda shuts the container down
+func flushMetrics() {
+	if err := metrics.Shutdown(metricsCollector, metrics.DefaultShutdownTimeout); err != nil {
+		fmt.Printf("Warning: failed to flush metrics on shutdown: %v\n", err)
+	}
+}
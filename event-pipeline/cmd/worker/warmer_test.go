@@ -0,0 +1,61 @@
+// cmd/worker/warmer_test.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestIsWarmerPing(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    bool
+	}{
+		{payload: `{"warmer": true}`, want: true},
+		{payload: `{"warmer": false}`},
+		{payload: `{"Records": []}`},
+		{payload: `not json`},
+		{payload: ``},
+	}
+	for _, tt := range tests {
+		if got := isWarmerPing([]byte(tt.payload)); got != tt.want {
+			t.Errorf("isWarmerPing(%q) = %v, want %v", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestWarmerPingDoesNoWork(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload json.RawMessage
+	}{
+		{name: "whole payload", payload: json.RawMessage(`{"warmer": true}`)},
+		{name: "SQS message body", payload: sqsPayload(t, events.SQSMessage{
+			MessageId:  "ping-1",
+			Body:       `{"warmer": true}`,
+			Attributes: map[string]string{"ApproximateReceiveCount": "1"},
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs3, fsink, _ := stubWorker(t)
+
+			resp, err := handler(context.Background(), tt.payload)
+			if err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			if len(resp.BatchItemFailures) != 0 {
+				t.Errorf("failures = %v, want none", resp.BatchItemFailures)
+			}
+			if len(fs3.gets) != 0 {
+				t.Errorf("fetched %q from S3, want nothing", fs3.gets)
+			}
+			if len(fsink.saved) != 0 {
+				t.Errorf("saved %d results, want none", len(fsink.saved))
+			}
+		})
+	}
+}
@@ -4,20 +4,76 @@ resource "aws_dynamodb_table" "results" {
   name         = "${var.project_name}-results-${var.environment}"
   billing_mode = "PAY_PER_REQUEST"
   hash_key     = "job_id"
+  range_key    = var.dynamodb_sort_key != "" ? var.dynamodb_sort_key : null
 
   attribute {
     name = "job_id"
     type = "S"
   }
 
+  attribute {
+    name = "status"
+    type = "S"
+  }
+
+  attribute {
+    name = "completed_at"
+    type = "S"
+  }
+
+  # Used by store.ResultStore.ListByStatus
+  global_secondary_index {
+    name            = "status-index"
+    hash_key        = "status"
+    range_key       = "completed_at"
+    projection_type = "ALL"
+  }
+
   ttl {
     attribute_name = "expires_at"
     enabled        = true
   }
 
+  # Feeds the rollup Lambda; MODIFY records need the old image too
+  stream_enabled   = var.hourly_rollups
+  stream_view_type = var.hourly_rollups ? "NEW_AND_OLD_IMAGES" : null
+
   point_in_time_recovery {
     enabled = var.environment == "aws"
   }
 
   tags = var.tags
-}
\ No newline at end of file
+}
+# Per-endpoint latency EMA, written by the worker when latency_trends is on
+resource "aws_dynamodb_table" "trends" {
+  name         = "${var.project_name}-trends-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "endpoint"
+
+  attribute {
+    name = "endpoint"
+    type = "S"
+  }
+
+  tags = var.tags
+}
+
+# Hourly totals across results, written by the rollup Lambda when
+# hourly_rollups is on. Stream dedup markers expire through expires_at.
+resource "aws_dynamodb_table" "rollups" {
+  name         = "${var.project_name}-rollups-${var.environment}"
+  billing_mode = "PAY_PER_REQUEST"
+  hash_key     = "rollup_key"
+
+  attribute {
+    name = "rollup_key"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = var.tags
+}
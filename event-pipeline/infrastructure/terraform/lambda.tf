@@ -43,17 +43,26 @@ resource "aws_iam_role_policy" "lambda_policy" {
         Effect = "Allow"
         Action = [
           "s3:GetObject",
-          "s3:HeadObject"
+          "s3:HeadObject",
+          "s3:PutObject",
+          "s3:DeleteObject"
         ]
         Resource = "${aws_s3_bucket.upload_bucket.arn}/*"
       },
+      {
+        Effect   = "Allow"
+        Action   = ["s3:ListBucket"]
+        Resource = aws_s3_bucket.upload_bucket.arn
+      },
       {
         Effect = "Allow"
         Action = [
           "sqs:SendMessage",
           "sqs:ReceiveMessage",
           "sqs:DeleteMessage",
-          "sqs:GetQueueAttributes"
+          "sqs:ChangeMessageVisibility",
+          "sqs:GetQueueAttributes",
+          "sqs:GetQueueUrl"
         ]
         Resource = [
           aws_sqs_queue.processing_queue.arn,
@@ -64,11 +73,28 @@ resource "aws_iam_role_policy" "lambda_policy" {
         Effect = "Allow"
         Action = [
           "dynamodb:PutItem",
+          "dynamodb:BatchWriteItem",
           "dynamodb:GetItem",
           "dynamodb:UpdateItem",
-          "dynamodb:Query"
+          "dynamodb:Query",
+          "dynamodb:DescribeTable"
+        ]
+        Resource = [
+          aws_dynamodb_table.results.arn,
+          "${aws_dynamodb_table.results.arn}/index/*",
+          aws_dynamodb_table.trends.arn,
+          aws_dynamodb_table.rollups.arn
+        ]
+      },
+      {
+        Effect = "Allow"
+        Action = [
+          "dynamodb:DescribeStream",
+          "dynamodb:GetRecords",
+          "dynamodb:GetShardIterator",
+          "dynamodb:ListStreams"
         ]
-        Resource = aws_dynamodb_table.results.arn
+        Resource = "${aws_dynamodb_table.results.arn}/stream/*"
       },
       {
         Effect = "Allow"
@@ -101,6 +127,8 @@ resource "aws_lambda_function" "trigger" {
   environment {
     variables = {
       QUEUE_URL       = aws_sqs_queue.processing_queue.url
+      FIFO_QUEUE      = var.sqs_fifo
+      ACCEPTED_EXTENSIONS = join(",", var.accepted_extensions)
       ENVIRONMENT     = var.environment
       AWS_ENDPOINT_URL = var.environment == "local" ? var.lambda_endpoint : ""
     }
@@ -125,6 +153,15 @@ resource "aws_lambda_function" "worker" {
   environment {
     variables = {
       DYNAMODB_TABLE   = aws_dynamodb_table.results.name
+      DYNAMODB_SORT_KEY = var.dynamodb_sort_key
+      MAX_RETRIES      = var.sqs_max_receive_count - 1
+      DELETE_ON_SUCCESS = var.delete_on_success
+      TREND_TABLE      = var.latency_trends ? aws_dynamodb_table.trends.name : ""
+      RESULT_SINK      = var.result_sink
+      RESULT_SINK_BUCKET = var.result_sink == "s3" ? aws_s3_bucket.upload_bucket.bucket : ""
+      RESULT_SINK_URL  = var.result_sink_url
+      UPLOAD_BUCKET    = aws_s3_bucket.upload_bucket.bucket
+      QUEUE_URL        = aws_sqs_queue.processing_queue.url
       ENVIRONMENT      = var.environment
       AWS_ENDPOINT_URL = var.environment == "local" ? var.lambda_endpoint : ""
     }
@@ -133,6 +170,42 @@ resource "aws_lambda_function" "worker" {
   tags = var.tags
 }
 
+# Rollup Lambda Function, fed by the results table's stream
+resource "aws_lambda_function" "rollup" {
+  count            = var.hourly_rollups ? 1 : 0
+  filename         = "${path.module}/../../build/rollup.zip"
+  function_name    = "${var.project_name}-rollup-${var.environment}"
+  role             = local.lambda_role_arn
+  handler          = "bootstrap"
+  source_code_hash = filebase64sha256("${path.module}/../../build/rollup.zip")
+  runtime          = "provided.al2023"
+  architectures    = ["arm64"]
+
+  memory_size = var.lambda_memory_size
+  timeout     = var.lambda_timeout
+
+  environment {
+    variables = {
+      ROLLUP_TABLE     = aws_dynamodb_table.rollups.name
+      ENVIRONMENT      = var.environment
+      AWS_ENDPOINT_URL = var.environment == "local" ? var.lambda_endpoint : ""
+    }
+  }
+
+  tags = var.tags
+}
+
+resource "aws_lambda_event_source_mapping" "rollup_stream" {
+  count                          = var.hourly_rollups ? 1 : 0
+  event_source_arn               = aws_dynamodb_table.results.stream_arn
+  function_name                  = aws_lambda_function.rollup[0].arn
+  starting_position              = "LATEST"
+  batch_size                     = 100
+  maximum_retry_attempts         = 10
+  bisect_batch_on_function_error = true
+  function_response_types        = ["ReportBatchItemFailures"]
+}
+
 # S3 trigger permission
 resource "aws_lambda_permission" "s3_trigger" {
   statement_id  = "AllowS3Invoke"
@@ -146,11 +219,15 @@ resource "aws_lambda_permission" "s3_trigger" {
 resource "aws_s3_bucket_notification" "trigger_notification" {
   bucket = aws_s3_bucket.upload_bucket.id
 
-  lambda_function {
-    lambda_function_arn = aws_lambda_function.trigger.arn
-    events              = ["s3:ObjectCreated:*"]
-    filter_prefix       = "logs/"
-    filter_suffix       = ".json"
+  # S3 filters are case-sensitive and take one suffix each
+  dynamic "lambda_function" {
+    for_each = var.accepted_extensions
+    content {
+      lambda_function_arn = aws_lambda_function.trigger.arn
+      events              = ["s3:ObjectCreated:*"]
+      filter_prefix       = "logs/"
+      filter_suffix       = lambda_function.value
+    }
   }
 
   depends_on = [aws_lambda_permission.s3_trigger]
@@ -162,6 +239,9 @@ resource "aws_lambda_event_source_mapping" "sqs_trigger" {
   function_name    = aws_lambda_function.worker.arn
   batch_size       = 10
   enabled          = true
+
+  # Worker reports failed message IDs so successful ones aren't redelivered
+  function_response_types = ["ReportBatchItemFailures"]
 }
 
 # CloudWatch Log Groups
@@ -38,6 +38,19 @@ resource "aws_s3_bucket_lifecycle_configuration" "upload_bucket_lifecycle" {
       noncurrent_days = 7
     }
   }
+
+  rule {
+    id     = "cleanup-staged-jobs"
+    status = "Enabled"
+
+    filter {
+      prefix = "staging/"
+    }
+
+    expiration {
+      days = 7
+    }
+  }
 }
 
 resource "aws_s3_bucket_public_access_block" "upload_bucket_public_access" {
@@ -2,15 +2,17 @@
 
 # Dead Letter Queue
 resource "aws_sqs_queue" "dlq" {
-  name                      = "${var.project_name}-dlq-${var.environment}"
+  name                      = "${var.project_name}-dlq-${var.environment}${var.sqs_fifo ? ".fifo" : ""}"
   message_retention_seconds = 1209600  # 14 days
+  fifo_queue                = var.sqs_fifo  # a FIFO queue's DLQ must also be FIFO
 
   tags = var.tags
 }
 
 # Main Processing Queue
 resource "aws_sqs_queue" "processing_queue" {
-  name                       = "${var.project_name}-queue-${var.environment}"
+  name                       = "${var.project_name}-queue-${var.environment}${var.sqs_fifo ? ".fifo" : ""}"
+  fifo_queue                 = var.sqs_fifo
   visibility_timeout_seconds = var.sqs_visibility_timeout
   message_retention_seconds  = 86400  # 1 day
   receive_wait_time_seconds  = 10     # Long polling
@@ -47,6 +47,12 @@ variable "sqs_visibility_timeout" {
   default     = 60
 }
 
+variable "sqs_fifo" {
+  description = "Use FIFO queues, ordered per job ID and deduplicated per object version"
+  type        = bool
+  default     = false
+}
+
 variable "sqs_max_receive_count" {
   description = "Max receives before sending to DLQ"
   type        = number
@@ -59,6 +65,58 @@ variable "dynamodb_ttl_days" {
   default     = 7
 }
 
+variable "dynamodb_sort_key" {
+  description = "Results table sort key; completed_at keeps every run of a job instead of only the latest"
+  type        = string
+  default     = ""
+
+  validation {
+    condition     = contains(["", "completed_at"], var.dynamodb_sort_key)
+    error_message = "dynamodb_sort_key must be empty or 'completed_at'."
+  }
+}
+
+variable "delete_on_success" {
+  description = "Delete uploaded log files once the worker has saved their completed result"
+  type        = bool
+  default     = false
+}
+
+variable "accepted_extensions" {
+  description = "Key suffixes that trigger processing; each gets its own S3 notification"
+  type        = list(string)
+  default     = [".json", ".jsonl"]
+}
+
+variable "result_sink" {
+  description = "Where the worker saves results: dynamodb, s3 (results/ in the upload bucket) or http"
+  type        = string
+  default     = "dynamodb"
+
+  validation {
+    condition     = contains(["dynamodb", "s3", "http"], var.result_sink)
+    error_message = "result_sink must be dynamodb, s3 or http."
+  }
+}
+
+variable "result_sink_url" {
+  description = "Endpoint the http result sink POSTs results to"
+  type        = string
+  default     = ""
+}
+
+variable "hourly_rollups" {
+  description = "Stream results into hourly totals in the rollups table through the rollup Lambda"
+  type        = bool
+  default     = false
+}
+
+variable "latency_trends" {
+  description = "Keep a per-endpoint moving average of response times across files in the trends table"
+  type        = bool
+  default     = false
+}
+
 variable "project_name" {
   description = "Project name for resource naming"
   type        = string
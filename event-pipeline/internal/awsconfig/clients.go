@@ -0,0 +1,37 @@
+// internal/awsconfig/clients.go
+package awsconfig
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// Client constructors for a config from Load. Create every client through
+// these so service-specific LocalStack options are applied the same way
+// everywhere.
+
+// NewS3 creates an S3 client, with path-style addressing when an endpoint
+// override is set since LocalStack can't serve virtual-hosted bucket names
+func NewS3(cfg aws.Config) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = Endpoint() != ""
+	})
+}
+
+// NewSQS creates an SQS client
+func NewSQS(cfg aws.Config) *sqs.Client {
+	return sqs.NewFromConfig(cfg)
+}
+
+// NewDynamoDB creates a DynamoDB client
+func NewDynamoDB(cfg aws.Config) *dynamodb.Client {
+	return dynamodb.NewFromConfig(cfg)
+}
+
+// NewCloudWatch creates a CloudWatch client
+func NewCloudWatch(cfg aws.Config) *cloudwatch.Client {
+	return cloudwatch.NewFromConfig(cfg)
+}
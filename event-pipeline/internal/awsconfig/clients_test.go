@@ -0,0 +1,54 @@
+// internal/awsconfig/clients_test.go
+package awsconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestNewS3PathStyleWithEndpoint(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Length", "0")
+	}))
+	t.Cleanup(server.Close)
+	isolateAWSEnv(t, server.URL)
+
+	cfg, err := Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	client := NewS3(cfg)
+	if !client.Options().UsePathStyle {
+		t.Error("UsePathStyle = false with AWS_ENDPOINT_URL set")
+	}
+
+	// The bucket goes in the path, not the host name
+	if _, err := client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String("logs-bucket"),
+		Key:    aws.String("logs/app.json"),
+	}); err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+	if gotPath != "/logs-bucket/logs/app.json" {
+		t.Errorf("request path = %q, want /logs-bucket/logs/app.json", gotPath)
+	}
+}
+
+func TestNewS3VirtualHostedWithoutEndpoint(t *testing.T) {
+	isolateAWSEnv(t, "")
+
+	cfg, err := Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if NewS3(cfg).Options().UsePathStyle {
+		t.Error("UsePathStyle = true without AWS_ENDPOINT_URL")
+	}
+}
@@ -0,0 +1,73 @@
+// internal/awsconfig/config.go
+package awsconfig
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+
+	"event-pipeline/internal/envconfig"
+)
+
+const (
+	// DefaultConnectTimeout bounds dialing and the TLS handshake
+	DefaultConnectTimeout = 5 * time.Second
+
+	// DefaultResponseTimeout bounds the wait for response headers once a
+	// request is sent; reading the body is not limited
+	DefaultResponseTimeout = 10 * time.Second
+)
+
+// Load returns the default AWS config tuned through environment variables:
+//
+//   - AWS_ENDPOINT_URL points every client at LocalStack
+//   - AWS_RETRY_MAX_ATTEMPTS caps SDK attempts per call, including the
+//     first (the SDK default of 3 when unset)
+//   - AWS_CONNECT_TIMEOUT and AWS_RESPONSE_TIMEOUT set the HTTP client's
+//     timeouts (DefaultConnectTimeout, DefaultResponseTimeout); 0 disables one
+func Load(ctx context.Context) (aws.Config, error) {
+	connectTimeout := envconfig.Duration("AWS_CONNECT_TIMEOUT", DefaultConnectTimeout)
+	responseTimeout := envconfig.Duration("AWS_RESPONSE_TIMEOUT", DefaultResponseTimeout)
+
+	opts := []func(*config.LoadOptions) error{
+		config.WithHTTPClient(newHTTPClient(connectTimeout, responseTimeout)),
+	}
+	if attempts := envconfig.Int("AWS_RETRY_MAX_ATTEMPTS", 0); attempts > 0 {
+		opts = append(opts, config.WithRetryMaxAttempts(attempts))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	// LocalStack support
+	if endpoint := Endpoint(); endpoint != "" {
+		cfg.BaseEndpoint = aws.String(endpoint)
+	}
+	return cfg, nil
+}
+
+// Endpoint returns the AWS_ENDPOINT_URL override, or "" for real AWS
+func Endpoint() string {
+	return os.Getenv("AWS_ENDPOINT_URL")
+}
+
+// newHTTPClient builds the SDK's HTTP client with the given timeouts
+func newHTTPClient(connectTimeout, responseTimeout time.Duration) *awshttp.BuildableClient {
+	return awshttp.NewBuildableClient().
+		WithDialerOptions(func(d *net.Dialer) {
+			d.Timeout = connectTimeout
+		}).
+		WithTransportOptions(func(t *http.Transport) {
+			t.TLSHandshakeTimeout = connectTimeout
+			t.ResponseHeaderTimeout = responseTimeout
+		})
+}
@@ -0,0 +1,74 @@
+// internal/awsconfig/config_test.go
+package awsconfig
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// isolateAWSEnv points the SDK at endpoint with static credentials, so
+// no shared config or instance metadata is consulted
+func isolateAWSEnv(t *testing.T, endpoint string) {
+	t.Helper()
+	t.Setenv("AWS_ENDPOINT_URL", endpoint)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_RETRY_MAX_ATTEMPTS", "")
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxAttempts  string
+		wantRequests int32
+	}{
+		{name: "SDK default", wantRequests: int32(retry.DefaultMaxAttempts)},
+		{name: "configured", maxAttempts: "5", wantRequests: 5},
+		{name: "single attempt", maxAttempts: "1", wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every call fails with a transient server error
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"try again"}`))
+			}))
+			t.Cleanup(server.Close)
+
+			isolateAWSEnv(t, server.URL)
+			t.Setenv("AWS_RETRY_MAX_ATTEMPTS", tt.maxAttempts)
+
+			cfg, err := Load(context.Background())
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			// Keep the backoff between attempts short
+			client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+				o.Retryer = retry.AddWithMaxBackoffDelay(o.Retryer, time.Millisecond)
+			})
+
+			_, err = client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("results")})
+			if err == nil {
+				t.Fatal("DescribeTable succeeded, want the server error")
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("%d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// internal/envconfig/env.go
+package envconfig
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Bool reads a boolean environment variable, returning def when unset or invalid
+func Bool(name string, def bool) bool {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, using default %t\n", name, raw, def)
+		return def
+	}
+	return v
+}
+
+// Int reads an integer environment variable, returning def when unset or invalid
+func Int(name string, def int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, using default %d\n", name, raw, def)
+		return def
+	}
+	return v
+}
+
+// Float reads a float environment variable, returning def when unset or invalid
+func Float(name string, def float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, using default %g\n", name, raw, def)
+		return def
+	}
+	return v
+}
+
+// Duration reads a time.ParseDuration value such as "5s", returning def when unset or invalid
+func Duration(name string, def time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s=%q, using default %s\n", name, raw, def)
+		return def
+	}
+	return v
+}
@@ -0,0 +1,185 @@
+// internal/export/csv.go
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"event-pipeline/internal/models"
+)
+
+// column is one field of a result row
+type column struct {
+	name  string
+	value func(r *models.ProcessingResult) string
+}
+
+// resultColumns is the row layout written by WriteResults. Columns are only
+// ever appended, so consumers can rely on names and positions.
+var resultColumns = []column{
+	{"job_id", func(r *models.ProcessingResult) string { return r.JobID }},
+	{"schema_version", func(r *models.ProcessingResult) string { return formatInt(r.SchemaVersion) }},
+	{"status", func(r *models.ProcessingResult) string { return string(r.Status) }},
+	{"line_count", func(r *models.ProcessingResult) string { return formatInt(r.LineCount) }},
+	{"error_count", func(r *models.ProcessingResult) string { return formatInt(r.ErrorCount) }},
+	{"warn_count", func(r *models.ProcessingResult) string { return formatInt(r.WarnCount) }},
+	{"info_count", func(r *models.ProcessingResult) string { return formatInt(r.InfoCount) }},
+	{"debug_count", func(r *models.ProcessingResult) string { return formatInt(r.DebugCount) }},
+	{"sampled_out_debug_count", func(r *models.ProcessingResult) string { return formatInt(r.SampledOutDebugCount) }},
+	{"unknown_level_count", func(r *models.ProcessingResult) string { return formatInt(r.UnknownLevelCount) }},
+	{"malformed_line_count", func(r *models.ProcessingResult) string { return formatInt(r.MalformedLineCount) }},
+	{"invalid_entry_count", func(r *models.ProcessingResult) string { return formatInt(r.InvalidEntryCount) }},
+	{"duplicate_line_count", func(r *models.ProcessingResult) string { return formatInt(r.DuplicateLineCount) }},
+	{"oversized_line_count", func(r *models.ProcessingResult) string { return formatInt(r.OversizedLineCount) }},
+	{"avg_response_time_ms", func(r *models.ProcessingResult) string { return formatFloat(r.AvgResponseTimeMs) }},
+	{"min_response_time_ms", func(r *models.ProcessingResult) string { return formatInt(r.MinResponseTimeMs) }},
+	{"max_response_time_ms", func(r *models.ProcessingResult) string { return formatInt(r.MaxResponseTimeMs) }},
+	{"p50_response_time_ms", func(r *models.ProcessingResult) string { return formatInt(r.P50ResponseTimeMs) }},
+	{"p90_response_time_ms", func(r *models.ProcessingResult) string { return formatInt(r.P90ResponseTimeMs) }},
+	{"p95_response_time_ms", func(r *models.ProcessingResult) string { return formatInt(r.P95ResponseTimeMs) }},
+	{"p99_response_time_ms", func(r *models.ProcessingResult) string { return formatInt(r.P99ResponseTimeMs) }},
+	{"stddev_response_time_ms", func(r *models.ProcessingResult) string { return formatFloat(r.StdDevResponseTimeMs) }},
+	{"anomalous_request_count", func(r *models.ProcessingResult) string { return formatInt(r.AnomalousRequestCount) }},
+	{"total_bytes_sent", func(r *models.ProcessingResult) string { return strconv.FormatInt(r.TotalBytesSent, 10) }},
+	{"avg_bytes_sent", func(r *models.ProcessingResult) string { return formatFloat(r.AvgBytesSent) }},
+	{"http_error_rate", func(r *models.ProcessingResult) string { return formatFloat(r.HTTPErrorRate) }},
+	{"http_4xx_rate", func(r *models.ProcessingResult) string { return formatFloat(r.HTTP4xxRate) }},
+	{"http_5xx_rate", func(r *models.ProcessingResult) string { return formatFloat(r.HTTP5xxRate) }},
+	{"status_1xx", func(r *models.ProcessingResult) string { return formatInt(r.Status1xx) }},
+	{"status_2xx", func(r *models.ProcessingResult) string { return formatInt(r.Status2xx) }},
+	{"status_3xx", func(r *models.ProcessingResult) string { return formatInt(r.Status3xx) }},
+	{"status_4xx", func(r *models.ProcessingResult) string { return formatInt(r.Status4xx) }},
+	{"status_5xx", func(r *models.ProcessingResult) string { return formatInt(r.Status5xx) }},
+	{"status_other", func(r *models.ProcessingResult) string { return formatInt(r.StatusOther) }},
+	{"unique_users", func(r *models.ProcessingResult) string { return formatInt(r.UniqueUsers) }},
+	{"unique_endpoints", func(r *models.ProcessingResult) string { return formatInt(r.UniqueEndpoints) }},
+	{"earliest_timestamp", func(r *models.ProcessingResult) string { return formatTimePtr(r.EarliestTimestamp) }},
+	{"latest_timestamp", func(r *models.ProcessingResult) string { return formatTimePtr(r.LatestTimestamp) }},
+	{"malformed_timestamps", func(r *models.ProcessingResult) string { return formatInt(r.MalformedTimestamps) }},
+	{"processing_time_ms", func(r *models.ProcessingResult) string { return strconv.FormatInt(r.ProcessingTimeMs, 10) }},
+	{"lines_per_second", func(r *models.ProcessingResult) string { return formatFloat(r.LinesPerSecond) }},
+	{"bytes_per_second", func(r *models.ProcessingResult) string { return formatFloat(r.BytesPerSecond) }},
+	{"file_size_bytes", func(r *models.ProcessingResult) string { return strconv.FormatInt(r.FileSizeBytes, 10) }},
+	{"source_etag", func(r *models.ProcessingResult) string { return r.SourceETag }},
+	{"level_filter", func(r *models.ProcessingResult) string { return r.LevelFilter }},
+	{"started_at", func(r *models.ProcessingResult) string { return formatTime(r.StartedAt) }},
+	{"completed_at", func(r *models.ProcessingResult) string { return formatTime(r.CompletedAt) }},
+	{"error_category", func(r *models.ProcessingResult) string { return r.ErrorCategory }},
+	{"error_message", func(r *models.ProcessingResult) string { return r.ErrorMessage }},
+	{"retry_count", func(r *models.ProcessingResult) string { return formatInt(r.RetryCount) }},
+	{"terminal", func(r *models.ProcessingResult) string { return strconv.FormatBool(r.Terminal) }},
+	{"unknown_schema_count", func(r *models.ProcessingResult) string { return formatInt(r.UnknownSchemaCount) }},
+	{"bad_response_time_count", func(r *models.ProcessingResult) string { return formatInt(r.BadResponseTimeCount) }},
+	{"max_line_bytes", func(r *models.ProcessingResult) string { return formatInt(r.MaxLineBytes) }},
+	{"content_hash", func(r *models.ProcessingResult) string { return r.ContentHash }},
+	{"peak_errors_per_window", func(r *models.ProcessingResult) string { return formatInt(r.PeakErrorsPerWindow) }},
+	{"total_response_time_ms", func(r *models.ProcessingResult) string { return strconv.FormatInt(r.TotalResponseTimeMs, 10) }},
+	{"aggregated_line_count", func(r *models.ProcessingResult) string { return formatInt(r.AggregatedLineCount) }},
+}
+
+// bucketColumns is the row layout written by WriteBuckets
+var bucketColumns = []string{"job_id", "bucket", "count"}
+
+// ResultHeader returns the header row written by WriteResults
+func ResultHeader() []string {
+	header := make([]string, len(resultColumns))
+	for i, col := range resultColumns {
+		header[i] = col.name
+	}
+	return header
+}
+
+// WriteResults writes a header row and one row per result. Lists and maps
+// (top endpoints, top users, slowest requests, the source job) are left
+// out; response-time buckets are written in long format by WriteBuckets.
+func WriteResults(w io.Writer, results []models.ProcessingResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ResultHeader()); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	row := make([]string, len(resultColumns))
+	for i := range results {
+		for j, col := range resultColumns {
+			row[j] = col.value(&results[i])
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for job %s: %w", results[i].JobID, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteBuckets writes each result's response-time histogram in long format,
+// one job_id,bucket,count row per bucket in ascending order, so results
+// with different bucket boundaries share one layout
+func WriteBuckets(w io.Writer, results []models.ProcessingResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(bucketColumns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, result := range results {
+		labels := make([]string, 0, len(result.ResponseTimeBuckets))
+		for label := range result.ResponseTimeBuckets {
+			labels = append(labels, label)
+		}
+		slices.SortFunc(labels, func(a, b string) int {
+			if c := bucketLower(a) - bucketLower(b); c != 0 {
+				return c
+			}
+			return strings.Compare(a, b)
+		})
+
+		for _, label := range labels {
+			row := []string{result.JobID, label, formatInt(result.ResponseTimeBuckets[label])}
+			if err := cw.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV row for job %s: %w", result.JobID, err)
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// bucketLower returns the lower bound of a bucket label such as "50-100ms"
+// or "500ms+", or 0 when it has none
+func bucketLower(label string) int {
+	end := strings.IndexFunc(label, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(label)
+	}
+	n, _ := strconv.Atoi(label[:end])
+	return n
+}
+
+func formatInt(v int) string {
+	return strconv.Itoa(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatTime renders t as RFC3339 in UTC, or "" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return formatTime(*t)
+}
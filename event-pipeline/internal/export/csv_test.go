@@ -0,0 +1,111 @@
+// internal/export/csv_test.go
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"event-pipeline/internal/models"
+)
+
+// wantHeader is the published column layout. New columns go after it;
+// renaming or reordering one breaks downstream consumers.
+var wantHeader = []string{
+	"job_id", "schema_version", "status", "line_count", "error_count", "warn_count", "info_count",
+	"debug_count", "sampled_out_debug_count", "unknown_level_count", "malformed_line_count",
+	"invalid_entry_count", "duplicate_line_count", "oversized_line_count", "avg_response_time_ms",
+	"min_response_time_ms", "max_response_time_ms", "p50_response_time_ms", "p90_response_time_ms",
+	"p95_response_time_ms", "p99_response_time_ms", "stddev_response_time_ms",
+	"anomalous_request_count", "total_bytes_sent", "avg_bytes_sent", "http_error_rate",
+	"http_4xx_rate", "http_5xx_rate", "status_1xx", "status_2xx", "status_3xx", "status_4xx",
+	"status_5xx", "status_other", "unique_users", "unique_endpoints", "earliest_timestamp",
+	"latest_timestamp", "malformed_timestamps", "processing_time_ms", "lines_per_second",
+	"bytes_per_second", "file_size_bytes", "source_etag", "level_filter", "started_at",
+	"completed_at", "error_category", "error_message", "retry_count", "terminal",
+	"unknown_schema_count", "bad_response_time_count", "max_line_bytes", "content_hash",
+	"peak_errors_per_window", "total_response_time_ms", "aggregated_line_count",
+}
+
+func TestResultHeaderIsStable(t *testing.T) {
+	header := ResultHeader()
+	if len(header) < len(wantHeader) || !slices.Equal(header[:len(wantHeader)], wantHeader) {
+		t.Fatalf("published columns changed:\n got %q\nwant %q first", header, wantHeader)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, nil); err != nil {
+		t.Fatalf("WriteResults: %v", err)
+	}
+	if want := strings.Join(header, ",") + "\n"; buf.String() != want {
+		t.Errorf("empty export = %q, want only the header", buf.String())
+	}
+}
+
+func TestWriteResultsEscaping(t *testing.T) {
+	completed := time.Date(2024, 1, 15, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	results := []models.ProcessingResult{
+		{
+			JobID:        "job,1",
+			Status:       models.StatusFailed,
+			ErrorMessage: `failed to parse "app.json": line 3,` + "\nunexpected EOF",
+			CompletedAt:  completed,
+		},
+		{JobID: "job-2", Status: models.StatusCompleted, LineCount: 3, AvgResponseTimeMs: 12.5},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteResults(&buf, results); err != nil {
+		t.Fatalf("WriteResults: %v", err)
+	}
+	out := buf.String()
+	for _, quoted := range []string{`"job,1"`, `"failed to parse ""app.json"": line 3,` + "\nunexpected EOF\""} {
+		if !strings.Contains(out, quoted) {
+			t.Errorf("output lacks the quoted field %s:\n%s", quoted, out)
+		}
+	}
+
+	// Quoting keeps every row intact for a CSV reader
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("reading export back: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("read %d rows, want a header and 2 results", len(rows))
+	}
+	field := func(row []string, name string) string { return row[slices.Index(wantHeader, name)] }
+	if got := field(rows[1], "job_id"); got != results[0].JobID {
+		t.Errorf("job_id = %q, want %q", got, results[0].JobID)
+	}
+	if got := field(rows[1], "error_message"); got != results[0].ErrorMessage {
+		t.Errorf("error_message = %q, want %q", got, results[0].ErrorMessage)
+	}
+	if got := field(rows[1], "completed_at"); got != "2024-01-15T09:00:00Z" {
+		t.Errorf("completed_at = %q, want UTC", got)
+	}
+	if got := field(rows[1], "started_at"); got != "" {
+		t.Errorf("zero started_at = %q, want empty", got)
+	}
+	if got := field(rows[2], "avg_response_time_ms"); got != "12.5" {
+		t.Errorf("avg_response_time_ms = %q, want 12.5", got)
+	}
+}
+
+func TestWriteBucketsOrder(t *testing.T) {
+	results := []models.ProcessingResult{{
+		JobID:               "job-1",
+		ResponseTimeBuckets: map[string]int{"500ms+": 1, "0-50ms": 4, "100-500ms": 2, "50-100ms": 3},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteBuckets(&buf, results); err != nil {
+		t.Fatalf("WriteBuckets: %v", err)
+	}
+	want := "job_id,bucket,count\njob-1,0-50ms,4\njob-1,50-100ms,3\njob-1,100-500ms,2\njob-1,500ms+,1\n"
+	if buf.String() != want {
+		t.Errorf("WriteBuckets =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,73 @@
+// internal/metrics/backend.go
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"event-pipeline/internal/envconfig"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// Collector is implemented by every metrics backend
+type Collector interface {
+	EmitLatency(ctx context.Context, name string, valueMs float64) error
+	EmitCount(ctx context.Context, name string, value float64) error
+	EmitBytes(ctx context.Context, name string, value float64) error
+	EmitStatisticSet(ctx context.Context, name string, set StatisticValues, unit types.StandardUnit) error
+	EmitBatch(ctx context.Context, metrics map[string]MetricValue) error
+	EmitBatchWithDimensions(ctx context.Context, metrics map[string]MetricValue, extraDims map[string]string) error
+	EmitData(ctx context.Context, datums []Datum) error
+}
+
+// Backends accepted by METRICS_BACKEND
+const (
+	BackendCloudWatch = "cloudwatch"
+	BackendPrometheus = "prometheus"
+)
+
+// NewFromEnv creates the collector selected by METRICS_BACKEND, CloudWatch
+// by default. CloudWatch collectors are configured with EnvOptions and
+// publish to ResolveNamespace(namespace). A positive METRICS_FLUSH_INTERVAL
+// makes the CloudWatch collector a BufferedCollector; see Shutdown.
+func NewFromEnv(ctx context.Context, namespace string) (Collector, error) {
+	switch backend := os.Getenv("METRICS_BACKEND"); backend {
+	case "", BackendCloudWatch:
+		if interval := envconfig.Duration("METRICS_FLUSH_INTERVAL", 0); interval > 0 {
+			// The flusher outlives the invocation that may have created it
+			c, err := NewBufferedCollector(context.WithoutCancel(ctx), ResolveNamespace(namespace), interval, EnvOptions()...)
+			if err != nil {
+				return nil, err
+			}
+			return c, nil
+		}
+		c, err := NewCloudWatchCollector(ctx, ResolveNamespace(namespace), EnvOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case BackendPrometheus:
+		return NewPrometheusCollector(namespace), nil
+	default:
+		return nil, fmt.Errorf("unknown METRICS_BACKEND %q", backend)
+	}
+}
+
+// ResolveNamespace returns base with the environment appended
+// (EventPipeline/production), so environments don't share CloudWatch
+// graphs. METRICS_NAMESPACE, when set, replaces the environment and base's
+// first element but keeps the rest, so EventPipeline/DryRun becomes
+// <METRICS_NAMESPACE>/DryRun. Prometheus keeps the environment as a label
+// instead.
+func ResolveNamespace(base string) string {
+	if ns := os.Getenv("METRICS_NAMESPACE"); ns != "" {
+		if _, qualifier, ok := strings.Cut(base, "/"); ok {
+			return ns + "/" + qualifier
+		}
+		return ns
+	}
+	return base + "/" + getEnvironment()
+}
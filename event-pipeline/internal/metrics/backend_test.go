@@ -0,0 +1,29 @@
+// internal/metrics/backend_test.go
+package metrics
+
+import "testing"
+
+func TestResolveNamespace(t *testing.T) {
+	tests := []struct {
+		name        string
+		base        string
+		environment string
+		override    string
+		want        string
+	}{
+		{name: "environment", base: "EventPipeline", environment: "production", want: "EventPipeline/production"},
+		{name: "default environment", base: "EventPipeline", want: "EventPipeline/development"},
+		{name: "dry run", base: "EventPipeline/DryRun", environment: "local", want: "EventPipeline/DryRun/local"},
+		{name: "override", base: "EventPipeline", environment: "production", override: "Custom", want: "Custom"},
+		{name: "override keeps dry run", base: "EventPipeline/DryRun", environment: "production", override: "Custom", want: "Custom/DryRun"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT", tt.environment)
+			t.Setenv("METRICS_NAMESPACE", tt.override)
+			if got := ResolveNamespace(tt.base); got != tt.want {
+				t.Errorf("ResolveNamespace(%q) = %q, want %q", tt.base, got, tt.want)
+			}
+		})
+	}
+}
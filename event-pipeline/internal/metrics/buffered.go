@@ -0,0 +1,164 @@
+// internal/metrics/buffered.go
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// defaultFlushInterval is used when NewBufferedCollector gets a non-positive interval
+const defaultFlushInterval = 10 * time.Second
+
+// BufferedCollector accumulates metrics in memory and sends them to CloudWatch
+// periodically, or as soon as a full PutMetricData call's worth is buffered.
+// It is safe for concurrent use. Call Close before the process exits so the
+// final window isn't lost.
+type BufferedCollector struct {
+	*CloudWatchCollector
+
+	mu     sync.Mutex
+	buffer []types.MetricDatum
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewBufferedCollector creates a collector that flushes every flushInterval.
+// The background flusher stops when ctx is canceled or Close is called.
+func NewBufferedCollector(ctx context.Context, namespace string, flushInterval time.Duration, opts ...Option) (*BufferedCollector, error) {
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	base, err := NewCloudWatchCollector(ctx, namespace, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &BufferedCollector{
+		CloudWatchCollector: base,
+		buffer:              make([]types.MetricDatum, 0, maxDatumsPerCall),
+		stop:                make(chan struct{}),
+		done:                make(chan struct{}),
+	}
+	go b.run(ctx, flushInterval)
+	return b, nil
+}
+
+// run flushes the buffer on every tick until stopped
+func (b *BufferedCollector) run(ctx context.Context, flushInterval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := b.Flush(ctx); err != nil {
+				fmt.Printf("Warning: periodic metrics flush failed: %v\n", err)
+			}
+		case <-b.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// EmitBatch buffers metrics for the next flush
+func (b *BufferedCollector) EmitBatch(ctx context.Context, metrics map[string]MetricValue) error {
+	data := b.buildData(metrics)
+	if len(data) == 0 {
+		return nil
+	}
+	return b.add(ctx, data)
+}
+
+// EmitData buffers datums with per-datum dimensions
+func (b *BufferedCollector) EmitData(ctx context.Context, datums []Datum) error {
+	data, err := b.buildDatums(datums)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return b.add(ctx, data)
+}
+
+// EmitBatchWithDimensions buffers metrics tagged with extra dimensions
+func (b *BufferedCollector) EmitBatchWithDimensions(ctx context.Context, metrics map[string]MetricValue, extraDims map[string]string) error {
+	data, err := b.buildDataWithDimensions(metrics, extraDims)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return b.add(ctx, data)
+}
+
+// EmitLatency buffers a latency metric in milliseconds
+func (b *BufferedCollector) EmitLatency(ctx context.Context, name string, valueMs float64) error {
+	return b.EmitBatch(ctx, map[string]MetricValue{name: LatencyMs(valueMs)})
+}
+
+// EmitCount buffers a count metric
+func (b *BufferedCollector) EmitCount(ctx context.Context, name string, value float64) error {
+	return b.EmitBatch(ctx, map[string]MetricValue{name: Count(value)})
+}
+
+// EmitBytes buffers a bytes metric
+func (b *BufferedCollector) EmitBytes(ctx context.Context, name string, value float64) error {
+	return b.EmitBatch(ctx, map[string]MetricValue{name: {Value: value, Unit: types.StandardUnitBytes}})
+}
+
+// EmitStatisticSet buffers a pre-aggregated distribution
+func (b *BufferedCollector) EmitStatisticSet(ctx context.Context, name string, set StatisticValues, unit types.StandardUnit) error {
+	return b.EmitBatch(ctx, map[string]MetricValue{name: Statistics(set, unit)})
+}
+
+// add appends datums and sends any full chunks immediately
+func (b *BufferedCollector) add(ctx context.Context, data []types.MetricDatum) error {
+	b.mu.Lock()
+	b.buffer = append(b.buffer, data...)
+
+	var full []types.MetricDatum
+	if n := len(b.buffer) / maxDatumsPerCall * maxDatumsPerCall; n > 0 {
+		full = b.buffer[:n:n]
+		b.buffer = append(make([]types.MetricDatum, 0, maxDatumsPerCall), b.buffer[n:]...)
+	}
+	b.mu.Unlock()
+
+	// Send outside the lock so other goroutines can keep buffering
+	if len(full) > 0 {
+		return b.putData(ctx, full)
+	}
+	return nil
+}
+
+// Flush sends everything currently buffered
+func (b *BufferedCollector) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	data := b.buffer
+	b.buffer = make([]types.MetricDatum, 0, maxDatumsPerCall)
+	b.mu.Unlock()
+
+	if len(data) == 0 {
+		return nil
+	}
+	return b.putData(ctx, data)
+}
+
+// Close stops the background flusher and drains the buffer
+func (b *BufferedCollector) Close(ctx context.Context) error {
+	b.closeOnce.Do(func() { close(b.stop) })
+	<-b.done
+	return b.Flush(ctx)
+}
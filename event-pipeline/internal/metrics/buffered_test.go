@@ -0,0 +1,91 @@
+// internal/metrics/buffered_test.go
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// newTestBufferedCollector wraps a test collector for client in a buffer
+// whose periodic flush never fires during a test
+func newTestBufferedCollector(t *testing.T, client putMetricDataAPI) *BufferedCollector {
+	t.Helper()
+	b := &BufferedCollector{
+		CloudWatchCollector: newTestCollector(client),
+		buffer:              make([]types.MetricDatum, 0, maxDatumsPerCall),
+		stop:                make(chan struct{}),
+		done:                make(chan struct{}),
+	}
+	go b.run(context.Background(), time.Hour)
+	t.Cleanup(func() { b.Close(context.Background()) })
+	return b
+}
+
+func TestShutdownFlushesBuffer(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	b := newTestBufferedCollector(t, cw)
+	ctx := context.Background()
+
+	b.EmitCount(ctx, "WorkerSuccessCount", 1)
+	b.EmitLatency(ctx, "WorkerProcessingLatencyMs", 250)
+	b.EmitBatch(ctx, map[string]MetricValue{"WorkerLinesProcessed": Count(3), "WorkerErrorsFound": Count(1)})
+	if len(cw.calls) != 0 {
+		t.Fatalf("sent %d calls before shutdown, want the datums buffered", len(cw.calls))
+	}
+
+	if err := Shutdown(b, DefaultShutdownTimeout); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(cw.calls) != 1 {
+		t.Fatalf("got %d PutMetricData calls, want one", len(cw.calls))
+	}
+	want := []string{"WorkerErrorsFound", "WorkerLinesProcessed", "WorkerProcessingLatencyMs", "WorkerSuccessCount"}
+	if got := sentNames(cw); !slices.Equal(got, want) {
+		t.Errorf("sent %v, want %v", got, want)
+	}
+	if got := cw.datums()["WorkerProcessingLatencyMs"]; *got.Value != 250 || got.Unit != types.StandardUnitMilliseconds {
+		t.Errorf("WorkerProcessingLatencyMs = %v %s, want 250 Milliseconds", *got.Value, got.Unit)
+	}
+
+	// A second shutdown has nothing left to send
+	if err := Shutdown(b, DefaultShutdownTimeout); err != nil || len(cw.calls) != 1 {
+		t.Errorf("second Shutdown = %v with %d calls, want nil and no new call", err, len(cw.calls))
+	}
+}
+
+func TestBufferSendsFullChunks(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	b := newTestBufferedCollector(t, cw)
+
+	batch := make(map[string]MetricValue, maxDatumsPerCall+5)
+	for i := range maxDatumsPerCall + 5 {
+		batch[fmt.Sprintf("Metric%d", i)] = Count(1)
+	}
+	if err := b.EmitBatch(context.Background(), batch); err != nil {
+		t.Fatalf("EmitBatch: %v", err)
+	}
+	if len(cw.calls) != 1 || len(cw.calls[0].MetricData) != maxDatumsPerCall {
+		t.Fatalf("got %d calls, want one full call before shutdown", len(cw.calls))
+	}
+
+	if err := Shutdown(b, DefaultShutdownTimeout); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if len(cw.calls) != 2 || len(cw.calls[1].MetricData) != 5 {
+		t.Errorf("got %d calls, want the remaining 5 datums in a second call", len(cw.calls))
+	}
+}
+
+func TestShutdownIgnoresUnbufferedCollectors(t *testing.T) {
+	if err := Shutdown(nil, DefaultShutdownTimeout); err != nil {
+		t.Errorf("Shutdown(nil) = %v", err)
+	}
+	if err := Shutdown(newTestCollector(&fakeCloudWatch{}), DefaultShutdownTimeout); err != nil {
+		t.Errorf("Shutdown(CloudWatchCollector) = %v", err)
+	}
+}
@@ -3,36 +3,61 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"event-pipeline/internal/awsconfig"
+)
+
+const (
+	// maxDatumsPerCall is the CloudWatch limit on datums per PutMetricData call
+	maxDatumsPerCall = 1000
+
+	// maxDimensionsPerMetric is the CloudWatch limit on dimensions per datum
+	maxDimensionsPerMetric = 30
 )
 
-// Collector handles custom CloudWatch metrics emission
-type Collector struct {
-	client    *cloudwatch.Client
-	namespace string
-	dims      []types.Dimension
+// putMetricDataAPI is the part of the CloudWatch client collectors use, so
+// tests can stub it
+type putMetricDataAPI interface {
+	PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
 }
 
-// NewCollector creates a new metrics collector
-func NewCollector(ctx context.Context, namespace string) (*Collector, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
-	}
+// CloudWatchCollector handles custom CloudWatch metrics emission.
+// It is immutable after construction and safe for concurrent use.
+type CloudWatchCollector struct {
+	client      putMetricDataAPI
+	namespace   string
+	extraNS     []string // also receive every metric; see WithExtraNamespaces
+	dims        []types.Dimension
+	maxAttempts int
+	baseDelay   time.Duration
+	callTimeout time.Duration // bounds each PutMetricData call including retries
+
+	// High-resolution (1s) storage, off unless WithHighResolution is used
+	highRes      bool
+	highResUnits map[types.StandardUnit]bool
 
-	// Check for LocalStack endpoint
-	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
-		cfg.BaseEndpoint = aws.String(endpoint)
+	// Zero-valued datums are dropped, except keepZero ones; see WithSkipZero
+	skipZero bool
+	keepZero map[string]bool
+}
+
+// NewCloudWatchCollector creates a new metrics collector
+func NewCloudWatchCollector(ctx context.Context, namespace string, opts ...Option) (*CloudWatchCollector, error) {
+	cfg, err := awsconfig.Load(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	client := cloudwatch.NewFromConfig(cfg)
+	client := awsconfig.NewCloudWatch(cfg)
 
 	// Default dimensions
 	dims := []types.Dimension{
@@ -44,93 +69,299 @@ func NewCollector(ctx context.Context, namespace string) (*Collector, error) {
 			Name:  aws.String("Service"),
 			Value: aws.String("event-pipeline"),
 		},
+		{
+			Name:  aws.String("Region"),
+			Value: aws.String(resolveRegion(cfg.Region)),
+		},
 	}
 
-	return &Collector{
-		client:    client,
-		namespace: namespace,
-		dims:      dims,
-	}, nil
+	c := &CloudWatchCollector{
+		client:      client,
+		namespace:   namespace,
+		dims:        dims,
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		callTimeout: defaultCallTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // EmitLatency records a latency metric in milliseconds
-func (c *Collector) EmitLatency(ctx context.Context, name string, valueMs float64) error {
+func (c *CloudWatchCollector) EmitLatency(ctx context.Context, name string, valueMs float64) error {
 	return c.emit(ctx, name, valueMs, types.StandardUnitMilliseconds)
 }
 
 // EmitCount records a count metric
-func (c *Collector) EmitCount(ctx context.Context, name string, value float64) error {
+func (c *CloudWatchCollector) EmitCount(ctx context.Context, name string, value float64) error {
 	return c.emit(ctx, name, value, types.StandardUnitCount)
 }
 
 // EmitBytes records a bytes metric
-func (c *Collector) EmitBytes(ctx context.Context, name string, value float64) error {
+func (c *CloudWatchCollector) EmitBytes(ctx context.Context, name string, value float64) error {
 	return c.emit(ctx, name, value, types.StandardUnitBytes)
 }
 
-// emit sends a metric to CloudWatch
-func (c *Collector) emit(ctx context.Context, name string, value float64, unit types.StandardUnit) error {
-	_, err := c.client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
-		Namespace: aws.String(c.namespace),
-		MetricData: []types.MetricDatum{
-			{
-				MetricName: aws.String(name),
-				Value:      aws.Float64(value),
-				Unit:       unit,
-				Timestamp:  aws.Time(time.Now()),
-				Dimensions: c.dims,
-			},
-		},
-	})
+// emit sends a metric to CloudWatch unless it is a zero the collector skips
+func (c *CloudWatchCollector) emit(ctx context.Context, name string, value float64, unit types.StandardUnit) error {
+	if c.skips(name, MetricValue{Value: value, Unit: unit}) {
+		return nil
+	}
+	return c.send(ctx, name, value, unit)
+}
+
+// send puts a single datum with the default dimensions
+func (c *CloudWatchCollector) send(ctx context.Context, name string, value float64, unit types.StandardUnit) error {
+	datum := c.newDatum(name, MetricValue{Value: value, Unit: unit}, c.dims, aws.Time(time.Now()))
+	err := c.putData(ctx, []types.MetricDatum{datum})
 
 	if err != nil {
-		return fmt.Errorf("failed to emit metric %s: %w", name, err)
+		return fmt.Errorf("metric %s: %w", name, err)
 	}
 	return nil
 }
 
+// Ping sends a zero-valued SelfTest count straight to CloudWatch, bypassing
+// any buffering, to confirm the caller may publish metrics
+func (c *CloudWatchCollector) Ping(ctx context.Context) error {
+	return c.send(ctx, "SelfTest", 0, types.StandardUnitCount)
+}
+
 // EmitBatch sends multiple metrics at once (more efficient)
-func (c *Collector) EmitBatch(ctx context.Context, metrics map[string]MetricValue) error {
-	if len(metrics) == 0 {
+func (c *CloudWatchCollector) EmitBatch(ctx context.Context, metrics map[string]MetricValue) error {
+	data := c.buildData(metrics)
+	if len(data) == 0 {
 		return nil
 	}
+	return c.putData(ctx, data)
+}
+
+// EmitBatchWithDimensions sends metrics tagged with extraDims in addition to
+// the collector's default dimensions, for this call only
+func (c *CloudWatchCollector) EmitBatchWithDimensions(ctx context.Context, metrics map[string]MetricValue, extraDims map[string]string) error {
+	data, err := c.buildDataWithDimensions(metrics, extraDims)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return c.putData(ctx, data)
+}
+
+// EmitData sends datums that may each carry their own dimensions, such as
+// one count per status code. Per-datum dimensions are added to the defaults.
+func (c *CloudWatchCollector) EmitData(ctx context.Context, datums []Datum) error {
+	data, err := c.buildDatums(datums)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return c.putData(ctx, data)
+}
+
+// Datum is a single named metric value with optional extra dimensions
+type Datum struct {
+	Name       string
+	Value      MetricValue
+	Dimensions map[string]string
+}
+
+// buildData converts named metric values into datums with the default
+// dimensions, leaving out skipped zeros
+func (c *CloudWatchCollector) buildData(metrics map[string]MetricValue) []types.MetricDatum {
+	data := make([]types.MetricDatum, 0, len(metrics))
+	timestamp := aws.Time(time.Now())
+
+	for name, mv := range metrics {
+		if c.skips(name, mv) {
+			continue
+		}
+		data = append(data, c.newDatum(name, mv, c.dims, timestamp))
+	}
+	return data
+}
+
+// buildDataWithDimensions converts named metric values into datums sharing
+// the default dimensions plus extra
+func (c *CloudWatchCollector) buildDataWithDimensions(metrics map[string]MetricValue, extra map[string]string) ([]types.MetricDatum, error) {
+	dims, err := c.withDimensions(extra)
+	if err != nil {
+		return nil, err
+	}
 
 	data := make([]types.MetricDatum, 0, len(metrics))
 	timestamp := aws.Time(time.Now())
 
 	for name, mv := range metrics {
-		data = append(data, types.MetricDatum{
-			MetricName: aws.String(name),
-			Value:      aws.Float64(mv.Value),
-			Unit:       mv.Unit,
-			Timestamp:  timestamp,
-			Dimensions: c.dims,
+		if c.skips(name, mv) {
+			continue
+		}
+		data = append(data, c.newDatum(name, mv, dims, timestamp))
+	}
+	return data, nil
+}
+
+// buildDatums converts Datums into CloudWatch datums, merging dimensions
+func (c *CloudWatchCollector) buildDatums(datums []Datum) ([]types.MetricDatum, error) {
+	data := make([]types.MetricDatum, 0, len(datums))
+	timestamp := aws.Time(time.Now())
+
+	for _, d := range datums {
+		if c.skips(d.Name, d.Value) {
+			continue
+		}
+		dims, err := c.withDimensions(d.Dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", d.Name, err)
+		}
+		data = append(data, c.newDatum(d.Name, d.Value, dims, timestamp))
+	}
+	return data, nil
+}
+
+// withDimensions returns the default dimensions plus extra, sorted by name
+// so identical metrics always produce identical dimension lists. Extra
+// dimensions may not redefine a default one or exceed CloudWatch's limit.
+func (c *CloudWatchCollector) withDimensions(extra map[string]string) ([]types.Dimension, error) {
+	if len(extra) == 0 {
+		return c.dims, nil
+	}
+	if total := len(c.dims) + len(extra); total > maxDimensionsPerMetric {
+		return nil, fmt.Errorf("%d dimensions exceeds the CloudWatch limit of %d per metric", total, maxDimensionsPerMetric)
+	}
+	for _, dim := range c.dims {
+		if _, dup := extra[aws.ToString(dim.Name)]; dup {
+			return nil, fmt.Errorf("dimension %s duplicates a default dimension", aws.ToString(dim.Name))
+		}
+	}
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	dims := make([]types.Dimension, 0, len(c.dims)+len(extra))
+	dims = append(dims, c.dims...)
+	for _, name := range names {
+		dims = append(dims, types.Dimension{
+			Name:  aws.String(name),
+			Value: aws.String(extra[name]),
 		})
 	}
+	return dims, nil
+}
+
+// skips reports whether a datum is a zero value that WithSkipZero drops.
+// Statistic sets are never skipped.
+func (c *CloudWatchCollector) skips(name string, mv MetricValue) bool {
+	return c.skipZero && mv.Statistics == nil && mv.Value == 0 && !mv.KeepZero && !c.keepZero[name]
+}
 
-	// CloudWatch accepts max 1000 metrics per call, batch if needed
-	for i := 0; i < len(data); i += 1000 {
-		end := i + 1000
+// newDatum builds a single CloudWatch datum
+func (c *CloudWatchCollector) newDatum(name string, mv MetricValue, dims []types.Dimension, timestamp *time.Time) types.MetricDatum {
+	datum := types.MetricDatum{
+		MetricName: aws.String(name),
+		Unit:       mv.Unit,
+		Timestamp:  timestamp,
+		Dimensions: dims,
+	}
+	if res := c.storageResolution(mv); res != 0 {
+		datum.StorageResolution = aws.Int32(res)
+	}
+	if mv.Statistics != nil {
+		datum.StatisticValues = &types.StatisticSet{
+			SampleCount: aws.Float64(mv.Statistics.SampleCount),
+			Sum:         aws.Float64(mv.Statistics.Sum),
+			Minimum:     aws.Float64(mv.Statistics.Minimum),
+			Maximum:     aws.Float64(mv.Statistics.Maximum),
+		}
+	} else {
+		datum.Value = aws.Float64(mv.Value)
+	}
+	return datum
+}
+
+// putData sends datums to the collector's namespace and each extra one. A
+// failing namespace doesn't stop the others; their errors are joined.
+func (c *CloudWatchCollector) putData(ctx context.Context, data []types.MetricDatum) error {
+	var errs []error
+	for _, namespace := range append([]string{c.namespace}, c.extraNS...) {
+		if err := c.putNamespace(ctx, namespace, data); err != nil {
+			errs = append(errs, fmt.Errorf("failed to emit metrics to %s: %w", namespace, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// putNamespace sends datums to one namespace in chunks of maxDatumsPerCall
+func (c *CloudWatchCollector) putNamespace(ctx context.Context, namespace string, data []types.MetricDatum) error {
+	for i := 0; i < len(data); i += maxDatumsPerCall {
+		end := i + maxDatumsPerCall
 		if end > len(data) {
 			end = len(data)
 		}
 
-		_, err := c.client.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
-			Namespace:  aws.String(c.namespace),
+		err := c.putWithRetry(ctx, &cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(namespace),
 			MetricData: data[i:end],
 		})
 		if err != nil {
-			return fmt.Errorf("failed to emit batch metrics: %w", err)
+			return err
 		}
 	}
 
 	return nil
 }
 
-// MetricValue holds a metric value and its unit
+// EmitStatisticSet records a pre-aggregated distribution as a single datum
+func (c *CloudWatchCollector) EmitStatisticSet(ctx context.Context, name string, set StatisticValues, unit types.StandardUnit) error {
+	return c.EmitBatch(ctx, map[string]MetricValue{name: Statistics(set, unit)})
+}
+
+// storageResolution picks the datum's resolution: the per-metric override
+// if set, else 1 second when high resolution applies to its unit, else the
+// CloudWatch default (0 leaves the field unset)
+func (c *CloudWatchCollector) storageResolution(mv MetricValue) int32 {
+	if mv.StorageResolution != 0 {
+		return mv.StorageResolution
+	}
+	if c.highRes && (len(c.highResUnits) == 0 || c.highResUnits[mv.Unit]) {
+		return HighResolution
+	}
+	return 0
+}
+
+// Storage resolutions accepted by CloudWatch, in seconds
+const (
+	HighResolution     int32 = 1
+	StandardResolution int32 = 60
+)
+
+// MetricValue holds a metric value and its unit.
+// When Statistics is set it is sent as a StatisticSet and Value is ignored.
+// StorageResolution overrides the collector's resolution for this metric,
+// and KeepZero sends a zero Value even under WithSkipZero.
 type MetricValue struct {
-	Value float64
-	Unit  types.StandardUnit
+	Value             float64
+	Unit              types.StandardUnit
+	Statistics        *StatisticValues
+	StorageResolution int32
+	KeepZero          bool
+}
+
+// StatisticValues summarizes many observations of a metric.
+// SampleCount must be positive for CloudWatch to accept the datum.
+type StatisticValues struct {
+	SampleCount float64
+	Sum         float64
+	Minimum     float64
+	Maximum     float64
 }
 
 // Helper to create latency metric value
@@ -143,10 +374,34 @@ func Count(v float64) MetricValue {
 	return MetricValue{Value: v, Unit: types.StandardUnitCount}
 }
 
+// Always marks mv to be sent even when it is zero, for counts whose zero
+// is meaningful
+func Always(mv MetricValue) MetricValue {
+	mv.KeepZero = true
+	return mv
+}
+
+// Helper to create a statistic set metric value
+func Statistics(set StatisticValues, unit types.StandardUnit) MetricValue {
+	return MetricValue{Unit: unit, Statistics: &set}
+}
+
+// resolveRegion returns the configured region, falling back to AWS_REGION
+// and then "unknown" so the Region dimension is never empty
+func resolveRegion(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return "unknown"
+}
+
 // getEnvironment returns the current environment
 func getEnvironment() string {
 	if env := os.Getenv("ENVIRONMENT"); env != "" {
 		return env
 	}
 	return "development"
-}
\ No newline at end of file
+}
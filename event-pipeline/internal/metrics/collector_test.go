@@ -0,0 +1,353 @@
+// internal/metrics/collector_test.go
+package metrics
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+// fakeCloudWatch records every PutMetricData call. Each call returns the
+// next of errs, then nil.
+type fakeCloudWatch struct {
+	mu    sync.Mutex
+	calls []*cloudwatch.PutMetricDataInput
+	errs  []error
+}
+
+func (f *fakeCloudWatch) PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, params)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return &cloudwatch.PutMetricDataOutput{}, nil
+}
+
+// datums returns every datum sent, by metric name
+func (f *fakeCloudWatch) datums() map[string]types.MetricDatum {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	byName := map[string]types.MetricDatum{}
+	for _, call := range f.calls {
+		for _, datum := range call.MetricData {
+			byName[aws.ToString(datum.MetricName)] = datum
+		}
+	}
+	return byName
+}
+
+// newTestCollector creates a collector for namespace Test that sends to
+// client without retry delays
+func newTestCollector(client putMetricDataAPI, opts ...Option) *CloudWatchCollector {
+	c := &CloudWatchCollector{
+		client:      client,
+		namespace:   "Test",
+		dims:        []types.Dimension{{Name: aws.String("Environment"), Value: aws.String("test")}},
+		maxAttempts: defaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func TestEmitStatisticSet(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	c := newTestCollector(cw)
+
+	set := StatisticValues{SampleCount: 3, Sum: 690, Minimum: 90, Maximum: 480}
+	if err := c.EmitStatisticSet(context.Background(), "ResponseTimeMs", set, types.StandardUnitMilliseconds); err != nil {
+		t.Fatalf("EmitStatisticSet: %v", err)
+	}
+
+	if len(cw.calls) != 1 || len(cw.calls[0].MetricData) != 1 {
+		t.Fatalf("got %d calls, want one call with one datum", len(cw.calls))
+	}
+	datum := cw.calls[0].MetricData[0]
+	if datum.Value != nil {
+		t.Errorf("datum has Value %v, want only StatisticValues", *datum.Value)
+	}
+	got := datum.StatisticValues
+	if got == nil || *got.SampleCount != 3 || *got.Sum != 690 || *got.Minimum != 90 || *got.Maximum != 480 {
+		t.Fatalf("StatisticValues = %+v, want %+v", got, set)
+	}
+	if datum.Unit != types.StandardUnitMilliseconds {
+		t.Errorf("unit = %s, want Milliseconds", datum.Unit)
+	}
+}
+
+func TestPrometheusStatisticSet(t *testing.T) {
+	p := NewPrometheusCollector("EventPipeline")
+	set := StatisticValues{SampleCount: 3, Sum: 690, Minimum: 90, Maximum: 480}
+	if err := p.EmitStatisticSet(context.Background(), "ResponseTimeMs", set, types.StandardUnitMilliseconds); err != nil {
+		t.Fatalf("EmitStatisticSet: %v", err)
+	}
+
+	families, err := p.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	for _, family := range families {
+		m := family.GetMetric()[0]
+		switch {
+		case m.GetCounter() != nil:
+			got[family.GetName()] = m.GetCounter().GetValue()
+		case m.GetGauge() != nil:
+			got[family.GetName()] = m.GetGauge().GetValue()
+		}
+	}
+
+	want := map[string]float64{
+		"event_pipeline_response_time_ms_sum_total":   690,
+		"event_pipeline_response_time_ms_count_total": 3,
+		"event_pipeline_response_time_ms_min":         90,
+		"event_pipeline_response_time_ms_max":         480,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s = %v, want %v (have %v)", name, got[name], value, got)
+		}
+	}
+}
+
+func TestExtraNamespaces(t *testing.T) {
+	cw := &fakeCloudWatch{}
+	// Empty names and the collector's own namespace are ignored
+	c := newTestCollector(cw, WithExtraNamespaces("Shared", "", "Test", "Org/All"))
+
+	err := c.EmitBatch(context.Background(), map[string]MetricValue{
+		"Files":  Count(2),
+		"Errors": Count(1),
+	})
+	if err != nil {
+		t.Fatalf("EmitBatch: %v", err)
+	}
+
+	var namespaces []string
+	for _, call := range cw.calls {
+		namespaces = append(namespaces, aws.ToString(call.Namespace))
+		if len(call.MetricData) != 2 {
+			t.Errorf("%s got %d datums, want both", aws.ToString(call.Namespace), len(call.MetricData))
+		}
+	}
+	if want := []string{"Test", "Shared", "Org/All"}; !slices.Equal(namespaces, want) {
+		t.Errorf("PutMetricData namespaces = %q, want one call each to %q", namespaces, want)
+	}
+}
+
+func TestStorageResolution(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]int32 // 0 leaves StorageResolution unset
+	}{
+		{
+			name: "standard",
+			want: map[string]int32{"Latency": 0, "Files": 0, "Pinned": StandardResolution, "Single": 0, "PerDatum": 0},
+		},
+		{
+			name: "high resolution",
+			opts: []Option{WithHighResolution()},
+			want: map[string]int32{"Latency": 1, "Files": 1, "Pinned": StandardResolution, "Single": 1, "PerDatum": 1},
+		},
+		{
+			// Only millisecond metrics are high resolution
+			name: "high resolution by unit",
+			opts: []Option{WithHighResolution(types.StandardUnitMilliseconds)},
+			want: map[string]int32{"Latency": 1, "Files": 0, "Pinned": StandardResolution, "Single": 1, "PerDatum": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := &fakeCloudWatch{}
+			c := newTestCollector(cw, tt.opts...)
+			ctx := context.Background()
+
+			c.EmitBatch(ctx, map[string]MetricValue{
+				"Latency": LatencyMs(12),
+				"Files":   Count(2),
+				"Pinned":  {Value: 5, Unit: types.StandardUnitMilliseconds, StorageResolution: StandardResolution},
+			})
+			c.EmitLatency(ctx, "Single", 30)
+			c.EmitData(ctx, []Datum{{Name: "PerDatum", Value: Count(1), Dimensions: map[string]string{"StatusCode": "200"}}})
+
+			datums := cw.datums()
+			for name, want := range tt.want {
+				got := int32(0)
+				if res := datums[name].StorageResolution; res != nil {
+					got = *res
+				}
+				if got != want {
+					t.Errorf("%s StorageResolution = %d, want %d", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegionDimension(t *testing.T) {
+	// Keep config loading off the network and out of local AWS profiles
+	t.Setenv("AWS_REGION", "eu-west-2")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("ENVIRONMENT", "staging")
+
+	c, err := NewCloudWatchCollector(context.Background(), "Test")
+	if err != nil {
+		t.Fatalf("NewCloudWatchCollector: %v", err)
+	}
+	cw := &fakeCloudWatch{}
+	c.client = cw
+
+	if err := c.EmitCount(context.Background(), "Files", 1); err != nil {
+		t.Fatalf("EmitCount: %v", err)
+	}
+	dims := map[string]string{}
+	for _, dim := range cw.datums()["Files"].Dimensions {
+		dims[aws.ToString(dim.Name)] = aws.ToString(dim.Value)
+	}
+	want := map[string]string{"Environment": "staging", "Service": "event-pipeline", "Region": "eu-west-2"}
+	if !maps.Equal(dims, want) {
+		t.Errorf("dimensions = %v, want %v", dims, want)
+	}
+}
+
+func TestResolveRegion(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		env        string
+		want       string
+	}{
+		{name: "configured", configured: "ap-southeast-2", env: "us-east-1", want: "ap-southeast-2"},
+		{name: "environment", env: "us-east-1", want: "us-east-1"},
+		{name: "unknown", want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_REGION", tt.env)
+			if got := resolveRegion(tt.configured); got != tt.want {
+				t.Errorf("resolveRegion(%q) = %q, want %q", tt.configured, got, tt.want)
+			}
+		})
+	}
+}
+
+// sentNames returns the sorted names of every datum sent
+func sentNames(cw *fakeCloudWatch) []string {
+	return slices.Sorted(maps.Keys(cw.datums()))
+}
+
+func TestSkipZero(t *testing.T) {
+	batch := map[string]MetricValue{
+		"Files":         Count(1),
+		"ErrorsFound":   Count(0),
+		"FilesRejected": Always(Count(0)),
+		"QueueDepth":    Count(0),
+		"Latency":       Statistics(StatisticValues{}, types.StandardUnitMilliseconds),
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "off",
+			want: []string{"ErrorsFound", "Files", "FilesRejected", "Latency", "QueueDepth"},
+		},
+		{
+			// Statistic sets and values marked Always are kept
+			name: "on",
+			opts: []Option{WithSkipZero()},
+			want: []string{"Files", "FilesRejected", "Latency"},
+		},
+		{
+			name: "keep list",
+			opts: []Option{WithSkipZero("QueueDepth", "Unrelated")},
+			want: []string{"Files", "FilesRejected", "Latency", "QueueDepth"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			batchCW := &fakeCloudWatch{}
+			if err := newTestCollector(batchCW, tt.opts...).EmitBatch(ctx, batch); err != nil {
+				t.Fatalf("EmitBatch: %v", err)
+			}
+			if got := sentNames(batchCW); !slices.Equal(got, tt.want) {
+				t.Errorf("EmitBatch sent %q, want %q", got, tt.want)
+			}
+
+			dimsCW := &fakeCloudWatch{}
+			if err := newTestCollector(dimsCW, tt.opts...).EmitBatchWithDimensions(ctx, batch, map[string]string{"Queue": "jobs"}); err != nil {
+				t.Fatalf("EmitBatchWithDimensions: %v", err)
+			}
+			if got := sentNames(dimsCW); !slices.Equal(got, tt.want) {
+				t.Errorf("EmitBatchWithDimensions sent %q, want %q", got, tt.want)
+			}
+
+			var datums []Datum
+			for name, mv := range batch {
+				datums = append(datums, Datum{Name: name, Value: mv})
+			}
+			dataCW := &fakeCloudWatch{}
+			if err := newTestCollector(dataCW, tt.opts...).EmitData(ctx, datums); err != nil {
+				t.Fatalf("EmitData: %v", err)
+			}
+			if got := sentNames(dataCW); !slices.Equal(got, tt.want) {
+				t.Errorf("EmitData sent %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipZeroSingleMetric(t *testing.T) {
+	ctx := context.Background()
+
+	cw := &fakeCloudWatch{}
+	c := newTestCollector(cw, WithSkipZero("QueueDepth"))
+	c.EmitCount(ctx, "ErrorsFound", 0)
+	c.EmitLatency(ctx, "ParseMs", 0)
+	c.EmitBytes(ctx, "BytesRead", 0)
+	c.EmitCount(ctx, "QueueDepth", 0)
+	c.EmitCount(ctx, "Files", 3)
+	if want := []string{"Files", "QueueDepth"}; !slices.Equal(sentNames(cw), want) {
+		t.Errorf("sent %q, want %q", sentNames(cw), want)
+	}
+
+	// Nothing left to send makes no call at all
+	empty := &fakeCloudWatch{}
+	if err := newTestCollector(empty, WithSkipZero()).EmitBatch(ctx, map[string]MetricValue{"ErrorsFound": Count(0)}); err != nil {
+		t.Fatalf("EmitBatch: %v", err)
+	}
+	if len(empty.calls) != 0 {
+		t.Errorf("made %d PutMetricData calls for an all-zero batch, want none", len(empty.calls))
+	}
+}
+
+func TestSkipZeroEnvOptions(t *testing.T) {
+	t.Setenv("METRICS_SKIP_ZERO", "true")
+	t.Setenv("METRICS_KEEP_ZERO", " QueueDepth , ,Backlog")
+
+	c := newTestCollector(&fakeCloudWatch{}, EnvOptions()...)
+	if !c.skipZero {
+		t.Fatal("METRICS_SKIP_ZERO=true did not enable WithSkipZero")
+	}
+	if len(c.keepZero) != 2 || !c.keepZero["QueueDepth"] || !c.keepZero["Backlog"] {
+		t.Errorf("keepZero = %v, want QueueDepth and Backlog", c.keepZero)
+	}
+}
@@ -0,0 +1,126 @@
+// internal/metrics/options.go
+package metrics
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+
+	"event-pipeline/internal/envconfig"
+)
+
+// Option configures a CloudWatchCollector
+type Option func(*CloudWatchCollector)
+
+// WithMaxAttempts sets the total number of PutMetricData attempts (1 disables retries)
+func WithMaxAttempts(n int) Option {
+	return func(c *CloudWatchCollector) {
+		if n < 1 {
+			n = 1
+		}
+		c.maxAttempts = n
+	}
+}
+
+// WithBaseDelay sets the initial backoff delay, doubled on each retry.
+// A zero delay retries immediately, which is useful in tests.
+func WithBaseDelay(d time.Duration) Option {
+	return func(c *CloudWatchCollector) {
+		if d < 0 {
+			d = 0
+		}
+		c.baseDelay = d
+	}
+}
+
+// WithCallTimeout bounds each emit, retries included (0 disables the bound)
+func WithCallTimeout(d time.Duration) Option {
+	return func(c *CloudWatchCollector) {
+		if d < 0 {
+			d = 0
+		}
+		c.callTimeout = d
+	}
+}
+
+// WithHighResolution stores metrics at 1-second resolution, which costs more
+// than the standard 60 seconds. With units given, only metrics in those units
+// (e.g. types.StandardUnitMilliseconds) are high resolution.
+func WithHighResolution(units ...types.StandardUnit) Option {
+	return func(c *CloudWatchCollector) {
+		c.highRes = true
+		c.highResUnits = make(map[types.StandardUnit]bool, len(units))
+		for _, unit := range units {
+			c.highResUnits[unit] = true
+		}
+	}
+}
+
+// WithExtraNamespaces also publishes every metric to each of namespaces,
+// e.g. a shared org-wide namespace next to the team's own. Each namespace
+// is a separate PutMetricData call, billed separately.
+func WithExtraNamespaces(namespaces ...string) Option {
+	return func(c *CloudWatchCollector) {
+		for _, ns := range namespaces {
+			if ns != "" && ns != c.namespace {
+				c.extraNS = append(c.extraNS, ns)
+			}
+		}
+	}
+}
+
+// WithSkipZero drops datums whose value is zero, such as WorkerErrorsFound
+// for a clean file, to save cost and noise. Metrics named in keep, and
+// values marked with Always, are still sent when zero. Statistic sets are
+// never dropped.
+func WithSkipZero(keep ...string) Option {
+	return func(c *CloudWatchCollector) {
+		c.skipZero = true
+		c.keepZero = make(map[string]bool, len(keep))
+		for _, name := range keep {
+			c.keepZero[name] = true
+		}
+	}
+}
+
+// EnvOptions returns options configured through environment variables.
+// HIGH_RES_METRICS=latency makes millisecond metrics high resolution and
+// HIGH_RES_METRICS=all applies it to every metric. METRICS_TIMEOUT sets
+// WithCallTimeout, and the comma-separated METRICS_EXTRA_NAMESPACES sets
+// WithExtraNamespaces. METRICS_SKIP_ZERO=true sets WithSkipZero, keeping the
+// comma-separated metrics in METRICS_KEEP_ZERO.
+func EnvOptions() []Option {
+	opts := []Option{WithCallTimeout(envconfig.Duration("METRICS_TIMEOUT", defaultCallTimeout))}
+
+	if raw := os.Getenv("METRICS_EXTRA_NAMESPACES"); raw != "" {
+		var namespaces []string
+		for _, ns := range strings.Split(raw, ",") {
+			namespaces = append(namespaces, strings.TrimSpace(ns))
+		}
+		opts = append(opts, WithExtraNamespaces(namespaces...))
+	}
+
+	if envconfig.Bool("METRICS_SKIP_ZERO", false) {
+		var keep []string
+		for _, name := range strings.Split(os.Getenv("METRICS_KEEP_ZERO"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				keep = append(keep, name)
+			}
+		}
+		opts = append(opts, WithSkipZero(keep...))
+	}
+
+	switch mode := os.Getenv("HIGH_RES_METRICS"); mode {
+	case "":
+	case "latency":
+		opts = append(opts, WithHighResolution(types.StandardUnitMilliseconds))
+	case "all":
+		opts = append(opts, WithHighResolution())
+	default:
+		fmt.Printf("Warning: unknown HIGH_RES_METRICS=%q, using standard resolution\n", mode)
+	}
+	return opts
+}
@@ -0,0 +1,229 @@
+// internal/metrics/prometheus.go
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// latencyBuckets are the histogram boundaries (ms) for latency metrics
+var latencyBuckets = []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// PrometheusCollector records metrics in a prometheus.Registry for scraping,
+// for running the pipeline outside Lambda. Milliseconds become histograms,
+// counts become counters and every other unit becomes a gauge. Dimensions
+// become labels, so a metric must always be emitted with the same dimension
+// names. It is safe for concurrent use.
+type PrometheusCollector struct {
+	registry    *prometheus.Registry
+	namespace   string
+	constLabels prometheus.Labels
+
+	mu      sync.Mutex
+	metrics map[string]*promMetric
+}
+
+// promMetric is a registered vector and the label names it was created with
+type promMetric struct {
+	labels    []string
+	histogram *prometheus.HistogramVec
+	counter   *prometheus.CounterVec
+	gauge     *prometheus.GaugeVec
+}
+
+// NewPrometheusCollector creates a collector with its own registry. The
+// namespace prefixes every metric name, e.g. EventPipeline becomes event_pipeline_.
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		registry:  prometheus.NewRegistry(),
+		namespace: snakeCase(namespace),
+		constLabels: prometheus.Labels{
+			"environment": getEnvironment(),
+			"service":     "event-pipeline",
+			"region":      resolveRegion(""),
+		},
+		metrics: make(map[string]*promMetric),
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format, for
+// mounting at /metrics
+func (p *PrometheusCollector) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// EmitLatency records a latency metric in milliseconds
+func (p *PrometheusCollector) EmitLatency(ctx context.Context, name string, valueMs float64) error {
+	return p.record(name, LatencyMs(valueMs), nil)
+}
+
+// EmitCount records a count metric
+func (p *PrometheusCollector) EmitCount(ctx context.Context, name string, value float64) error {
+	return p.record(name, Count(value), nil)
+}
+
+// EmitBytes records a bytes metric
+func (p *PrometheusCollector) EmitBytes(ctx context.Context, name string, value float64) error {
+	return p.record(name, MetricValue{Value: value, Unit: types.StandardUnitBytes}, nil)
+}
+
+// EmitStatisticSet records a pre-aggregated distribution
+func (p *PrometheusCollector) EmitStatisticSet(ctx context.Context, name string, set StatisticValues, unit types.StandardUnit) error {
+	return p.record(name, Statistics(set, unit), nil)
+}
+
+// EmitBatch records multiple metrics
+func (p *PrometheusCollector) EmitBatch(ctx context.Context, metrics map[string]MetricValue) error {
+	return p.EmitBatchWithDimensions(ctx, metrics, nil)
+}
+
+// EmitBatchWithDimensions records metrics labeled with extraDims
+func (p *PrometheusCollector) EmitBatchWithDimensions(ctx context.Context, metrics map[string]MetricValue, extraDims map[string]string) error {
+	for name, mv := range metrics {
+		if err := p.record(name, mv, extraDims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmitData records datums, each with its own labels
+func (p *PrometheusCollector) EmitData(ctx context.Context, datums []Datum) error {
+	for _, d := range datums {
+		if err := p.record(d.Name, d.Value, d.Dimensions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record applies one metric value. A statistic set has no Prometheus
+// equivalent, so it is split into _sum and _count counters and _min and
+// _max gauges.
+func (p *PrometheusCollector) record(name string, mv MetricValue, dims map[string]string) error {
+	if s := mv.Statistics; s != nil {
+		parts := []struct {
+			suffix string
+			value  MetricValue
+		}{
+			{"Sum", Count(s.Sum)},
+			{"Count", Count(s.SampleCount)},
+			{"Min", MetricValue{Value: s.Minimum, Unit: types.StandardUnitNone}},
+			{"Max", MetricValue{Value: s.Maximum, Unit: types.StandardUnitNone}},
+		}
+		for _, part := range parts {
+			if err := p.record(name+part.suffix, part.value, dims); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	labels := make(prometheus.Labels, len(dims))
+	for k, v := range dims {
+		label := snakeCase(k)
+		if _, dup := p.constLabels[label]; dup {
+			return fmt.Errorf("dimension %s duplicates a default dimension", k)
+		}
+		labels[label] = v
+	}
+
+	m, err := p.metric(name, mv.Unit, labels)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case m.histogram != nil:
+		m.histogram.With(labels).Observe(mv.Value)
+	case m.counter != nil:
+		// Counters only go up; a negative count has no meaning here
+		if mv.Value > 0 {
+			m.counter.With(labels).Add(mv.Value)
+		}
+	default:
+		m.gauge.With(labels).Set(mv.Value)
+	}
+	return nil
+}
+
+// metric returns the vector for name, registering it on first use
+func (p *PrometheusCollector) metric(name string, unit types.StandardUnit, labels prometheus.Labels) (*promMetric, error) {
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m, ok := p.metrics[name]; ok {
+		if strings.Join(m.labels, ",") != strings.Join(names, ",") {
+			return nil, fmt.Errorf("metric %s emitted with dimensions %v, previously %v", name, names, m.labels)
+		}
+		return m, nil
+	}
+
+	m := &promMetric{labels: names}
+	fullName := p.namespace + "_" + snakeCase(name)
+	help := name + " (event-pipeline)"
+
+	var collector prometheus.Collector
+	switch unit {
+	case types.StandardUnitMilliseconds:
+		m.histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: fullName, Help: help, ConstLabels: p.constLabels, Buckets: latencyBuckets,
+		}, names)
+		collector = m.histogram
+	case types.StandardUnitCount:
+		m.counter = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: fullName + "_total", Help: help, ConstLabels: p.constLabels,
+		}, names)
+		collector = m.counter
+	default:
+		m.gauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: fullName, Help: help, ConstLabels: p.constLabels,
+		}, names)
+		collector = m.gauge
+	}
+
+	if err := p.registry.Register(collector); err != nil {
+		return nil, fmt.Errorf("failed to register metric %s: %w", name, err)
+	}
+	p.metrics[name] = m
+	return m, nil
+}
+
+// snakeCase converts a CloudWatch-style name such as WorkerHttpErrorRate or
+// EventPipeline/DryRun into a Prometheus name (worker_http_error_rate)
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && !strings.HasSuffix(b.String(), "_") {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,98 @@
+// internal/metrics/retry.go
+package metrics
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultBaseDelay   = 100 * time.Millisecond
+	maxBackoffDelay    = 5 * time.Second
+	defaultCallTimeout = 2 * time.Second
+)
+
+// putWithRetry calls PutMetricData, retrying throttling and 5xx errors
+// with exponential backoff and full jitter
+func (c *CloudWatchCollector) putWithRetry(ctx context.Context, input *cloudwatch.PutMetricDataInput) error {
+	// A hung CloudWatch call shouldn't eat the rest of the Lambda's time
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+
+	var err error
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleepWithContext(ctx, c.backoff(attempt)); waitErr != nil {
+				return errors.Join(err, waitErr)
+			}
+		}
+
+		_, err = c.client.PutMetricData(ctx, input)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// backoff returns a random delay in [0, baseDelay * 2^(attempt-1)], capped
+func (c *CloudWatchCollector) backoff(attempt int) time.Duration {
+	if c.baseDelay == 0 {
+		return 0
+	}
+
+	ceiling := c.baseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > maxBackoffDelay {
+		ceiling = maxBackoffDelay
+	}
+	return rand.N(ceiling + 1)
+}
+
+// isRetryable reports whether a PutMetricData error is transient
+func isRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "Throttling", "ThrottlingException", "RequestLimitExceeded", "ServiceUnavailable", "InternalServiceError", "InternalFailure":
+			return true
+		}
+	}
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() >= 500
+	}
+
+	return false
+}
+
+// sleepWithContext waits for d or until ctx is done
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+// internal/metrics/retry_test.go
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// throttled is the error CloudWatch returns when PutMetricData is rate limited
+var throttled = &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}
+
+func TestPutWithRetry(t *testing.T) {
+	invalid := &smithy.GenericAPIError{Code: "InvalidParameterValue", Message: "bad unit"}
+
+	tests := []struct {
+		name      string
+		attempts  int
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", attempts: 3, wantCalls: 1},
+		{name: "throttled then sent", attempts: 3, errs: []error{throttled, throttled}, wantCalls: 3},
+		{name: "gives up after the limit", attempts: 3, errs: []error{throttled, throttled, throttled, throttled}, wantCalls: 3, wantErr: throttled},
+		{name: "retries disabled", attempts: 1, errs: []error{throttled}, wantCalls: 1, wantErr: throttled},
+		{name: "not retryable", attempts: 3, errs: []error{invalid}, wantCalls: 1, wantErr: invalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cw := &fakeCloudWatch{errs: tt.errs}
+			c := newTestCollector(cw, WithMaxAttempts(tt.attempts), WithBaseDelay(0))
+
+			err := c.EmitCount(context.Background(), "WorkerSuccessCount", 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("EmitCount = %v, want %v", err, tt.wantErr)
+			}
+			if len(cw.calls) != tt.wantCalls {
+				t.Errorf("got %d PutMetricData calls, want %d", len(cw.calls), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPutWithRetryStopsWhenCanceled(t *testing.T) {
+	cw := &fakeCloudWatch{errs: []error{throttled, throttled}}
+	c := newTestCollector(cw, WithMaxAttempts(3), WithBaseDelay(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := c.EmitCount(ctx, "WorkerSuccessCount", 1)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, throttled) {
+		t.Errorf("EmitCount = %v, want the throttling error joined with the deadline", err)
+	}
+	if len(cw.calls) != 1 {
+		t.Errorf("got %d PutMetricData calls, want the backoff cut short after one", len(cw.calls))
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	responseErr := func(status int) error {
+		return &awshttp.ResponseError{ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      errors.New("response error"),
+		}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "throttling", err: throttled, want: true},
+		{name: "internal failure", err: &smithy.GenericAPIError{Code: "InternalFailure"}, want: true},
+		{name: "invalid parameter", err: &smithy.GenericAPIError{Code: "InvalidParameterValue"}},
+		{name: "5xx response", err: responseErr(http.StatusBadGateway), want: true},
+		{name: "4xx response", err: responseErr(http.StatusForbidden)},
+		{name: "canceled", err: context.Canceled},
+		{name: "deadline", err: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	c := newTestCollector(&fakeCloudWatch{}, WithBaseDelay(100*time.Millisecond))
+	for attempt, ceiling := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 40: maxBackoffDelay} {
+		for range 50 {
+			if d := c.backoff(attempt); d < 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %v, want within [0, %v]", attempt, d, ceiling)
+			}
+		}
+	}
+	if d := newTestCollector(&fakeCloudWatch{}).backoff(2); d != 0 {
+		t.Errorf("backoff without a base delay = %v, want 0", d)
+	}
+}
@@ -0,0 +1,33 @@
+// internal/metrics/shutdown.go
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds the final flush. Lambda gives functions
+// with an internal extension 500ms between SIGTERM and termination.
+const DefaultShutdownTimeout = 400 * time.Millisecond
+
+// Closer is implemented by collectors that hold metrics in memory and must
+// drain them before the process exits
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// Shutdown closes c if it is a Closer, waiting at most timeout for the
+// final flush. Collectors that send immediately, and a nil c, are left alone.
+//
+// Lambdas call it from lambda.WithEnableSIGTERM, which registers an
+// internal extension so the runtime receives SIGTERM in the shutdown phase.
+func Shutdown(c Collector, timeout time.Duration) error {
+	closer, ok := c.(Closer)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return closer.Close(ctx)
+}
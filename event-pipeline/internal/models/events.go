@@ -1,7 +1,10 @@
 // internal/models/events.go
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // ProcessingJob represents a job queued for processing
 type ProcessingJob struct {
@@ -12,37 +15,193 @@ type ProcessingJob struct {
 	ContentType string    `json:"content_type" dynamodbav:"content_type"`
 	ReceivedAt  time.Time `json:"received_at" dynamodbav:"received_at"`
 	ValidatedAt time.Time `json:"validated_at" dynamodbav:"validated_at"`
+	ETag        string    `json:"etag,omitempty" dynamodbav:"etag,omitempty"` // from HeadObject
+
+	// The object's LastModified from HeadObject, and how long it had existed
+	// when S3 raised the event (see AgeAt)
+	ObjectLastModified time.Time `json:"object_last_modified,omitzero" dynamodbav:"object_last_modified"`
+	ObjectAgeMs        int64     `json:"object_age_ms,omitempty" dynamodbav:"object_age_ms,omitempty"`
+
+	// Priority is PriorityHigh or PriorityLow when the trigger routes jobs
+	// across priority queues, else empty
+	Priority string `json:"priority,omitempty" dynamodbav:"priority,omitempty"`
+
+	// Optional inclusive byte range to fetch instead of the whole object.
+	// ByteRangeEnd of zero means no range is set.
+	ByteRangeStart int64 `json:"byte_range_start,omitempty" dynamodbav:"byte_range_start,omitempty"`
+	ByteRangeEnd   int64 `json:"byte_range_end,omitempty" dynamodbav:"byte_range_end,omitempty"`
+
+	// Optional list of objects in Bucket to aggregate into one result
+	// (manifest mode). When set, Key is only used for logging and byte
+	// ranges are ignored.
+	Keys []string `json:"keys,omitempty" dynamodbav:"keys,omitempty"`
+}
+
+// StagedJob points at a job the trigger stored in S3 as gzipped JSON
+// because it was too large for an SQS message. The message body is then
+// {"staged_job": {...}} instead of the job itself.
+type StagedJob struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+// StagedJobMessage is the body of a message that carries a StagedJob
+type StagedJobMessage struct {
+	StagedJob *StagedJob `json:"staged_job"`
+}
+
+// Job priorities set by the trigger's priority routing
+const (
+	PriorityHigh = "high"
+	PriorityLow  = "low"
+)
+
+// AgeAt returns how long the object had existed at t. Clock skew between S3
+// and t can make the difference negative, which is clamped to zero, as is
+// the age of a job without ObjectLastModified.
+func (j ProcessingJob) AgeAt(t time.Time) time.Duration {
+	if j.ObjectLastModified.IsZero() {
+		return 0
+	}
+	return max(t.Sub(j.ObjectLastModified), 0)
+}
+
+// IsManifest reports whether the job aggregates several objects
+func (j ProcessingJob) IsManifest() bool {
+	return len(j.Keys) > 0
+}
+
+// ObjectKeys returns the keys to process: Keys in manifest mode, else Key
+func (j ProcessingJob) ObjectKeys() []string {
+	if j.IsManifest() {
+		return j.Keys
+	}
+	return []string{j.Key}
 }
 
+// HasRange reports whether only part of the object should be processed
+func (j ProcessingJob) HasRange() bool {
+	return j.ByteRangeEnd > 0 && !j.IsManifest()
+}
+
+// RangeHeader returns the HTTP Range value for the job's byte range
+func (j ProcessingJob) RangeHeader() string {
+	return fmt.Sprintf("bytes=%d-%d", j.ByteRangeStart, j.ByteRangeEnd)
+}
+
+// ResultSchemaVersion is stamped on every stored ProcessingResult. Bump it
+// whenever the result's shape changes materially (a field is renamed,
+// retyped or changes meaning), not for every added field. Results written
+// before versioning read back as 0.
+const ResultSchemaVersion = 1
+
+// Error categories stored on failed and partial results
+const (
+	ErrorCategoryS3Fetch  = "s3_fetch"  // the object could not be read
+	ErrorCategoryParse    = "parse"     // the object was read but not parsed
+	ErrorCategoryDDBWrite = "ddb_write" // the result could not be saved
+	ErrorCategoryTimeout  = "timeout"   // a per-operation deadline expired
+	ErrorCategoryUnknown  = "unknown"
+)
+
 // ProcessingResult represents the outcome of processing a job
 type ProcessingResult struct {
-	JobID            string    `json:"job_id" dynamodbav:"job_id"`
-	Status           string    `json:"status" dynamodbav:"status"` // "completed", "failed"
-	LineCount        int       `json:"line_count,omitempty" dynamodbav:"line_count,omitempty"`
-	ErrorCount       int       `json:"error_count,omitempty" dynamodbav:"error_count,omitempty"`
-	WarnCount        int       `json:"warn_count,omitempty" dynamodbav:"warn_count,omitempty"`
-	InfoCount        int       `json:"info_count,omitempty" dynamodbav:"info_count,omitempty"`
-	AvgResponseTimeMs float64  `json:"avg_response_time_ms,omitempty" dynamodbav:"avg_response_time_ms,omitempty"`
-	MaxResponseTimeMs int      `json:"max_response_time_ms,omitempty" dynamodbav:"max_response_time_ms,omitempty"`
-	UniqueUsers      int       `json:"unique_users,omitempty" dynamodbav:"unique_users,omitempty"`
-	UniqueEndpoints  int       `json:"unique_endpoints,omitempty" dynamodbav:"unique_endpoints,omitempty"`
-	ProcessingTimeMs int64     `json:"processing_time_ms" dynamodbav:"processing_time_ms"`
-	FileSizeBytes    int64     `json:"file_size_bytes" dynamodbav:"file_size_bytes"`
-	StartedAt        time.Time `json:"started_at" dynamodbav:"started_at"`
-	CompletedAt      time.Time `json:"completed_at" dynamodbav:"completed_at"`
-	ErrorMessage     string    `json:"error_message,omitempty" dynamodbav:"error_message,omitempty"`
-	ExpiresAt        int64     `json:"expires_at" dynamodbav:"expires_at"` // TTL
+	JobID                 string            `json:"job_id" dynamodbav:"job_id"`
+	SchemaVersion         int               `json:"schema_version,omitempty" dynamodbav:"schema_version,omitempty"` // ResultSchemaVersion of the writer
+	Status                Status            `json:"status" dynamodbav:"status"`
+	LineCount             int               `json:"line_count,omitempty" dynamodbav:"line_count,omitempty"`
+	ErrorCount            int               `json:"error_count,omitempty" dynamodbav:"error_count,omitempty"`
+	WarnCount             int               `json:"warn_count,omitempty" dynamodbav:"warn_count,omitempty"`
+	InfoCount             int               `json:"info_count,omitempty" dynamodbav:"info_count,omitempty"`
+	DebugCount            int               `json:"debug_count,omitempty" dynamodbav:"debug_count,omitempty"`
+	UnknownLevelCount     int               `json:"unknown_level_count,omitempty" dynamodbav:"unknown_level_count,omitempty"`
+	MalformedLineCount    int               `json:"malformed_line_count,omitempty" dynamodbav:"malformed_line_count,omitempty"`
+	InvalidEntryCount     int               `json:"invalid_entry_count,omitempty" dynamodbav:"invalid_entry_count,omitempty"`
+	DuplicateLineCount    int               `json:"duplicate_line_count,omitempty" dynamodbav:"duplicate_line_count,omitempty"`
+	OversizedLineCount    int               `json:"oversized_line_count,omitempty" dynamodbav:"oversized_line_count,omitempty"`
+	UnknownSchemaCount    int               `json:"unknown_schema_count,omitempty" dynamodbav:"unknown_schema_count,omitempty"`
+	BadResponseTimeCount  int               `json:"bad_response_time_count,omitempty" dynamodbav:"bad_response_time_count,omitempty"`
+	AvgResponseTimeMs     float64           `json:"avg_response_time_ms,omitempty" dynamodbav:"avg_response_time_ms,omitempty"`
+	TotalResponseTimeMs   int64             `json:"total_response_time_ms,omitempty" dynamodbav:"total_response_time_ms,omitempty"` // sum behind AvgResponseTimeMs
+	AggregatedLineCount   int               `json:"aggregated_line_count,omitempty" dynamodbav:"aggregated_line_count,omitempty"`   // entries behind AvgResponseTimeMs
+	MinResponseTimeMs     int               `json:"min_response_time_ms,omitempty" dynamodbav:"min_response_time_ms,omitempty"`
+	MaxResponseTimeMs     int               `json:"max_response_time_ms,omitempty" dynamodbav:"max_response_time_ms,omitempty"`
+	P50ResponseTimeMs     int               `json:"p50_response_time_ms,omitempty" dynamodbav:"p50_response_time_ms,omitempty"`
+	P90ResponseTimeMs     int               `json:"p90_response_time_ms,omitempty" dynamodbav:"p90_response_time_ms,omitempty"`
+	P95ResponseTimeMs     int               `json:"p95_response_time_ms,omitempty" dynamodbav:"p95_response_time_ms,omitempty"`
+	P99ResponseTimeMs     int               `json:"p99_response_time_ms,omitempty" dynamodbav:"p99_response_time_ms,omitempty"`
+	StdDevResponseTimeMs  float64           `json:"stddev_response_time_ms,omitempty" dynamodbav:"stddev_response_time_ms,omitempty"`
+	AnomalousRequestCount int               `json:"anomalous_request_count,omitempty" dynamodbav:"anomalous_request_count,omitempty"`
+	SampledOutDebugCount  int               `json:"sampled_out_debug_count,omitempty" dynamodbav:"sampled_out_debug_count,omitempty"`
+	TotalBytesSent        int64             `json:"total_bytes_sent,omitempty" dynamodbav:"total_bytes_sent,omitempty"`
+	AvgBytesSent          float64           `json:"avg_bytes_sent,omitempty" dynamodbav:"avg_bytes_sent,omitempty"`
+	HTTPErrorRate         float64           `json:"http_error_rate,omitempty" dynamodbav:"http_error_rate,omitempty"`
+	HTTP4xxRate           float64           `json:"http_4xx_rate,omitempty" dynamodbav:"http_4xx_rate,omitempty"`
+	HTTP5xxRate           float64           `json:"http_5xx_rate,omitempty" dynamodbav:"http_5xx_rate,omitempty"`
+	Status1xx             int               `json:"status_1xx,omitempty" dynamodbav:"status_1xx,omitempty"`
+	Status2xx             int               `json:"status_2xx,omitempty" dynamodbav:"status_2xx,omitempty"`
+	Status3xx             int               `json:"status_3xx,omitempty" dynamodbav:"status_3xx,omitempty"`
+	Status4xx             int               `json:"status_4xx,omitempty" dynamodbav:"status_4xx,omitempty"`
+	Status5xx             int               `json:"status_5xx,omitempty" dynamodbav:"status_5xx,omitempty"`
+	StatusOther           int               `json:"status_other,omitempty" dynamodbav:"status_other,omitempty"` // codes outside 100-599 or missing
+	UniqueUsers           int               `json:"unique_users,omitempty" dynamodbav:"unique_users,omitempty"`
+	UniqueEndpoints       int               `json:"unique_endpoints,omitempty" dynamodbav:"unique_endpoints,omitempty"`
+	EarliestTimestamp     *time.Time        `json:"earliest_timestamp,omitempty" dynamodbav:"earliest_timestamp,omitempty"`
+	LatestTimestamp       *time.Time        `json:"latest_timestamp,omitempty" dynamodbav:"latest_timestamp,omitempty"`
+	MalformedTimestamps   int               `json:"malformed_timestamps,omitempty" dynamodbav:"malformed_timestamps,omitempty"`
+	TopEndpoints          []EndpointSummary `json:"top_endpoints,omitempty" dynamodbav:"top_endpoints,omitempty"`
+	SlowestEndpoints      []EndpointSummary `json:"slowest_endpoints,omitempty" dynamodbav:"slowest_endpoints,omitempty"`
+	TopUsers              []UserSummary     `json:"top_users,omitempty" dynamodbav:"top_users,omitempty"`
+	RequestsPerMinute     []MinuteCount     `json:"requests_per_minute,omitempty" dynamodbav:"requests_per_minute,omitempty"`
+	SlowestRequests       []LogEntry        `json:"slowest_requests,omitempty" dynamodbav:"slowest_requests,omitempty"`
+	SampleErrors          []string          `json:"sample_errors,omitempty" dynamodbav:"sample_errors,omitempty"`
+	PeakErrorsPerWindow   int               `json:"peak_errors_per_window,omitempty" dynamodbav:"peak_errors_per_window,omitempty"`
+	ResponseTimeBuckets   map[string]int    `json:"response_time_buckets,omitempty" dynamodbav:"response_time_buckets,omitempty"`
+	ProcessingTimeMs      int64             `json:"processing_time_ms" dynamodbav:"processing_time_ms"`
+	LinesPerSecond        float64           `json:"lines_per_second,omitempty" dynamodbav:"lines_per_second,omitempty"`
+	BytesPerSecond        float64           `json:"bytes_per_second,omitempty" dynamodbav:"bytes_per_second,omitempty"`
+	FileSizeBytes         int64             `json:"file_size_bytes" dynamodbav:"file_size_bytes"`
+	MaxLineBytes          int               `json:"max_line_bytes,omitempty" dynamodbav:"max_line_bytes,omitempty"`
+	SourceETag            string            `json:"source_etag,omitempty" dynamodbav:"source_etag,omitempty"`   // ETag of the object that was parsed
+	LevelFilter           string            `json:"level_filter,omitempty" dynamodbav:"level_filter,omitempty"` // levels kept by S3 Select, if used
+	ContentHash           string            `json:"content_hash,omitempty" dynamodbav:"content_hash,omitempty"` // SHA-256 of the bytes parsed, if enabled
+	StartedAt             time.Time         `json:"started_at" dynamodbav:"started_at"`
+	CompletedAt           time.Time         `json:"completed_at" dynamodbav:"completed_at"`
+	ErrorMessage          string            `json:"error_message,omitempty" dynamodbav:"error_message,omitempty"`
+	ErrorCategory         string            `json:"error_category,omitempty" dynamodbav:"error_category,omitempty"` // one of the ErrorCategory constants
+	RetryCount            int               `json:"retry_count,omitempty" dynamodbav:"retry_count,omitempty"`
+	Terminal              bool              `json:"terminal,omitempty" dynamodbav:"terminal,omitempty"`     // no more SQS retries
+	SourceJob             *ProcessingJob    `json:"source_job,omitempty" dynamodbav:"source_job,omitempty"` // set on failures so they can be replayed
+	ExpiresAt             int64             `json:"expires_at" dynamodbav:"expires_at"`                     // TTL
+}
+
+// SetTiming records how long processing took and the throughput derived
+// from it. LineCount and FileSizeBytes must already be set.
+func (r *ProcessingResult) SetTiming(elapsed time.Duration) {
+	r.ProcessingTimeMs = elapsed.Milliseconds()
+	r.LinesPerSecond = perSecond(float64(r.LineCount), elapsed)
+	r.BytesPerSecond = perSecond(float64(r.FileSizeBytes), elapsed)
+}
+
+// perSecond converts a total over elapsed into a rate, or 0 when no
+// measurable time passed
+func perSecond(total float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return total / elapsed.Seconds()
 }
 
 // LogEntry represents a single log line from the input file
 type LogEntry struct {
-	Timestamp      string `json:"timestamp"`
-	Level          string `json:"level"`
-	Endpoint       string `json:"endpoint"`
-	ResponseTimeMs int    `json:"response_time_ms"`
-	StatusCode     int    `json:"status_code"`
-	UserID         string `json:"user_id"`
-	Message        string `json:"message,omitempty"`
+	Timestamp      string `json:"timestamp" dynamodbav:"timestamp,omitempty"`
+	Level          string `json:"level" dynamodbav:"level,omitempty"`
+	Endpoint       string `json:"endpoint" dynamodbav:"endpoint,omitempty"`
+	ResponseTimeMs int    `json:"response_time_ms" dynamodbav:"response_time_ms"`
+	StatusCode     int    `json:"status_code" dynamodbav:"status_code,omitempty"`
+	UserID         string `json:"user_id" dynamodbav:"user_id,omitempty"`
+	BytesSent      int    `json:"bytes_sent,omitempty" dynamodbav:"bytes_sent,omitempty"`
+	Message        string `json:"message,omitempty" dynamodbav:"message,omitempty"`
 }
 
 // LogAggregation holds aggregated statistics from log processing
@@ -54,10 +213,81 @@ type LogAggregation struct {
 	InfoCount        int
 	DebugCount       int
 	TotalResponseMs  int64
+	TotalBytesSent   int64 // entries without bytes_sent add 0
+	MinResponseMs    int   // 0 until the first entry is processed
 	MaxResponseMs    int
 	UniqueUsers      map[string]struct{}
 	UniqueEndpoints  map[string]struct{}
 	StatusCodeCounts map[int]int
+	EndpointStats    map[string]*EndpointStat
+
+	// Entries per status class; StatusOther holds codes outside 100-599,
+	// including entries without a status code
+	Status1xx   int
+	Status2xx   int
+	Status3xx   int
+	Status4xx   int
+	Status5xx   int
+	StatusOther int
+
+	// Requests per user, only filled when ParserConfig.MaxTrackedUsers is set
+	UserStats map[string]int
+
+	// Requests per Unix minute (seconds / 60) of their timestamp, only
+	// filled when ParserConfig.MaxTimeSeriesMinutes is set
+	RequestsPerMinute map[int64]int
+
+	// Distinct ERROR messages in order of first appearance, only filled
+	// when ParserConfig.MaxSampleErrors is set
+	SampleErrors []string
+
+	// Most ERROR entries within any ParserConfig.ErrorWindow, only set
+	// when the window is configured
+	PeakErrorsPerWindow int
+
+	// Entry counts per response-time histogram bucket, keyed by label
+	ResponseTimeBuckets map[string]int
+
+	// Lines that could not be decoded into a LogEntry
+	MalformedLineCount int
+
+	// Decoded entries skipped for missing a required field
+	InvalidEntryCount int
+
+	// Lines identical to one within the duplicate window (still aggregated)
+	DuplicateLineCount int
+
+	// Lines longer than ParserConfig.MaxLineBytes, skipped unparsed
+	OversizedLineCount int
+
+	// Parsing stopped at ParserConfig.MaxLines with input left unread
+	Truncated bool
+
+	// Longest line (or JSON array element) parsed, in bytes; oversized
+	// lines are skipped unmeasured
+	MaxLineBytes int
+
+	// JSON lines whose schema discriminator isn't registered, skipped
+	UnknownSchemaCount int
+
+	// Entries whose response time was negative or implausibly long, skipped
+	BadResponseTimeCount int
+
+	// Entries whose response time exceeded the anomaly z-score
+	AnomalousRequestCount int
+
+	// DEBUG entries counted by level but left out of every other statistic
+	// (see ParserConfig.DebugSampleRate)
+	SampledOutDebugCount int
+
+	// Entries whose level isn't ERROR/WARN/INFO/DEBUG (e.g. FATAL, TRACE, empty)
+	UnknownLevelCount int
+	LevelCounts       map[string]int
+
+	// Time window covered by parseable timestamps (zero if none parsed)
+	EarliestTimestamp       time.Time
+	LatestTimestamp         time.Time
+	MalformedTimestampCount int
 }
 
 // NewLogAggregation creates an initialized LogAggregation
@@ -66,5 +296,72 @@ func NewLogAggregation() *LogAggregation {
 		UniqueUsers:      make(map[string]struct{}),
 		UniqueEndpoints:  make(map[string]struct{}),
 		StatusCodeCounts: make(map[int]int),
+		LevelCounts:      make(map[string]int),
+		EndpointStats:    make(map[string]*EndpointStat),
+		UserStats:        make(map[string]int),
+
+		RequestsPerMinute: make(map[int64]int),
+
+		ResponseTimeBuckets: make(map[string]int),
+	}
+}
+
+// AggregatedLines returns how many processed entries contributed to
+// response-time, user and endpoint statistics, i.e. ProcessedLines minus
+// sampled-out DEBUG entries. Averages divide by this.
+func (a *LogAggregation) AggregatedLines() int {
+	return a.ProcessedLines - a.SampledOutDebugCount
+}
+
+// EndpointStat accumulates request statistics for a single endpoint
+type EndpointStat struct {
+	RequestCount    int
+	TotalResponseMs int64
+	MaxResponseMs   int
+	ErrorCount      int
+}
+
+// Summary converts the running totals into an EndpointSummary
+func (s *EndpointStat) Summary(endpoint string) EndpointSummary {
+	summary := EndpointSummary{
+		Endpoint:          endpoint,
+		RequestCount:      s.RequestCount,
+		MaxResponseTimeMs: s.MaxResponseMs,
+		ErrorCount:        s.ErrorCount,
 	}
-}
\ No newline at end of file
+	if s.RequestCount > 0 {
+		summary.AvgResponseTimeMs = float64(s.TotalResponseMs) / float64(s.RequestCount)
+	}
+	return summary
+}
+
+// EndpointSummary is the persisted per-endpoint view stored on ProcessingResult
+type EndpointSummary struct {
+	Endpoint          string  `json:"endpoint" dynamodbav:"endpoint"`
+	RequestCount      int     `json:"request_count" dynamodbav:"request_count"`
+	AvgResponseTimeMs float64 `json:"avg_response_time_ms" dynamodbav:"avg_response_time_ms"`
+	MaxResponseTimeMs int     `json:"max_response_time_ms" dynamodbav:"max_response_time_ms"`
+	ErrorCount        int     `json:"error_count" dynamodbav:"error_count"`
+}
+
+// UserSummary is a user's request count, persisted on ProcessingResult
+type UserSummary struct {
+	UserID       string `json:"user_id" dynamodbav:"user_id"`
+	RequestCount int    `json:"request_count" dynamodbav:"request_count"`
+}
+
+// MinuteCount is the number of requests in one minute, persisted on
+// ProcessingResult. Minute is the Unix time in minutes (seconds / 60).
+type MinuteCount struct {
+	Minute int64 `json:"minute" dynamodbav:"minute"`
+	Count  int   `json:"count" dynamodbav:"count"`
+}
+
+// HistogramBucket is one response-time bucket. UpperMs is 0 for the final,
+// open-ended bucket.
+type HistogramBucket struct {
+	Label   string `json:"label"`
+	LowerMs int    `json:"lower_ms"`
+	UpperMs int    `json:"upper_ms,omitempty"`
+	Count   int    `json:"count"`
+}
@@ -0,0 +1,106 @@
+// internal/models/merge.go
+package models
+
+import "slices"
+
+// Merge folds other into a, as if both inputs had been parsed together.
+// Sums are kept rather than averages, so GetAverageResponseTime-style
+// values can be recomputed from TotalResponseMs / AggregatedLines.
+//
+// Percentiles, slowest requests, the response-time standard deviation and
+// HyperLogLog estimates live on the LogParser, not the aggregation, and are
+// not merged here.
+func (a *LogAggregation) Merge(other *LogAggregation) {
+	if other == nil {
+		return
+	}
+
+	// MinResponseMs is 0 until an entry is processed, so only compare real minimums
+	if other.AggregatedLines() > 0 && (a.AggregatedLines() == 0 || other.MinResponseMs < a.MinResponseMs) {
+		a.MinResponseMs = other.MinResponseMs
+	}
+	if other.MaxResponseMs > a.MaxResponseMs {
+		a.MaxResponseMs = other.MaxResponseMs
+	}
+	a.MaxLineBytes = max(a.MaxLineBytes, other.MaxLineBytes)
+	a.PeakErrorsPerWindow = max(a.PeakErrorsPerWindow, other.PeakErrorsPerWindow) // bursts spanning both inputs are missed
+	a.Truncated = a.Truncated || other.Truncated
+
+	a.TotalLines += other.TotalLines
+	a.ProcessedLines += other.ProcessedLines
+	a.ErrorCount += other.ErrorCount
+	a.WarnCount += other.WarnCount
+	a.InfoCount += other.InfoCount
+	a.DebugCount += other.DebugCount
+	a.TotalResponseMs += other.TotalResponseMs
+	a.TotalBytesSent += other.TotalBytesSent
+	a.MalformedLineCount += other.MalformedLineCount
+	a.InvalidEntryCount += other.InvalidEntryCount
+	a.DuplicateLineCount += other.DuplicateLineCount
+	a.OversizedLineCount += other.OversizedLineCount
+	a.UnknownSchemaCount += other.UnknownSchemaCount
+	a.BadResponseTimeCount += other.BadResponseTimeCount
+	a.AnomalousRequestCount += other.AnomalousRequestCount
+	a.SampledOutDebugCount += other.SampledOutDebugCount
+	a.UnknownLevelCount += other.UnknownLevelCount
+	a.Status1xx += other.Status1xx
+	a.Status2xx += other.Status2xx
+	a.Status3xx += other.Status3xx
+	a.Status4xx += other.Status4xx
+	a.Status5xx += other.Status5xx
+	a.StatusOther += other.StatusOther
+	a.MalformedTimestampCount += other.MalformedTimestampCount
+
+	for user := range other.UniqueUsers {
+		a.UniqueUsers[user] = struct{}{}
+	}
+	for endpoint := range other.UniqueEndpoints {
+		a.UniqueEndpoints[endpoint] = struct{}{}
+	}
+	// The merged map may exceed MaxTrackedUsers; the cap is per parse
+	for user, count := range other.UserStats {
+		a.UserStats[user] += count
+	}
+	// Like UserStats, the merged series may exceed the per-parse cap
+	for minute, count := range other.RequestsPerMinute {
+		a.RequestsPerMinute[minute] += count
+	}
+	// Like UserStats, the merged samples may exceed the per-parse cap
+	for _, message := range other.SampleErrors {
+		if !slices.Contains(a.SampleErrors, message) {
+			a.SampleErrors = append(a.SampleErrors, message)
+		}
+	}
+	for code, count := range other.StatusCodeCounts {
+		a.StatusCodeCounts[code] += count
+	}
+	for level, count := range other.LevelCounts {
+		a.LevelCounts[level] += count
+	}
+	// Assumes both sides used the same bucket boundaries
+	for bucket, count := range other.ResponseTimeBuckets {
+		a.ResponseTimeBuckets[bucket] += count
+	}
+
+	for endpoint, stat := range other.EndpointStats {
+		mine, ok := a.EndpointStats[endpoint]
+		if !ok {
+			copied := *stat
+			a.EndpointStats[endpoint] = &copied
+			continue
+		}
+		mine.RequestCount += stat.RequestCount
+		mine.TotalResponseMs += stat.TotalResponseMs
+		mine.ErrorCount += stat.ErrorCount
+		if stat.MaxResponseMs > mine.MaxResponseMs {
+			mine.MaxResponseMs = stat.MaxResponseMs
+		}
+	}
+
+	if !other.EarliestTimestamp.IsZero() && (a.EarliestTimestamp.IsZero() || other.EarliestTimestamp.Before(a.EarliestTimestamp)) {
+		a.EarliestTimestamp = other.EarliestTimestamp
+	}
+	if other.LatestTimestamp.After(a.LatestTimestamp) {
+		a.LatestTimestamp = other.LatestTimestamp
+	}
+}
@@ -0,0 +1,42 @@
+// internal/models/status.go
+package models
+
+import "fmt"
+
+// Status is the outcome stored on a ProcessingResult
+type Status string
+
+// Result statuses stored on ProcessingResult
+const (
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+
+	// StatusPartial marks a failed job whose counts up to the failure point
+	// were kept (see SAVE_PARTIAL_RESULTS in the worker)
+	StatusPartial Status = "partial"
+
+	// StatusTruncated marks a job whose parse stopped at the worker's line
+	// limit (MAX_LINES); its counts cover only the lines before it
+	StatusTruncated Status = "truncated"
+)
+
+// Valid reports whether s is one of the Status constants
+func (s Status) Valid() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusPartial, StatusTruncated:
+		return true
+	}
+	return false
+}
+
+// UnmarshalText rejects unknown statuses, so JSON results with a status
+// this version doesn't understand fail to decode instead of being misread.
+// The result store applies the same check to DynamoDB items.
+func (s *Status) UnmarshalText(text []byte) error {
+	status := Status(text)
+	if !status.Valid() {
+		return fmt.Errorf("unknown result status %q", text)
+	}
+	*s = status
+	return nil
+}
@@ -0,0 +1,56 @@
+// internal/models/status_test.go
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+)
+
+var allStatuses = []Status{StatusCompleted, StatusFailed, StatusPartial, StatusTruncated}
+
+func TestStatusRoundTrip(t *testing.T) {
+	for _, status := range allStatuses {
+		t.Run(string(status), func(t *testing.T) {
+			if !status.Valid() {
+				t.Fatalf("%q is not valid", status)
+			}
+
+			data, err := json.Marshal(ProcessingResult{JobID: "abc", Status: status})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fromJSON ProcessingResult
+			if err := json.Unmarshal(data, &fromJSON); err != nil || fromJSON.Status != status {
+				t.Errorf("JSON round trip = %q, %v; want %q", fromJSON.Status, err, status)
+			}
+
+			item, err := attributevalue.MarshalMap(ProcessingResult{JobID: "abc", Status: status})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fromItem ProcessingResult
+			if err := attributevalue.UnmarshalMap(item, &fromItem); err != nil || fromItem.Status != status {
+				t.Errorf("DynamoDB round trip = %q, %v; want %q", fromItem.Status, err, status)
+			}
+		})
+	}
+}
+
+func TestInvalidStatus(t *testing.T) {
+	for _, raw := range []string{"", "done", "COMPLETED", "completed "} {
+		if Status(raw).Valid() {
+			t.Errorf("Status(%q).Valid() = true", raw)
+		}
+
+		data, err := json.Marshal(map[string]string{"job_id": "abc", "status": raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result ProcessingResult
+		if err := json.Unmarshal(data, &result); err == nil {
+			t.Errorf("decoding status %q succeeded with %q, want an error", raw, result.Status)
+		}
+	}
+}
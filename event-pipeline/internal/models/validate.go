@@ -0,0 +1,65 @@
+// internal/models/validate.go
+package models
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// AllowedContentTypes are the media types a job's file may have, in
+// normalized form (see NormalizeContentType). "binary/octet-stream" is
+// deliberately absent: it is what S3 assigns when the uploader didn't set a
+// content type. The trigger replaces the list with ALLOWED_CONTENT_TYPES.
+var AllowedContentTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/jsonl",
+	"text/plain",
+}
+
+// ValidationError describes why a ProcessingJob was rejected
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid job: %s %s", e.Field, e.Reason)
+}
+
+// Validate checks that the job describes a file worth processing
+func (j ProcessingJob) Validate() error {
+	if j.Bucket == "" {
+		return &ValidationError{Field: "bucket", Reason: "is empty"}
+	}
+	if j.Key == "" {
+		return &ValidationError{Field: "key", Reason: "is empty"}
+	}
+	if j.JobID == "" {
+		return &ValidationError{Field: "job_id", Reason: "is empty"}
+	}
+	if j.Size <= 0 {
+		return &ValidationError{Field: "size", Reason: fmt.Sprintf("must be positive, got %d", j.Size)}
+	}
+
+	mediaType := NormalizeContentType(j.ContentType)
+	for _, allowed := range AllowedContentTypes {
+		if mediaType == allowed {
+			return nil
+		}
+	}
+	return &ValidationError{Field: "content_type", Reason: fmt.Sprintf("%q is not accepted", j.ContentType)}
+}
+
+// NormalizeContentType reduces a Content-Type header to its lowercased
+// media type, dropping parameters such as charset, so
+// "Application/JSON; charset=utf-8" becomes "application/json"
+func NormalizeContentType(raw string) string {
+	if mediaType, _, err := mime.ParseMediaType(raw); err == nil {
+		return mediaType
+	}
+	// Malformed parameters shouldn't hide an otherwise valid media type
+	mediaType, _, _ := strings.Cut(raw, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
@@ -0,0 +1,80 @@
+// internal/models/validate_test.go
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func validJob() ProcessingJob {
+	return ProcessingJob{
+		JobID:       "abc",
+		Bucket:      "logs",
+		Key:         "logs/test_abc_1.json",
+		Size:        1024,
+		ContentType: "application/json",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		mutate    func(*ProcessingJob)
+		wantField string // empty when the job is valid
+	}{
+		{name: "valid", mutate: func(j *ProcessingJob) {}},
+		{name: "empty bucket", mutate: func(j *ProcessingJob) { j.Bucket = "" }, wantField: "bucket"},
+		{name: "empty key", mutate: func(j *ProcessingJob) { j.Key = "" }, wantField: "key"},
+		{name: "empty job id", mutate: func(j *ProcessingJob) { j.JobID = "" }, wantField: "job_id"},
+		{name: "zero size", mutate: func(j *ProcessingJob) { j.Size = 0 }, wantField: "size"},
+		{name: "negative size", mutate: func(j *ProcessingJob) { j.Size = -1 }, wantField: "size"},
+		{name: "unexpected content type", mutate: func(j *ProcessingJob) { j.ContentType = "image/png" }, wantField: "content_type"},
+		{name: "missing content type", mutate: func(j *ProcessingJob) { j.ContentType = "" }, wantField: "content_type"},
+		{name: "content type with charset", mutate: func(j *ProcessingJob) { j.ContentType = "application/json; charset=utf-8" }},
+		{name: "content type in upper case", mutate: func(j *ProcessingJob) { j.ContentType = "Application/X-NDJSON" }},
+		{name: "S3 default content type", mutate: func(j *ProcessingJob) { j.ContentType = "binary/octet-stream" }, wantField: "content_type"},
+		{name: "octet-stream", mutate: func(j *ProcessingJob) { j.ContentType = "application/octet-stream" }, wantField: "content_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := validJob()
+			tt.mutate(&job)
+
+			err := job.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate = %v, want a *ValidationError", err)
+			}
+			if validationErr.Field != tt.wantField {
+				t.Errorf("rejected field %q, want %q", validationErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestNormalizeContentType(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "application/json", want: "application/json"},
+		{raw: "application/json; charset=utf-8", want: "application/json"},
+		{raw: "Application/JSON; charset=UTF-8", want: "application/json"},
+		{raw: "  text/plain  ", want: "text/plain"},
+		{raw: "application/json; charset", want: "application/json"},
+		{raw: "binary/octet-stream", want: "binary/octet-stream"},
+		{raw: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeContentType(tt.raw); got != tt.want {
+			t.Errorf("NormalizeContentType(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
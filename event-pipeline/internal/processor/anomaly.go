@@ -0,0 +1,66 @@
+// internal/processor/anomaly.go
+package processor
+
+import "math"
+
+const (
+	// DefaultAnomalyZScore is the z-score beyond which a response time is anomalous
+	DefaultAnomalyZScore = 3.0
+
+	// minAnomalySamples is how many response times must be seen before any
+	// are judged, so the first few entries of a file aren't all outliers
+	minAnomalySamples = 30
+)
+
+// runningStats tracks the mean and variance of a stream in a single pass
+// with constant memory (Welford's algorithm)
+type runningStats struct {
+	n    int64
+	mean float64
+	m2   float64 // sum of squared differences from the mean
+}
+
+// add folds x into the statistics
+func (s *runningStats) add(x float64) {
+	s.n++
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+}
+
+// stdDev returns the sample standard deviation, or 0 for fewer than two values
+func (s *runningStats) stdDev() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(s.n-1))
+}
+
+// isAnomaly reports whether x lies more than threshold standard deviations
+// from the mean of the values seen so far. Values are only judged once
+// minAnomalySamples have been seen and they vary at all.
+func (s *runningStats) isAnomaly(x, threshold float64) bool {
+	if s.n < minAnomalySamples {
+		return false
+	}
+	sd := s.stdDev()
+	if sd == 0 {
+		return false
+	}
+	return math.Abs(x-s.mean)/sd > threshold
+}
+
+// trackAnomaly judges a response time against the entries before it, then
+// adds it to the running statistics
+func (p *LogParser) trackAnomaly(ms int) {
+	x := float64(ms)
+	if p.responseStats.isAnomaly(x, p.config.AnomalyZScore) {
+		p.aggregation.AnomalousRequestCount++
+	}
+	p.responseStats.add(x)
+}
+
+// GetResponseTimeStdDev returns the sample standard deviation of response times
+func (p *LogParser) GetResponseTimeStdDev() float64 {
+	return p.responseStats.stdDev()
+}
@@ -0,0 +1,57 @@
+// internal/processor/anomaly_test.go
+package processor
+
+import (
+	"slices"
+	"testing"
+)
+
+// clusterWithOutliers is 99-101ms traffic followed by three extreme outliers
+// and a return to normal
+func clusterWithOutliers() []int {
+	values := make([]int, 0, 105)
+	for i := range 100 {
+		values = append(values, 99+i%3)
+	}
+	return append(values, 0, 5000, 10000, 100, 101)
+}
+
+func TestAnomalousRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		zScore float64
+		values []int
+		want   int
+	}{
+		{
+			name:   "default threshold",
+			values: clusterWithOutliers(),
+			want:   3,
+		},
+		{
+			// The last outlier is only ~20 deviations out once the first
+			// two have widened the spread
+			name:   "high threshold",
+			zScore: 100,
+			values: clusterWithOutliers(),
+			want:   2,
+		},
+		{
+			// Fewer than minAnomalySamples values are never judged
+			name:   "outlier before enough samples",
+			values: append([]int{100, 101, 99, 100, 100000}, clusterWithOutliers()[:25]...),
+		},
+		{
+			name:   "no variation",
+			values: slices.Repeat([]int{100}, 2*minAnomalySamples),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, ParserConfig{AnomalyZScore: tt.zScore}, responseTimes(tt.values...)).Result("job")
+			if result.AnomalousRequestCount != tt.want {
+				t.Errorf("AnomalousRequestCount = %d, want %d", result.AnomalousRequestCount, tt.want)
+			}
+		})
+	}
+}
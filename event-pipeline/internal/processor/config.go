@@ -0,0 +1,206 @@
+// internal/processor/config.go
+package processor
+
+import (
+	"regexp"
+	"time"
+)
+
+const (
+	// DefaultMaxEndpoints caps how many distinct endpoints get individual stats
+	DefaultMaxEndpoints = 1000
+
+	// DefaultMalformedSampleSize is how many leading lines are checked against MaxMalformedRatio
+	DefaultMalformedSampleSize = 100
+
+	// MinMalformedSampleSize is the fewest lines the malformed check will judge,
+	// so a tiny file with one bad line isn't rejected
+	MinMalformedSampleSize = 10
+
+	// DefaultMaxLineBytes is the longest newline-delimited line parsed
+	DefaultMaxLineBytes = 1024 * 1024
+)
+
+// Input formats for ParserConfig.InputFormat
+const (
+	FormatAuto      = ""           // detect: a leading '[' means FormatJSONArray
+	FormatNDJSON    = "ndjson"     // one entry per line (or LinePattern text)
+	FormatJSONArray = "json_array" // a single JSON array of entries
+)
+
+// ParserConfig controls how LogParser interprets log lines.
+// The zero value is valid and uses the defaults below.
+type ParserConfig struct {
+	// TimestampLayout is the time.Parse layout for LogEntry.Timestamp (default RFC3339)
+	TimestampLayout string
+
+	// MaxEndpoints caps the per-endpoint stats map; extra endpoints are
+	// counted under OtherEndpoint (default DefaultMaxEndpoints)
+	MaxEndpoints int
+
+	// LinePattern, when set, parses plain-text lines (Apache-style, logfmt, ...)
+	// using named capture groups instead of JSON. See ValidatePattern.
+	LinePattern *regexp.Regexp
+
+	// ApproximateUniques counts unique users and endpoints with HyperLogLog
+	// sketches (~1-2% error, 16KB each) instead of exact sets. Use it for
+	// files whose cardinality could exhaust Lambda memory.
+	ApproximateUniques bool
+
+	// SkipFirstLine discards the first line without counting it toward
+	// TotalLines. Set it when the input is a byte range that may start
+	// mid-line, since that fragment would otherwise count as malformed.
+	SkipFirstLine bool
+
+	// MaxMalformedRatio aborts parsing with ErrTooManyMalformed when more than
+	// this fraction (0-1) of the first MalformedSampleSize non-empty lines fail
+	// to parse. Zero disables the check.
+	MaxMalformedRatio float64
+
+	// MalformedSampleSize is the number of leading lines checked
+	// (default DefaultMalformedSampleSize, never below MinMalformedSampleSize)
+	MalformedSampleSize int
+
+	// InputFormat forces FormatNDJSON or FormatJSONArray; the default
+	// FormatAuto detects the format from the first non-whitespace byte
+	InputFormat string
+
+	// SlowestSize is how many of the slowest entries GetSlowest can return
+	// (default DefaultSlowestSize)
+	SlowestSize int
+
+	// RequiredFields lists LogEntry fields (by JSON name) that must be set.
+	// Entries missing one are counted as InvalidEntryCount and skipped.
+	RequiredFields []string
+
+	// DuplicateWindow counts a line as a duplicate when an identical line
+	// appeared within this many preceding lines. Zero disables the check.
+	DuplicateWindow int
+
+	// ApproximateDuplicates tracks the window with Bloom filters instead of
+	// exact hashes, trading ~1-2% false positives for ~2.4 bytes per line
+	ApproximateDuplicates bool
+
+	// ResponseTimeBuckets are the ascending histogram upper bounds in ms
+	// (default DefaultResponseTimeBuckets); a final open-ended bucket is added
+	ResponseTimeBuckets []int
+
+	// MaxLines stops parsing once this many lines (or JSON array elements)
+	// have been read across all files, marking the aggregation Truncated,
+	// which bounds the time spent on one job. Zero disables it.
+	MaxLines int
+
+	// MaxLineBytes is the longest newline-delimited line that is parsed;
+	// longer lines are counted as OversizedLineCount and skipped
+	// (default DefaultMaxLineBytes)
+	MaxLineBytes int
+
+	// AnomalyZScore counts a response time as anomalous when it lies more
+	// than this many standard deviations from the running mean
+	// (default DefaultAnomalyZScore)
+	AnomalyZScore float64
+
+	// MaxTrackedUsers enables per-user request counts for TopUsers, keeping
+	// at most this many users; the rest are counted under OtherUser. Zero
+	// disables it, leaving only the unique user count.
+	MaxTrackedUsers int
+
+	// MaxTimeSeriesMinutes enables per-minute request counts for
+	// GetTimeSeries, keeping at most this many distinct minutes; requests
+	// in later new minutes are left out. Zero disables it.
+	MaxTimeSeriesMinutes int
+
+	// MaxSampleErrors keeps up to this many distinct messages of ERROR
+	// entries as SampleErrors, for triage without opening the file. Zero
+	// disables it.
+	MaxSampleErrors int
+
+	// ErrorWindow enables PeakErrorsPerWindow, the most ERROR entries whose
+	// timestamps fall within any window of this length, to tell bursts of
+	// errors from ones spread over the file. Zero disables it.
+	ErrorWindow time.Duration
+
+	// FastJSON decodes flat JSON lines with a hand-written scanner instead
+	// of reflection, falling back to encoding/json for any line it doesn't
+	// handle. Results are identical; it only trades code for speed.
+	FastJSON bool
+
+	// DebugSampleRate, when between 0 and 1, fully aggregates only this
+	// fraction of DEBUG entries. All DEBUG entries are still counted by
+	// level; the rest are left out of response times, uniques, endpoints
+	// and status codes. Zero or 1 aggregates every entry.
+	DebugSampleRate float64
+
+	// SchemaField names the JSON key (e.g. "schema" or "type") whose value
+	// selects the SchemaMapping in Schemas for each line, so producers with
+	// different key names can share a file. JSON lines whose value isn't
+	// registered are counted as UnknownSchemaCount. Empty disables it.
+	SchemaField string
+	Schemas     map[string]SchemaMapping
+
+	// FieldPaths reads LogEntry fields, keyed by their JSON names
+	// (endpoint, status_code, ...), from dotted paths into nested objects,
+	// e.g. "request.endpoint". Fields without a path keep their flat key.
+	// Applied after the schema mapping.
+	FieldPaths map[string]string
+
+	// ResponseTimeField is the JSON key, or LinePattern group, holding the
+	// response time (default "response_time_ms"), and ResponseTimeUnit its
+	// unit: UnitMilliseconds
+	// (the default), UnitMicroseconds or UnitSeconds. Values are converted
+	// to whole milliseconds and may be fractional.
+	ResponseTimeField string
+	ResponseTimeUnit  string
+
+	// Columns names the LogEntry field (by JSON name) held in each column
+	// of a row for the csv parser, in order; "" skips a column. Delimiter
+	// separates the columns (default ','), and HeaderRow skips the first
+	// row of each file. See NewDelimitedParser.
+	Columns   []string
+	Delimiter rune
+	HeaderRow bool
+
+	// MaxResponseTimeMs is the longest plausible response time; entries
+	// above it or below zero are counted as BadResponseTimeCount and
+	// skipped (default DefaultMaxResponseTimeMs)
+	MaxResponseTimeMs int
+}
+
+// withDefaults fills unset fields with their default values
+func (c ParserConfig) withDefaults() ParserConfig {
+	if c.TimestampLayout == "" {
+		c.TimestampLayout = time.RFC3339
+	}
+	if c.MaxEndpoints <= 0 {
+		c.MaxEndpoints = DefaultMaxEndpoints
+	}
+	if c.MalformedSampleSize <= 0 {
+		c.MalformedSampleSize = DefaultMalformedSampleSize
+	}
+	if c.MalformedSampleSize < MinMalformedSampleSize {
+		c.MalformedSampleSize = MinMalformedSampleSize
+	}
+	if c.AnomalyZScore <= 0 {
+		c.AnomalyZScore = DefaultAnomalyZScore
+	}
+	if c.ResponseTimeField == "" {
+		c.ResponseTimeField = responseTimeKey
+	}
+	if c.ResponseTimeUnit == "" {
+		c.ResponseTimeUnit = UnitMilliseconds
+	}
+	if c.MaxResponseTimeMs <= 0 {
+		c.MaxResponseTimeMs = DefaultMaxResponseTimeMs
+	}
+	if c.MaxLineBytes <= 0 {
+		c.MaxLineBytes = DefaultMaxLineBytes
+	}
+	if c.Delimiter == 0 {
+		c.Delimiter = ','
+	}
+	c.ResponseTimeBuckets = normalizeBuckets(c.ResponseTimeBuckets)
+	if len(c.ResponseTimeBuckets) == 0 {
+		c.ResponseTimeBuckets = DefaultResponseTimeBuckets
+	}
+	return c
+}
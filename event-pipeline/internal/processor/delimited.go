@@ -0,0 +1,167 @@
+// internal/processor/delimited.go
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"event-pipeline/internal/models"
+)
+
+// ParseColumns reads a comma-separated column layout for
+// ParserConfig.Columns, e.g. "timestamp,level,,endpoint,response_time_ms".
+// An empty name skips that column. Other names must be LogEntry fields, as
+// in ValidateRequiredFields, and each may be used once.
+func ParseColumns(raw string) ([]string, error) {
+	columns := strings.Split(raw, ",")
+	seen := make(map[string]bool, len(columns))
+	for i, name := range columns {
+		name = strings.TrimSpace(name)
+		columns[i] = name
+		if name == "" {
+			continue
+		}
+		if _, ok := fieldPresent[name]; !ok {
+			return nil, fmt.Errorf("column %d is unknown field %q", i+1, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("field %q is mapped to more than one column", name)
+		}
+		seen[name] = true
+	}
+	if len(seen) == 0 {
+		return nil, errors.New("no column is mapped to a field")
+	}
+	return columns, nil
+}
+
+// ParseDelimiter reads a ParserConfig.Delimiter: "tab" (or "\t") or a
+// single ASCII character other than a quote
+func ParseDelimiter(raw string) (rune, error) {
+	switch raw {
+	case "tab", `\t`, "\t":
+		return '\t', nil
+	}
+	if len(raw) != 1 || raw[0] >= 0x80 || raw[0] == '"' || raw[0] == '\n' || raw[0] == '\r' {
+		return 0, fmt.Errorf("delimiter %q is not a single ASCII character, or tab", raw)
+	}
+	return rune(raw[0]), nil
+}
+
+// NewDelimitedParser creates a LogParser for rows of delimited columns,
+// such as a legacy system's TSV export, instead of JSON. Each row is mapped
+// to a LogEntry by cfg.Columns and aggregated like a JSON line, so limits,
+// duplicates and the malformed check work the same.
+func NewDelimitedParser(cfg ParserConfig) *LogParser {
+	cfg.InputFormat = FormatNDJSON
+	p := NewLogParser(cfg)
+	p.delimited = true
+	return p
+}
+
+// decodeDelimited maps one row's cells into a LogEntry by ParserConfig.Columns.
+// Rows with fewer cells than columns, and numbers that don't parse, fail
+// decoding and count as malformed. Cells past the last column are ignored,
+// and an empty cell leaves its field unset.
+func (p *LogParser) decodeDelimited(line []byte) (models.LogEntry, error) {
+	var entry models.LogEntry
+
+	cells, err := splitRow(line, byte(p.config.Delimiter))
+	if err != nil {
+		return entry, err
+	}
+	if len(cells) < len(p.config.Columns) {
+		return entry, fmt.Errorf("row has %d columns, want %d", len(cells), len(p.config.Columns))
+	}
+
+	for i, name := range p.config.Columns {
+		value := cells[i]
+		switch name {
+		case "timestamp":
+			entry.Timestamp = value
+		case "level":
+			entry.Level = value
+		case "endpoint":
+			entry.Endpoint = value
+		case "user_id":
+			entry.UserID = value
+		case "message":
+			entry.Message = value
+		case responseTimeKey:
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return entry, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			ms, ok := p.toMilliseconds(n)
+			if !ok {
+				return entry, fmt.Errorf("%w: %s %s", errResponseTimeOutOfRange, name, value)
+			}
+			entry.ResponseTimeMs = ms
+		case "status_code", "bytes_sent":
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return entry, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			if name == "status_code" {
+				entry.StatusCode = n
+			} else {
+				entry.BytesSent = n
+			}
+		}
+	}
+	return entry, nil
+}
+
+// splitRow splits one row on delim. A cell starting with a double quote
+// runs to its closing quote, with "" standing for a quote inside it, so
+// quoted cells may contain the delimiter. Rows don't span lines.
+func splitRow(line []byte, delim byte) ([]string, error) {
+	var cells []string
+	for {
+		if len(line) == 0 || line[0] != '"' {
+			end := bytes.IndexByte(line, delim)
+			if end < 0 {
+				return append(cells, string(line)), nil
+			}
+			cells = append(cells, string(line[:end]))
+			line = line[end+1:]
+			continue
+		}
+
+		var cell strings.Builder
+		i := 1
+		for {
+			j := bytes.IndexByte(line[i:], '"')
+			if j < 0 {
+				return nil, errors.New("unterminated quoted cell")
+			}
+			cell.Write(line[i : i+j])
+			i += j + 1
+			if i < len(line) && line[i] == '"' {
+				cell.WriteByte('"')
+				i++
+				continue
+			}
+			break
+		}
+		cells = append(cells, cell.String())
+
+		line = line[i:]
+		if len(line) == 0 {
+			return cells, nil
+		}
+		if line[0] != delim {
+			return nil, errors.New("text after closing quote")
+		}
+		line = line[1:]
+	}
+}
@@ -0,0 +1,218 @@
+// internal/processor/delimited_test.go
+package processor
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "timestamp,level,endpoint,response_time_ms", want: []string{"timestamp", "level", "endpoint", "response_time_ms"}},
+		{raw: " level , , endpoint ", want: []string{"level", "", "endpoint"}},
+		{raw: "level,route", wantErr: true},
+		{raw: "level,endpoint,level", wantErr: true},
+		{raw: ",,", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseColumns(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseColumns(%q) = %q, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("ParseColumns(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    rune
+		wantErr bool
+	}{
+		{raw: "tab", want: '\t'},
+		{raw: `\t`, want: '\t'},
+		{raw: "\t", want: '\t'},
+		{raw: ",", want: ','},
+		{raw: "|", want: '|'},
+		{raw: ";", want: ';'},
+		{raw: "", wantErr: true},
+		{raw: "||", wantErr: true},
+		{raw: `"`, wantErr: true},
+		{raw: "\n", wantErr: true},
+		{raw: "§", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseDelimiter(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseDelimiter(%q) = %q, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseDelimiter(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		delim   byte
+		want    []string
+		wantErr bool
+	}{
+		{name: "plain", line: "a,b,c", delim: ',', want: []string{"a", "b", "c"}},
+		{name: "empty cells", line: ",b,", delim: ',', want: []string{"", "b", ""}},
+		{name: "tab", line: "a\tb,c\td", delim: '\t', want: []string{"a", "b,c", "d"}},
+		{name: "quoted delimiter", line: `a,"b,c",d`, delim: ',', want: []string{"a", "b,c", "d"}},
+		{name: "escaped quote", line: `"say ""hi""",b`, delim: ',', want: []string{`say "hi"`, "b"}},
+		{name: "quoted last cell", line: `a,"b,c"`, delim: ',', want: []string{"a", "b,c"}},
+		{name: "empty quoted cell", line: `"",b`, delim: ',', want: []string{"", "b"}},
+		{name: "quote mid-cell", line: `a"b,c`, delim: ',', want: []string{`a"b`, "c"}},
+		{name: "unterminated quote", line: `a,"b,c`, delim: ',', wantErr: true},
+		{name: "text after quote", line: `"b"c,d`, delim: ',', wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitRow([]byte(tt.line), tt.delim)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("splitRow(%q) = %q, want error", tt.line, got)
+				}
+				return
+			}
+			if err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("splitRow(%q) = %q, %v; want %q", tt.line, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// parseRows parses input with the delimited parser
+func parseRows(t *testing.T, cfg ParserConfig, input string) *LogParser {
+	t.Helper()
+	p := NewDelimitedParser(cfg)
+	if _, err := p.Parse(strings.NewReader(input)); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return p
+}
+
+func TestDelimitedParser(t *testing.T) {
+	columns := []string{"timestamp", "level", "", "endpoint", "response_time_ms", "status_code"}
+
+	tests := []struct {
+		name          string
+		cfg           ParserConfig
+		input         string
+		wantLines     int
+		wantMalformed int
+		wantErrors    int
+		wantMaxMs     int
+	}{
+		{
+			name:      "csv",
+			cfg:       ParserConfig{Columns: columns},
+			input:     "2024-01-15T10:00:00Z,INFO,host-1,/a,120,200\n2024-01-15T10:00:01Z,ERROR,host-1,/b,450,500\n",
+			wantLines: 2, wantErrors: 1, wantMaxMs: 450,
+		},
+		{
+			name:      "header row",
+			cfg:       ParserConfig{Columns: columns, HeaderRow: true},
+			input:     "timestamp,level,host,endpoint,ms,status\n2024-01-15T10:00:00Z,INFO,host-1,/a,120,200\n",
+			wantLines: 1, wantMaxMs: 120,
+		},
+		{
+			// Without HeaderRow the header is just a malformed row
+			name:      "header not skipped",
+			cfg:       ParserConfig{Columns: columns},
+			input:     "timestamp,level,host,endpoint,ms,status\n2024-01-15T10:00:00Z,INFO,host-1,/a,120,200\n",
+			wantLines: 2, wantMalformed: 1, wantMaxMs: 120,
+		},
+		{
+			name:      "tsv",
+			cfg:       ParserConfig{Columns: columns, Delimiter: '\t'},
+			input:     "2024-01-15T10:00:00Z\tINFO\thost-1\t/a,b\t120\t200\n",
+			wantLines: 1, wantMaxMs: 120,
+		},
+		{
+			name:      "quoted delimiter",
+			cfg:       ParserConfig{Columns: columns},
+			input:     `2024-01-15T10:00:00Z,INFO,"host,1","/search?q=a,b",300,200` + "\n",
+			wantLines: 1, wantMaxMs: 300,
+		},
+		{
+			// Missing cells, a bad number and an unterminated quote are
+			// malformed, but don't stop the rows around them
+			name: "malformed rows",
+			cfg:  ParserConfig{Columns: columns},
+			input: "2024-01-15T10:00:00Z,INFO,host-1,/a\n" +
+				"2024-01-15T10:00:00Z,INFO,host-1,/a,fast,200\n" +
+				"2024-01-15T10:00:00Z,INFO,host-1,/a,120,OK\n" +
+				`2024-01-15T10:00:00Z,INFO,"host-1,/a,120,200` + "\n" +
+				"2024-01-15T10:00:00Z,ERROR,host-1,/a,80,500\n",
+			wantLines: 5, wantMalformed: 4, wantErrors: 1, wantMaxMs: 80,
+		},
+		{
+			// Cells past the last column are ignored
+			name:      "extra cells",
+			cfg:       ParserConfig{Columns: columns},
+			input:     "2024-01-15T10:00:00Z,INFO,host-1,/a,120,200,extra,cells\n",
+			wantLines: 1, wantMaxMs: 120,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseRows(t, tt.cfg, tt.input).Result("job")
+			if result.LineCount != tt.wantLines || result.MalformedLineCount != tt.wantMalformed {
+				t.Errorf("lines %d malformed %d, want %d and %d", result.LineCount, result.MalformedLineCount, tt.wantLines, tt.wantMalformed)
+			}
+			if result.ErrorCount != tt.wantErrors || result.MaxResponseTimeMs != tt.wantMaxMs {
+				t.Errorf("errors %d max %dms, want %d and %dms", result.ErrorCount, result.MaxResponseTimeMs, tt.wantErrors, tt.wantMaxMs)
+			}
+		})
+	}
+}
+
+func TestDecodeDelimited(t *testing.T) {
+	p := NewDelimitedParser(ParserConfig{
+		Columns:   []string{"status_code", "", "user_id", "endpoint", "bytes_sent", "message", "level", "response_time_ms"},
+		Delimiter: '|',
+	})
+
+	got, err := p.decodeDelimited([]byte(`404|ignored|u1|/missing||"not found | gone"|WARN| 12.6 `))
+	if err != nil {
+		t.Fatalf("decodeDelimited: %v", err)
+	}
+	// Columns map in any order, and an empty cell leaves its field unset
+	want := models.LogEntry{
+		Level:          "WARN",
+		Endpoint:       "/missing",
+		ResponseTimeMs: 13,
+		StatusCode:     404,
+		UserID:         "u1",
+		Message:        "not found | gone",
+	}
+	if got != want {
+		t.Errorf("decoded %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+// internal/processor/duplicates.go
+package processor
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// bloomFalsePositiveRate is the target false-positive rate per filter
+const bloomFalsePositiveRate = 0.01
+
+// duplicateDetector reports whether a line was already seen among the most
+// recent lines
+type duplicateDetector interface {
+	seen(line []byte) bool
+}
+
+// newDuplicateDetector returns nil when duplicate tracking is disabled
+func newDuplicateDetector(cfg ParserConfig) duplicateDetector {
+	if cfg.DuplicateWindow <= 0 {
+		return nil
+	}
+	if cfg.ApproximateDuplicates {
+		return newBloomWindow(cfg.DuplicateWindow)
+	}
+	return newExactWindow(cfg.DuplicateWindow)
+}
+
+// hashLine returns a well-mixed 64-bit hash of line
+func hashLine(line []byte) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write(line)
+	return mix64(hasher.Sum64())
+}
+
+// exactWindow remembers the hashes of exactly the last size lines. With
+// 64-bit hashes a false match is vanishingly unlikely (~size/2^64 per line).
+type exactWindow struct {
+	ring   []uint64
+	next   int
+	full   bool
+	counts map[uint64]int
+}
+
+func newExactWindow(size int) *exactWindow {
+	return &exactWindow{
+		ring:   make([]uint64, size),
+		counts: make(map[uint64]int, size),
+	}
+}
+
+func (w *exactWindow) seen(line []byte) bool {
+	h := hashLine(line)
+	dup := w.counts[h] > 0
+
+	// Evict the oldest line once the window is full
+	if w.full {
+		old := w.ring[w.next]
+		if w.counts[old]--; w.counts[old] == 0 {
+			delete(w.counts, old)
+		}
+	}
+	w.ring[w.next] = h
+	w.counts[h]++
+	w.next++
+	if w.next == len(w.ring) {
+		w.next = 0
+		w.full = true
+	}
+	return dup
+}
+
+// bloomWindow approximates a sliding window with two Bloom filters: lines go
+// into the current one, and when it has absorbed size lines it replaces the
+// previous one. A line is a duplicate if either filter contains it, so the
+// effective window is between size and 2*size lines.
+//
+// Tradeoff: memory is about 2.4 bytes per window line regardless of line
+// length, but roughly 1-2% of unique lines are falsely reported as duplicates,
+// so DuplicateLineCount is an overestimate. A line is never missed.
+type bloomWindow struct {
+	size     int
+	added    int
+	hashes   int
+	current  []uint64
+	previous []uint64
+}
+
+func newBloomWindow(size int) *bloomWindow {
+	// Standard sizing: m = -n ln p / (ln 2)^2 bits, k = (m/n) ln 2 hashes
+	bits := int(math.Ceil(-float64(size) * math.Log(bloomFalsePositiveRate) / (math.Ln2 * math.Ln2)))
+	words := (bits + 63) / 64
+	return &bloomWindow{
+		size:     size,
+		hashes:   max(int(math.Round(float64(words*64)/float64(size)*math.Ln2)), 1),
+		current:  make([]uint64, words),
+		previous: make([]uint64, words),
+	}
+}
+
+func (w *bloomWindow) seen(line []byte) bool {
+	h1 := hashLine(line)
+	h2 := mix64(h1) | 1
+	nbits := uint64(len(w.current) * 64)
+
+	// Double hashing: probe i is h1 + i*h2
+	inCurrent, inPrevious := true, true
+	for i := range w.hashes {
+		bit := (h1 + uint64(i)*h2) % nbits
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if w.current[word]&mask == 0 {
+			inCurrent = false
+			w.current[word] |= mask
+		}
+		if w.previous[word]&mask == 0 {
+			inPrevious = false
+		}
+	}
+
+	w.added++
+	if w.added == w.size {
+		w.previous, w.current = w.current, w.previous
+		clear(w.current)
+		w.added = 0
+	}
+	return inCurrent || inPrevious
+}
@@ -0,0 +1,65 @@
+// internal/processor/duplicates_test.go
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateLines(t *testing.T) {
+	a := `{"level":"INFO","endpoint":"/a","response_time_ms":10}`
+	b := `{"level":"INFO","endpoint":"/b","response_time_ms":20}`
+	c := `{"level":"INFO","endpoint":"/c","response_time_ms":30}`
+	d := `{"level":"INFO","endpoint":"/d","response_time_ms":40}`
+	input := strings.Join([]string{a, a, b, c, b, d, a}, "\n") + "\n"
+
+	tests := []struct {
+		name string
+		cfg  ParserConfig
+		want int
+	}{
+		{name: "disabled", cfg: ParserConfig{}, want: 0},
+		// The second a and b repeat within two lines; the last a is 5 lines on
+		{name: "exact window", cfg: ParserConfig{DuplicateWindow: 3}, want: 2},
+		{name: "exact wide window", cfg: ParserConfig{DuplicateWindow: 10}, want: 3},
+		{name: "approximate", cfg: ParserConfig{DuplicateWindow: 10, ApproximateDuplicates: true}, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, tt.cfg, input).Result("job")
+			if result.DuplicateLineCount != tt.want {
+				t.Errorf("DuplicateLineCount = %d, want %d", result.DuplicateLineCount, tt.want)
+			}
+			// Duplicates are counted, not dropped
+			if result.LineCount != 7 || result.InfoCount != 7 {
+				t.Errorf("lines %d info %d, want 7 aggregated", result.LineCount, result.InfoCount)
+			}
+		})
+	}
+}
+
+func TestBloomWindowFalsePositives(t *testing.T) {
+	const window, lines = 1000, 20_000
+
+	w := newBloomWindow(window)
+	falsePositives := 0
+	for i := range lines {
+		if w.seen(fmt.Appendf(nil, "unique line %d", i)) {
+			falsePositives++
+		}
+	}
+	// Two filters are checked, so allow twice the per-filter target
+	if rate := float64(falsePositives) / lines; rate > 2*bloomFalsePositiveRate {
+		t.Errorf("false-positive rate %.2f%%, want at most %.0f%%", rate*100, 2*bloomFalsePositiveRate*100)
+	}
+
+	// A line repeated within the window is never missed
+	for i := range window {
+		line := fmt.Appendf(nil, "repeat %d", i)
+		w.seen(line)
+		if !w.seen(line) {
+			t.Fatalf("repeat %d not reported as a duplicate", i)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// internal/processor/endpoints.go
+package processor
+
+import (
+	"sort"
+
+	"event-pipeline/internal/models"
+)
+
+// OtherEndpoint is the bucket that absorbs endpoints beyond ParserConfig.MaxEndpoints
+const OtherEndpoint = "__other__"
+
+// trackEndpoint updates the per-endpoint statistics for a single entry
+func (p *LogParser) trackEndpoint(entry *models.LogEntry) {
+	name := entry.Endpoint
+	stat, ok := p.aggregation.EndpointStats[name]
+	if !ok {
+		// Cap the map so files with unbounded path cardinality (IDs in URLs, etc.)
+		// don't exhaust Lambda memory
+		if len(p.aggregation.EndpointStats) >= p.config.MaxEndpoints {
+			name = OtherEndpoint
+			stat = p.aggregation.EndpointStats[name]
+		}
+		if stat == nil {
+			stat = &models.EndpointStat{}
+			p.aggregation.EndpointStats[name] = stat
+		}
+	}
+
+	stat.RequestCount++
+	stat.TotalResponseMs += int64(entry.ResponseTimeMs)
+	if entry.ResponseTimeMs > stat.MaxResponseMs {
+		stat.MaxResponseMs = entry.ResponseTimeMs
+	}
+	if entry.Level == "ERROR" {
+		stat.ErrorCount++
+	}
+}
+
+// TopEndpointsByTraffic returns up to n endpoints with the most requests
+func (p *LogParser) TopEndpointsByTraffic(n int) []models.EndpointSummary {
+	return p.topEndpoints(n, func(a, b models.EndpointSummary) bool {
+		return a.RequestCount > b.RequestCount
+	})
+}
+
+// TopEndpointsByLatency returns up to n endpoints with the highest average response time
+func (p *LogParser) TopEndpointsByLatency(n int) []models.EndpointSummary {
+	return p.topEndpoints(n, func(a, b models.EndpointSummary) bool {
+		return a.AvgResponseTimeMs > b.AvgResponseTimeMs
+	})
+}
+
+// topEndpoints sorts endpoint summaries with less and returns the first n.
+// The overflow bucket is excluded since it isn't a real endpoint.
+func (p *LogParser) topEndpoints(n int, less func(a, b models.EndpointSummary) bool) []models.EndpointSummary {
+	if n <= 0 {
+		return nil
+	}
+
+	summaries := make([]models.EndpointSummary, 0, len(p.aggregation.EndpointStats))
+	for name, stat := range p.aggregation.EndpointStats {
+		if name == OtherEndpoint {
+			continue
+		}
+		summaries = append(summaries, stat.Summary(name))
+	}
+
+	// Break ties by name so output is deterministic across map iteration orders
+	sort.Slice(summaries, func(i, j int) bool {
+		if less(summaries[i], summaries[j]) {
+			return true
+		}
+		if less(summaries[j], summaries[i]) {
+			return false
+		}
+		return summaries[i].Endpoint < summaries[j].Endpoint
+	})
+
+	if len(summaries) > n {
+		summaries = summaries[:n]
+	}
+	return summaries
+}
@@ -0,0 +1,90 @@
+// internal/processor/endpoints_test.go
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// endpointLogs returns one line per response time for each endpoint
+func endpointLogs(times map[string][]int) string {
+	var b strings.Builder
+	for endpoint, values := range times {
+		for _, ms := range values {
+			fmt.Fprintf(&b, `{"level":"INFO","endpoint":%q,"response_time_ms":%d}`+"\n", endpoint, ms)
+		}
+	}
+	return b.String()
+}
+
+func TestTopEndpointsOrdering(t *testing.T) {
+	p := parseString(t, ParserConfig{}, endpointLogs(map[string][]int{
+		"/slow":   {900},
+		"/busy":   {10, 20, 30, 40},
+		"/medium": {100, 300},
+		"/tie-b":  {50, 50},
+		"/tie-a":  {50, 50},
+	}))
+
+	byTraffic := func(p *LogParser, n int) []string {
+		var names []string
+		for _, e := range p.TopEndpointsByTraffic(n) {
+			names = append(names, e.Endpoint)
+		}
+		return names
+	}
+	byLatency := func(p *LogParser, n int) []string {
+		var names []string
+		for _, e := range p.TopEndpointsByLatency(n) {
+			names = append(names, e.Endpoint)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name string
+		top  func(*LogParser, int) []string
+		n    int
+		want []string
+	}{
+		// Ties on request count or average break by name
+		{"traffic", byTraffic, 10, []string{"/busy", "/medium", "/tie-a", "/tie-b", "/slow"}},
+		{"latency", byLatency, 10, []string{"/slow", "/medium", "/tie-a", "/tie-b", "/busy"}},
+		{"latency limited", byLatency, 3, []string{"/slow", "/medium", "/tie-a"}},
+		{"zero", byLatency, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.top(p, tt.n)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopEndpointsExcludeOverflow(t *testing.T) {
+	p := parseString(t, ParserConfig{MaxEndpoints: 2}, endpointLogs(map[string][]int{
+		"/a": {10},
+		"/b": {20},
+		"/c": {5000},
+		"/d": {6000},
+	}))
+
+	// Map order decides which two endpoints are tracked, but the overflow
+	// bucket must never be reported whatever its latency
+	for _, e := range p.TopEndpointsByLatency(10) {
+		if e.Endpoint == OtherEndpoint {
+			t.Fatalf("overflow bucket %q returned as an endpoint", OtherEndpoint)
+		}
+	}
+	if got := len(p.TopEndpointsByLatency(10)); got != 2 {
+		t.Errorf("got %d endpoints, want 2", got)
+	}
+
+	result := p.Result("job")
+	if len(result.SlowestEndpoints) != 2 || result.SlowestEndpoints[0].AvgResponseTimeMs < result.SlowestEndpoints[1].AvgResponseTimeMs {
+		t.Errorf("SlowestEndpoints = %+v, want 2 in descending latency", result.SlowestEndpoints)
+	}
+}
@@ -0,0 +1,40 @@
+// internal/processor/errorsamples.go
+package processor
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSampleErrorBytes caps each sampled message, so one huge stack trace
+// can't bloat the stored result
+const maxSampleErrorBytes = 256
+
+// trackErrorMessage keeps an ERROR entry's message when sampling is enabled.
+// Messages are trimmed, truncated to maxSampleErrorBytes and deduplicated;
+// once ParserConfig.MaxSampleErrors distinct messages are kept, new ones are
+// dropped.
+func (p *LogParser) trackErrorMessage(message string) {
+	samples := p.aggregation.SampleErrors
+	if p.config.MaxSampleErrors <= 0 || len(samples) >= p.config.MaxSampleErrors {
+		return
+	}
+
+	message = truncateUTF8(strings.TrimSpace(message), maxSampleErrorBytes)
+	if message == "" || slices.Contains(samples, message) {
+		return
+	}
+	p.aggregation.SampleErrors = append(samples, message)
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
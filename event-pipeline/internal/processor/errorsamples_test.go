@@ -0,0 +1,70 @@
+// internal/processor/errorsamples_test.go
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// errorLogs is one line per level/message pair
+func errorLogs(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		fmt.Fprintf(&b, `{"level":%q,"endpoint":"/a","response_time_ms":10,"message":%q}`+"\n", pairs[i], pairs[i+1])
+	}
+	return b.String()
+}
+
+func TestSampleErrors(t *testing.T) {
+	input := errorLogs(
+		"ERROR", "db timeout",
+		"WARN", "slow query",
+		"ERROR", "  db timeout\t",
+		"ERROR", "",
+		"error", "cache miss",
+		"ERROR", "db timeout",
+		"ERROR", "disk full",
+		"ERROR", "queue full",
+	)
+
+	tests := []struct {
+		name string
+		max  int
+		want []string
+	}{
+		{name: "disabled"},
+		// Repeats, blank messages and other levels take no slot
+		{name: "deduplicated", max: 10, want: []string{"db timeout", "cache miss", "disk full", "queue full"}},
+		{name: "capped", max: 2, want: []string{"db timeout", "cache miss"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, ParserConfig{MaxSampleErrors: tt.max}, input).Result("job")
+			if !slices.Equal(result.SampleErrors, tt.want) {
+				t.Errorf("SampleErrors = %q, want %q", result.SampleErrors, tt.want)
+			}
+			if result.ErrorCount != 7 {
+				t.Errorf("ErrorCount = %d, want every ERROR counted regardless of sampling", result.ErrorCount)
+			}
+		})
+	}
+}
+
+func TestSampleErrorsTruncated(t *testing.T) {
+	// "é" is two bytes, so byte maxSampleErrorBytes falls inside a rune
+	long := strings.Repeat("x", maxSampleErrorBytes-1) + "é and more"
+	input := errorLogs("ERROR", long, "ERROR", long+" with another tail")
+
+	result := parseString(t, ParserConfig{MaxSampleErrors: 5}, input).Result("job")
+	want := strings.Repeat("x", maxSampleErrorBytes-1)
+	// Both messages share the kept prefix, so they collapse into one sample
+	if !slices.Equal(result.SampleErrors, []string{want}) {
+		t.Fatalf("SampleErrors = %q, want one sample of %d bytes", result.SampleErrors, len(want))
+	}
+	if !utf8.ValidString(result.SampleErrors[0]) {
+		t.Error("truncation split a rune")
+	}
+}
@@ -0,0 +1,40 @@
+// internal/processor/errorwindow.go
+package processor
+
+import (
+	"slices"
+	"time"
+)
+
+// maxErrorWindowEntries bounds the ERROR timestamps held for the sliding
+// window, so a flood of errors can't exhaust memory. PeakErrorsPerWindow
+// saturates at this value.
+const maxErrorWindowEntries = 1 << 16
+
+// trackErrorWindow adds an ERROR entry's timestamp to the sliding window
+// when ParserConfig.ErrorWindow is set, and raises PeakErrorsPerWindow to
+// the number of errors within ErrorWindow of the newest one. Logs are
+// expected to be roughly in time order: entries a little out of order are
+// placed where they belong, and those older than the whole window are left
+// out of the peak.
+func (p *LogParser) trackErrorWindow(ts time.Time) {
+	window := p.config.ErrorWindow.Nanoseconds()
+	if window <= 0 {
+		return
+	}
+
+	t := ts.UnixNano()
+	if n := len(p.errorTimes); n > 0 && t <= p.errorTimes[n-1]-window {
+		return
+	}
+	i, _ := slices.BinarySearch(p.errorTimes, t)
+	p.errorTimes = slices.Insert(p.errorTimes, i, t)
+
+	// Drop errors that fell out of the window ending at the newest one
+	newest := p.errorTimes[len(p.errorTimes)-1]
+	cut, _ := slices.BinarySearch(p.errorTimes, newest-window+1)
+	cut = max(cut, len(p.errorTimes)-maxErrorWindowEntries)
+	p.errorTimes = p.errorTimes[cut:]
+
+	p.aggregation.PeakErrorsPerWindow = max(p.aggregation.PeakErrorsPerWindow, len(p.errorTimes))
+}
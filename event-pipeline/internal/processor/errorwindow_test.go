@@ -0,0 +1,93 @@
+// internal/processor/errorwindow_test.go
+package processor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// timedLogs is one line at level per offset from 10:00:00
+func timedLogs(level string, offsets ...time.Duration) string {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, `{"timestamp":%q,"level":%q,"endpoint":"/a","response_time_ms":10}`+"\n",
+			start.Add(offset).Format(time.RFC3339Nano), level)
+	}
+	return b.String()
+}
+
+// every returns n offsets step apart, starting at from
+func every(from, step time.Duration, n int) []time.Duration {
+	offsets := make([]time.Duration, n)
+	for i := range offsets {
+		offsets[i] = from + time.Duration(i)*step
+	}
+	return offsets
+}
+
+func TestPeakErrorsPerWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		input  string
+		want   int
+	}{
+		{name: "disabled", input: timedLogs("ERROR", every(0, time.Second, 5)...)},
+		{
+			// Five errors in 20s, then one every ten minutes for the hour
+			name:   "burst inside the window",
+			window: time.Minute,
+			input:  timedLogs("ERROR", append(every(0, 5*time.Second, 5), every(10*time.Minute, 10*time.Minute, 5)...)...),
+			want:   5,
+		},
+		{
+			name:   "spread over an hour",
+			window: time.Minute,
+			input:  timedLogs("ERROR", every(0, 5*time.Minute, 12)...),
+			want:   1,
+		},
+		{
+			// The same errors all fall inside an hour-long window
+			name:   "spread inside a long window",
+			window: time.Hour,
+			input:  timedLogs("ERROR", every(0, 5*time.Minute, 12)...),
+			want:   12,
+		},
+		{
+			// A full window apart no longer overlaps
+			name:   "window boundary",
+			window: time.Minute,
+			input:  timedLogs("ERROR", 0, time.Minute, time.Minute+59*time.Second),
+			want:   2,
+		},
+		{
+			name:   "slightly out of order",
+			window: time.Minute,
+			input:  timedLogs("ERROR", 30*time.Second, 10*time.Second, 20*time.Second),
+			want:   3,
+		},
+		{
+			name:   "older than the window",
+			window: time.Minute,
+			input:  timedLogs("ERROR", 5*time.Minute, 0, 10*time.Second),
+			want:   1,
+		},
+		{
+			name:   "other levels",
+			window: time.Minute,
+			input:  timedLogs("WARN", every(0, time.Second, 5)...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ParserConfig{TimestampLayout: time.RFC3339Nano, ErrorWindow: tt.window}
+			result := parseString(t, cfg, tt.input).Result("job")
+			if result.PeakErrorsPerWindow != tt.want {
+				t.Errorf("PeakErrorsPerWindow = %d, want %d", result.PeakErrorsPerWindow, tt.want)
+			}
+		})
+	}
+}
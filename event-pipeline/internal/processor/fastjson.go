@@ -0,0 +1,183 @@
+// internal/processor/fastjson.go
+package processor
+
+import (
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+
+	"event-pipeline/internal/models"
+)
+
+// decodeEntry decodes a line into a LogEntry like json.Unmarshal. With
+// ParserConfig.FastJSON, flat objects are first tried with decodeFlatEntry,
+// which skips reflection; any line it declines goes through json.Unmarshal,
+// so results are identical either way.
+func (p *LogParser) decodeEntry(data []byte) (models.LogEntry, error) {
+	if p.config.FastJSON {
+		// A separate variable keeps this one off the heap
+		var entry models.LogEntry
+		if decodeFlatEntry(data, &entry) {
+			return entry, nil
+		}
+	}
+
+	var entry models.LogEntry
+	err := json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+// decodeFlatEntry handles the common line shape: one object whose keys are
+// exactly LogEntry's JSON names and whose values are escape-free strings or
+// integers. It reports false for anything else (escapes, other keys or
+// casings, floats, null, nested values, invalid UTF-8, syntax errors), and
+// the caller must then decode the line normally.
+func decodeFlatEntry(data []byte, entry *models.LogEntry) bool {
+	s := flatScanner{data: data}
+	if !s.consume('{') {
+		return false
+	}
+	if s.consume('}') {
+		return s.atEnd()
+	}
+
+	for {
+		key, ok := s.rawStr()
+		if !ok || !s.consume(':') {
+			return false
+		}
+
+		// Switching on a converted []byte doesn't allocate
+		var stored bool
+		switch string(key) {
+		case "timestamp":
+			entry.Timestamp, stored = s.strValue()
+		case "level":
+			entry.Level, stored = s.strValue()
+		case "endpoint":
+			entry.Endpoint, stored = s.strValue()
+		case "user_id":
+			entry.UserID, stored = s.strValue()
+		case "message":
+			entry.Message, stored = s.strValue()
+		case "response_time_ms":
+			entry.ResponseTimeMs, stored = s.intValue()
+		case "status_code":
+			entry.StatusCode, stored = s.intValue()
+		case "bytes_sent":
+			entry.BytesSent, stored = s.intValue()
+		}
+		if !stored {
+			return false
+		}
+
+		if s.consume('}') {
+			return s.atEnd()
+		}
+		if !s.consume(',') {
+			return false
+		}
+	}
+}
+
+// maxSafeIntDigits is the most digits that can't overflow a 32-bit int
+const maxSafeIntDigits = 9
+
+// flatScanner walks a JSON object without building intermediate values
+type flatScanner struct {
+	data []byte
+	pos  int
+}
+
+func (s *flatScanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// consume skips whitespace and then c, reporting whether c was next
+func (s *flatScanner) consume(c byte) bool {
+	s.skipSpace()
+	if s.pos < len(s.data) && s.data[s.pos] == c {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+// atEnd reports whether only whitespace remains
+func (s *flatScanner) atEnd() bool {
+	s.skipSpace()
+	return s.pos == len(s.data)
+}
+
+// strValue reads a string value that must be valid UTF-8, since
+// json.Unmarshal would replace invalid bytes
+func (s *flatScanner) strValue() (string, bool) {
+	raw, ok := s.rawStr()
+	if !ok || !utf8.Valid(raw) {
+		return "", false
+	}
+	return string(raw), true
+}
+
+// rawStr reads a string without escapes or control characters, returning
+// its bytes unconverted
+func (s *flatScanner) rawStr() ([]byte, bool) {
+	if !s.consume('"') {
+		return nil, false
+	}
+	start := s.pos
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return s.data[start : s.pos-1], true
+		case c == '\\' || c < 0x20:
+			return nil, false
+		}
+		s.pos++
+	}
+	return nil, false
+}
+
+// intValue reads a JSON integer (no fraction or exponent) that fits an int
+func (s *flatScanner) intValue() (int, bool) {
+	s.skipSpace()
+	start := s.pos
+	if s.pos < len(s.data) && s.data[s.pos] == '-' {
+		s.pos++
+	}
+	digits := s.pos
+	for s.pos < len(s.data) && s.data[s.pos] >= '0' && s.data[s.pos] <= '9' {
+		s.pos++
+	}
+	// JSON forbids leading zeros
+	if s.pos == digits || (s.data[digits] == '0' && s.pos-digits > 1) {
+		return 0, false
+	}
+	// A fraction or exponent means json.Unmarshal would reject it for an int
+	if s.pos < len(s.data) && (s.data[s.pos] == '.' || s.data[s.pos] == 'e' || s.data[s.pos] == 'E') {
+		return 0, false
+	}
+	if s.pos-digits > maxSafeIntDigits {
+		// Rare enough to let strconv sort out overflow
+		n, err := strconv.Atoi(string(s.data[start:s.pos]))
+		return n, err == nil
+	}
+
+	n := 0
+	for _, c := range s.data[digits:s.pos] {
+		n = n*10 + int(c-'0')
+	}
+	if s.data[start] == '-' {
+		n = -n
+	}
+	return n, true
+}
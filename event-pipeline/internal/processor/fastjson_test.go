@@ -0,0 +1,60 @@
+// internal/processor/fastjson_test.go
+package processor
+
+import (
+	"encoding/json"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+func TestDecodeFlatEntryMatchesUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantFast bool // whether decodeFlatEntry takes the line itself
+	}{
+		{
+			name:     "flat",
+			line:     `{"timestamp":"2024-01-15T10:00:00Z","level":"INFO","endpoint":"/a","user_id":"u1","message":"ok","response_time_ms":120,"status_code":200,"bytes_sent":512}`,
+			wantFast: true,
+		},
+		{name: "whitespace", line: " { \"level\" : \"WARN\" ,\t\"response_time_ms\" : 7 } ", wantFast: true},
+		{name: "negative number", line: `{"level":"INFO","response_time_ms":-5}`, wantFast: true},
+		{name: "empty object", line: `{}`, wantFast: true},
+		{name: "duplicate key", line: `{"level":"INFO","level":"ERROR"}`, wantFast: true},
+		{name: "escaped quote", line: `{"level":"ERROR","message":"said \"hi\""}`},
+		{name: "multibyte UTF-8", line: `{"level":"INFO","endpoint":"/café"}`, wantFast: true},
+		{name: "unicode escape", line: `{"level":"INFO","endpoint":"/caf\u00e9"}`},
+		{name: "escaped slash", line: `{"endpoint":"\/a\/b"}`},
+		{name: "null string", line: `{"level":null,"endpoint":"/a"}`},
+		{name: "null number", line: `{"level":"INFO","response_time_ms":null}`},
+		{name: "nested object", line: `{"level":"INFO","user_id":"u1","extra":{"a":1}}`},
+		{name: "nested in known field", line: `{"level":{"name":"INFO"}}`},
+		{name: "unknown field", line: `{"level":"INFO","trace_id":"abc","response_time_ms":3}`},
+		{name: "different key case", line: `{"Level":"INFO","Response_Time_Ms":3}`},
+		{name: "float", line: `{"level":"INFO","response_time_ms":1.5}`},
+		{name: "trailing garbage", line: `{"level":"INFO"} x`},
+		{name: "unterminated", line: `{"level":"INFO"`},
+		{name: "invalid UTF-8", line: "{\"message\":\"\xff\"}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want models.LogEntry
+			wantErr := json.Unmarshal([]byte(tt.line), &want)
+
+			var fast models.LogEntry
+			if ok := decodeFlatEntry([]byte(tt.line), &fast); ok != tt.wantFast {
+				t.Fatalf("decodeFlatEntry took the line: %v, want %v", ok, tt.wantFast)
+			} else if ok && (wantErr != nil || fast != want) {
+				t.Errorf("decodeFlatEntry = %+v, json.Unmarshal = %+v (%v)", fast, want, wantErr)
+			}
+
+			// With the fallback the parser always agrees with json.Unmarshal
+			got, err := NewLogParser(ParserConfig{FastJSON: true}).decodeEntry([]byte(tt.line))
+			if (err != nil) != (wantErr != nil) || (err == nil && got != want) {
+				t.Errorf("decodeEntry = %+v (%v), json.Unmarshal = %+v (%v)", got, err, want, wantErr)
+			}
+		})
+	}
+}
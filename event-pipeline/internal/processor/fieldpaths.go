@@ -0,0 +1,109 @@
+// internal/processor/fieldpaths.go
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ParseFieldPaths reads comma-separated field=path pairs, e.g.
+// "endpoint=request.endpoint,status_code=request.status", and validates them
+func ParseFieldPaths(raw string) (map[string]string, error) {
+	paths := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, path, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("field path %q is not field=path", strings.TrimSpace(pair))
+		}
+		paths[strings.TrimSpace(field)] = strings.TrimSpace(path)
+	}
+	if err := ValidateFieldPaths(paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// ValidateFieldPaths checks that every path targets a known LogEntry field,
+// using the JSON field names as in ValidateRequiredFields, and has no empty
+// segments
+func ValidateFieldPaths(paths map[string]string) error {
+	for field, path := range paths {
+		if _, ok := fieldPresent[field]; !ok {
+			return fmt.Errorf("field path for unknown field %q", field)
+		}
+		for _, segment := range strings.Split(path, ".") {
+			if segment == "" {
+				return fmt.Errorf("field path %q for %q has an empty segment", path, field)
+			}
+		}
+	}
+	return nil
+}
+
+// applyFieldPaths moves the value at each ParserConfig.FieldPaths path to
+// the top-level key LogEntry decodes the field from. A field whose path is
+// missing, or runs through something other than an object, is left unset.
+// mapped reports whether the response time was moved.
+func (p *LogParser) applyFieldPaths(fields map[string]json.RawMessage) (mapped bool) {
+	// Each nested object is decoded once per line, however many fields
+	// are read from it
+	objects := map[string]map[string]json.RawMessage{"": fields}
+	resolved := make(map[string]json.RawMessage, len(p.config.FieldPaths))
+	for field, path := range p.config.FieldPaths {
+		if value, ok := lookupPath(objects, path); ok {
+			resolved[field] = value
+		}
+	}
+
+	// Assign after every lookup, so a path may read a key another field
+	// is being moved to
+	for field := range p.config.FieldPaths {
+		if value, ok := resolved[field]; ok {
+			fields[field] = value
+		} else {
+			delete(fields, field)
+		}
+	}
+	_, mapped = p.config.FieldPaths[responseTimeKey]
+	return mapped
+}
+
+// lookupPath follows a dotted path through nested JSON objects, caching
+// each decoded object in objects under its path prefix
+func lookupPath(objects map[string]map[string]json.RawMessage, path string) (json.RawMessage, bool) {
+	prefix := ""
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		obj, ok := objects[prefix]
+		if !ok {
+			return nil, false
+		}
+		value, ok := obj[segment]
+		if !ok {
+			return nil, false
+		}
+		if i == len(segments)-1 {
+			return value, true
+		}
+
+		if prefix != "" {
+			prefix += "."
+		}
+		prefix += segment
+		if _, seen := objects[prefix]; !seen {
+			var nested map[string]json.RawMessage
+			if json.Unmarshal(value, &nested) != nil {
+				nested = nil
+			}
+			objects[prefix] = nested
+		}
+		if objects[prefix] == nil {
+			return nil, false
+		}
+	}
+	return nil, false
+}
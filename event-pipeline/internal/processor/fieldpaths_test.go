@@ -0,0 +1,145 @@
+// internal/processor/fieldpaths_test.go
+package processor
+
+import (
+	"maps"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// nestedPaths reads the newer format, which nests request fields
+var nestedPaths = map[string]string{
+	"endpoint":         "request.endpoint",
+	"response_time_ms": "request.response_time_ms",
+	"status_code":      "request.status",
+}
+
+func TestFieldPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths map[string]string
+		line  string
+		want  models.LogEntry
+	}{
+		{
+			name: "no paths",
+			line: `{"level":"INFO","endpoint":"/x","response_time_ms":12,"status_code":200}`,
+			want: models.LogEntry{Level: "INFO", Endpoint: "/x", ResponseTimeMs: 12, StatusCode: 200},
+		},
+		{
+			name:  "dotted paths",
+			paths: nestedPaths,
+			line:  `{"level":"INFO","request":{"endpoint":"/x","response_time_ms":12,"status":200}}`,
+			want:  models.LogEntry{Level: "INFO", Endpoint: "/x", ResponseTimeMs: 12, StatusCode: 200},
+		},
+		{
+			name:  "deeper path",
+			paths: map[string]string{"user_id": "request.auth.user"},
+			line:  `{"level":"INFO","request":{"auth":{"user":"u1"}}}`,
+			want:  models.LogEntry{Level: "INFO", UserID: "u1"},
+		},
+		{
+			// Fields without a path are still read from their flat keys
+			name:  "flat fallback",
+			paths: nestedPaths,
+			line:  `{"level":"WARN","user_id":"u1","message":"slow","request":{"endpoint":"/x","response_time_ms":12,"status":200}}`,
+			want:  models.LogEntry{Level: "WARN", UserID: "u1", Message: "slow", Endpoint: "/x", ResponseTimeMs: 12, StatusCode: 200},
+		},
+		{
+			// A flat key for a field with a path is not a fallback
+			name:  "flat key ignored",
+			paths: nestedPaths,
+			line:  `{"level":"INFO","endpoint":"/flat","request":{"response_time_ms":12}}`,
+			want:  models.LogEntry{Level: "INFO", ResponseTimeMs: 12},
+		},
+		{
+			name:  "missing intermediate",
+			paths: nestedPaths,
+			line:  `{"level":"INFO","endpoint":"/x"}`,
+			want:  models.LogEntry{Level: "INFO"},
+		},
+		{
+			name:  "missing leaf",
+			paths: nestedPaths,
+			line:  `{"level":"INFO","request":{"endpoint":"/x"}}`,
+			want:  models.LogEntry{Level: "INFO", Endpoint: "/x"},
+		},
+		{
+			name:  "non-object intermediate",
+			paths: nestedPaths,
+			line:  `{"level":"INFO","request":"GET /x"}`,
+			want:  models.LogEntry{Level: "INFO"},
+		},
+		{
+			name:  "null intermediate",
+			paths: nestedPaths,
+			line:  `{"level":"INFO","request":null}`,
+			want:  models.LogEntry{Level: "INFO"},
+		},
+		{
+			// A path may read the key another field is moved to
+			name:  "swapped keys",
+			paths: map[string]string{"endpoint": "message", "message": "endpoint"},
+			line:  `{"level":"INFO","endpoint":"boom","message":"/x"}`,
+			want:  models.LogEntry{Level: "INFO", Endpoint: "/x", Message: "boom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewLogParser(ParserConfig{FieldPaths: tt.paths}).decodeJSON([]byte(tt.line))
+			if err != nil {
+				t.Fatalf("decodeJSON: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("decoded %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNestedLinesAreNotMalformed(t *testing.T) {
+	p := parseString(t, ParserConfig{FieldPaths: nestedPaths},
+		`{"level":"INFO","request":{"endpoint":"/x","response_time_ms":12,"status":200}}
+{"level":"ERROR","request":{"endpoint":"/y","response_time_ms":30,"status":500}}
+`)
+	result := p.Result("job")
+	if result.LineCount != 2 || result.MalformedLineCount != 0 {
+		t.Errorf("lines %d malformed %d, want 2 and 0", result.LineCount, result.MalformedLineCount)
+	}
+	if result.UniqueEndpoints != 2 || result.MaxResponseTimeMs != 30 {
+		t.Errorf("endpoints %d max %dms, want 2 and 30ms", result.UniqueEndpoints, result.MaxResponseTimeMs)
+	}
+}
+
+func TestParseFieldPaths(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			raw:  " endpoint = request.endpoint , status_code=request.status,",
+			want: map[string]string{"endpoint": "request.endpoint", "status_code": "request.status"},
+		},
+		{raw: "", want: map[string]string{}},
+		{raw: "endpoint", wantErr: true},
+		{raw: "route=request.route", wantErr: true},
+		{raw: "endpoint=request..endpoint", wantErr: true},
+		{raw: "endpoint=request.", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseFieldPaths(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseFieldPaths(%q) = %v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, tt.want) {
+				t.Errorf("ParseFieldPaths(%q) = %v, %v; want %v", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// internal/processor/histogram.go
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"event-pipeline/internal/models"
+)
+
+// DefaultResponseTimeBuckets are the histogram upper bounds (ms) used when
+// ParserConfig.ResponseTimeBuckets is unset: 0-50, 50-100, 100-250, 250-500
+// and 500+
+var DefaultResponseTimeBuckets = []int{50, 100, 250, 500}
+
+// normalizeBuckets sorts bounds and drops duplicates and non-positive values
+func normalizeBuckets(bounds []int) []int {
+	out := make([]int, 0, len(bounds))
+	for _, b := range bounds {
+		if b > 0 {
+			out = append(out, b)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// bucketLabels names each bucket, e.g. "50-100ms" and "500ms+"
+func bucketLabels(bounds []int) []string {
+	labels := make([]string, 0, len(bounds)+1)
+	lower := 0
+	for _, upper := range bounds {
+		labels = append(labels, fmt.Sprintf("%d-%dms", lower, upper))
+		lower = upper
+	}
+	return append(labels, fmt.Sprintf("%dms+", lower))
+}
+
+// bucketIndex returns the bucket for a response time. Buckets include their
+// lower bound and exclude their upper one, so 50ms lands in "50-100ms".
+func (p *LogParser) bucketIndex(ms int) int {
+	bounds := p.config.ResponseTimeBuckets
+	return sort.Search(len(bounds), func(i int) bool { return bounds[i] > ms })
+}
+
+// GetHistogram returns every response-time bucket in ascending order,
+// including empty ones
+func (p *LogParser) GetHistogram() []models.HistogramBucket {
+	bounds := p.config.ResponseTimeBuckets
+	buckets := make([]models.HistogramBucket, len(p.bucketLabels))
+	lower := 0
+	for i, label := range p.bucketLabels {
+		buckets[i] = models.HistogramBucket{
+			Label:   label,
+			LowerMs: lower,
+			Count:   p.aggregation.ResponseTimeBuckets[label],
+		}
+		if i < len(bounds) {
+			buckets[i].UpperMs = bounds[i]
+			lower = bounds[i]
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,92 @@
+// internal/processor/histogram_test.go
+package processor
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// responseTimes renders one INFO line per response time
+func responseTimes(values ...int) string {
+	var b strings.Builder
+	for _, ms := range values {
+		fmt.Fprintf(&b, `{"level":"INFO","endpoint":"/a","response_time_ms":%d}`+"\n", ms)
+	}
+	return b.String()
+}
+
+func TestGetHistogram(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds []int
+		values []int
+		want   []models.HistogramBucket
+	}{
+		{
+			// A value on a boundary goes in the higher bucket
+			name:   "default bounds",
+			values: []int{0, 49, 50, 99, 100, 249, 250, 499, 500, 5000},
+			want: []models.HistogramBucket{
+				{Label: "0-50ms", LowerMs: 0, UpperMs: 50, Count: 2},
+				{Label: "50-100ms", LowerMs: 50, UpperMs: 100, Count: 2},
+				{Label: "100-250ms", LowerMs: 100, UpperMs: 250, Count: 2},
+				{Label: "250-500ms", LowerMs: 250, UpperMs: 500, Count: 2},
+				{Label: "500ms+", LowerMs: 500, Count: 2},
+			},
+		},
+		{
+			// Bounds are sorted, deduplicated and stripped of non-positive values
+			name:   "custom bounds",
+			bounds: []int{1000, 10, 0, 10, -5},
+			values: []int{9, 10, 999, 1000},
+			want: []models.HistogramBucket{
+				{Label: "0-10ms", LowerMs: 0, UpperMs: 10, Count: 1},
+				{Label: "10-1000ms", LowerMs: 10, UpperMs: 1000, Count: 2},
+				{Label: "1000ms+", LowerMs: 1000, Count: 1},
+			},
+		},
+		{
+			// Empty buckets are listed, unlike in ResponseTimeBuckets
+			name:   "empty buckets",
+			bounds: []int{100, 200},
+			values: []int{150},
+			want: []models.HistogramBucket{
+				{Label: "0-100ms", LowerMs: 0, UpperMs: 100},
+				{Label: "100-200ms", LowerMs: 100, UpperMs: 200, Count: 1},
+				{Label: "200ms+", LowerMs: 200},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := parseString(t, ParserConfig{ResponseTimeBuckets: tt.bounds}, responseTimes(tt.values...))
+			got := p.GetHistogram()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetHistogram() = %+v, want %+v", got, tt.want)
+			}
+
+			// The result keeps the same counts, without the empty buckets
+			want := map[string]int{}
+			for _, b := range tt.want {
+				if b.Count > 0 {
+					want[b.Label] = b.Count
+				}
+			}
+			if got := p.Result("job").ResponseTimeBuckets; !maps.Equal(got, want) {
+				t.Errorf("ResponseTimeBuckets = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultResponseTimeBuckets(t *testing.T) {
+	want := []int{50, 100, 250, 500}
+	if !slices.Equal(DefaultResponseTimeBuckets, want) {
+		t.Errorf("DefaultResponseTimeBuckets = %v, want %v", DefaultResponseTimeBuckets, want)
+	}
+}
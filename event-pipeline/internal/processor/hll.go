@@ -0,0 +1,73 @@
+// internal/processor/hll.go
+package processor
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of index bits; 2^14 registers use 16KB
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct strings in fixed memory.
+//
+// With 2^14 registers the standard error is 1.04/sqrt(16384) ≈ 0.8%, so
+// roughly 95% of estimates land within ±1.6% of the true count. Small sets
+// use linear counting and are close to exact.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+// newHyperLogLog creates an empty sketch
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// add records a value in the sketch
+func (h *hyperLogLog) add(value string) {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(value))
+	x := mix64(hasher.Sum64())
+
+	idx := x >> (64 - hllPrecision)
+	// Sentinel bit bounds rho when the remaining bits are all zero
+	rho := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rho > h.registers[idx] {
+		h.registers[idx] = rho
+	}
+}
+
+// estimate returns the approximate number of distinct values added
+func (h *hyperLogLog) estimate() int {
+	m := float64(len(h.registers))
+
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	est := alpha * m * m / sum
+
+	// Linear counting is more accurate while many registers are empty
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(est))
+}
+
+// mix64 is the splitmix64 finalizer; it spreads FNV's weak low-entropy
+// output across all 64 bits so register indexes are evenly distributed
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
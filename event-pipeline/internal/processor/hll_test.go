@@ -0,0 +1,57 @@
+// internal/processor/hll_test.go
+package processor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// hllTolerance is the relative error allowed, twice the sketch's ~0.8%
+// standard error
+const hllTolerance = 0.02
+
+func TestHyperLogLogEstimate(t *testing.T) {
+	for _, n := range []int{1, 100, 10_000, 100_000} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			h := newHyperLogLog()
+			for i := range n {
+				h.add(fmt.Sprintf("user-%d", i))
+			}
+			est := h.estimate()
+			if relErr := math.Abs(float64(est-n)) / float64(n); relErr > hllTolerance {
+				t.Errorf("estimate %d for %d distinct values, error %.2f%% over %.0f%%", est, n, relErr*100, hllTolerance*100)
+			}
+
+			// Registers only keep maxima, so repeats can't move the estimate
+			for i := range n {
+				h.add(fmt.Sprintf("user-%d", i))
+			}
+			if again := h.estimate(); again != est {
+				t.Errorf("estimate changed from %d to %d after duplicate inserts", est, again)
+			}
+		})
+	}
+}
+
+func TestApproximateUniqueUsers(t *testing.T) {
+	const users = 100_000
+
+	var b strings.Builder
+	for i := range users {
+		fmt.Fprintf(&b, `{"level":"INFO","endpoint":"/e%d","response_time_ms":1,"user_id":"user-%d"}`+"\n", i%50, i)
+	}
+	// Every user appears a second time
+	for i := range users {
+		fmt.Fprintf(&b, `{"level":"INFO","endpoint":"/e0","response_time_ms":1,"user_id":"user-%d"}`+"\n", i)
+	}
+
+	result := parseString(t, ParserConfig{ApproximateUniques: true}, b.String()).Result("job")
+	if relErr := math.Abs(float64(result.UniqueUsers-users)) / users; relErr > hllTolerance {
+		t.Errorf("UniqueUsers = %d, want %d within %.0f%%", result.UniqueUsers, users, hllTolerance*100)
+	}
+	if result.UniqueEndpoints != 50 {
+		t.Errorf("UniqueEndpoints = %d, want 50", result.UniqueEndpoints)
+	}
+}
@@ -0,0 +1,64 @@
+// internal/processor/lines.go
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// lineReader splits newline-delimited input like bufio.ScanLines, but a
+// line longer than max is discarded and reported instead of failing the
+// whole read the way bufio.Scanner does with ErrTooLong
+type lineReader struct {
+	br  *bufio.Reader
+	max int
+	buf []byte
+}
+
+func newLineReader(br *bufio.Reader, max int) *lineReader {
+	return &lineReader{br: br, max: max}
+}
+
+// next returns the next line without its "\n" or "\r\n" terminator. When
+// the line exceeded max, oversized is true and line is nil. The returned
+// slice is only valid until the next call. At the end of input err is io.EOF.
+func (r *lineReader) next() (line []byte, oversized bool, err error) {
+	r.buf = r.buf[:0]
+	read := 0
+	for {
+		chunk, err := r.br.ReadSlice('\n')
+		read += len(chunk)
+		if !oversized {
+			r.buf = append(r.buf, chunk...)
+			// Leave room for a "\r\n" terminator before giving up on the line
+			if len(r.buf) > r.max+2 {
+				oversized = true
+				r.buf = r.buf[:0]
+			}
+		}
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+			continue
+		case errors.Is(err, io.EOF):
+			if read == 0 {
+				return nil, false, io.EOF
+			}
+		case err != nil:
+			return nil, false, err
+		}
+		break
+	}
+
+	if oversized {
+		return nil, true, nil
+	}
+	line = bytes.TrimSuffix(r.buf, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	if len(line) > r.max {
+		return nil, true, nil
+	}
+	return line, false, nil
+}
@@ -4,101 +4,419 @@ package processor
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"strings"
+	"time"
 
 	"event-pipeline/internal/models"
 )
 
+// ErrTooManyMalformed means the file doesn't look like the configured log format
+var ErrTooManyMalformed = errors.New("too many malformed lines")
+
+// maxDistinctLevels caps the LevelCounts map
+const maxDistinctLevels = 32
+
 // LogParser processes log files and extracts statistics
 type LogParser struct {
-	aggregation *models.LogAggregation
+	config        ParserConfig
+	aggregation   *models.LogAggregation
+	responseTimes *reservoir
+	responseStats runningStats // mean and deviation for anomaly detection
+	slowest       *slowestHeap
+	duplicates    duplicateDetector // nil unless DuplicateWindow is set
+	bucketLabels  []string          // one per response-time histogram bucket
+	delimited     bool              // rows are decoded by Columns; see NewDelimitedParser
+
+	// sampled counts entries checked against MaxMalformedRatio
+	sampled int
+
+	// debugSeen counts DEBUG entries considered for DebugSampleRate
+	debugSeen int
+
+	// errorTimes holds the ascending Unix nanosecond timestamps of ERROR
+	// entries within ErrorWindow of the newest, for PeakErrorsPerWindow
+	errorTimes []int64
+
+	// Only set in approximate uniques mode
+	userSketch     *hyperLogLog
+	endpointSketch *hyperLogLog
 }
 
 // NewLogParser creates a new LogParser instance
-func NewLogParser() *LogParser {
-	return &LogParser{
-		aggregation: models.NewLogAggregation(),
+func NewLogParser(cfg ParserConfig) *LogParser {
+	p := &LogParser{
+		config:        cfg.withDefaults(),
+		aggregation:   models.NewLogAggregation(),
+		responseTimes: newReservoir(DefaultReservoirSize),
+		slowest:       newSlowestHeap(cfg.SlowestSize),
+		duplicates:    newDuplicateDetector(cfg),
+	}
+	p.bucketLabels = bucketLabels(p.config.ResponseTimeBuckets)
+	if p.config.ApproximateUniques {
+		p.userSketch = newHyperLogLog()
+		p.endpointSketch = newHyperLogLog()
 	}
+	return p
 }
 
-// Parse reads a log file and aggregates statistics
+// Parse reads a log file and aggregates statistics. It may be called once
+// per file to combine several files into the same aggregation.
+//
+// On error the aggregation is still returned, holding everything counted
+// before the failure, so callers can keep a partial result.
 func (p *LogParser) Parse(reader io.Reader) (*models.LogAggregation, error) {
-	scanner := bufio.NewScanner(reader)
-	
-	// Increase buffer size for potentially long lines
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+	br := bufio.NewReaderSize(reader, 64*1024)
 
+	format := p.config.InputFormat
+	if format == FormatAuto {
+		format = detectFormat(br)
+	}
+
+	var err error
+	if format == FormatJSONArray {
+		err = p.parseArray(br)
+	} else {
+		err = p.parseLines(br)
+	}
+	if err != nil {
+		return p.aggregation, err
+	}
+
+	// Files shorter than the sample are judged once they are fully read
+	if p.sampled < p.config.MalformedSampleSize && p.sampled >= MinMalformedSampleSize {
+		if err := p.checkMalformed(p.sampled, p.sampled); err != nil {
+			return p.aggregation, err
+		}
+	}
+
+	return p.aggregation, nil
+}
+
+// parseLines handles newline-delimited input (NDJSON or LinePattern text).
+// Lines longer than MaxLineBytes are counted as oversized and skipped.
+func (p *LogParser) parseLines(br *bufio.Reader) error {
+	lines := newLineReader(br, p.config.MaxLineBytes)
+
+	// A range starting mid-file has no header row to skip besides
+	if p.config.SkipFirstLine || (p.delimited && p.config.HeaderRow) {
+		if _, _, err := lines.next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	// Count lines read so far even when reading fails partway
 	lineNum := 0
-	for scanner.Scan() {
+	defer func() { p.aggregation.TotalLines += lineNum }()
+
+	for {
+		line, oversized, err := lines.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading file after line %d: %w", lineNum, err)
+		}
+		if p.lineLimitReached(lineNum) {
+			return nil
+		}
 		lineNum++
-		line := scanner.Bytes()
-		
+
+		if oversized {
+			// stderr keeps localproc's JSON output on stdout clean
+			fmt.Fprintf(os.Stderr, "Skipping line %d: longer than %d bytes\n", lineNum, p.config.MaxLineBytes)
+			p.aggregation.OversizedLineCount++
+			continue
+		}
 		if len(line) == 0 {
 			continue
 		}
 
-		var entry models.LogEntry
-		if err := json.Unmarshal(line, &entry); err != nil {
-			// Count parse errors as warnings, continue processing
-			p.aggregation.WarnCount++
-			continue
+		entry, err := p.decodeLine(line)
+		if err := p.record(line, &entry, err); err != nil {
+			return err
 		}
+	}
+}
+
+// parseArray streams the elements of a single top-level JSON array, so the
+// file never has to fit in memory. Each element counts as one line.
+func (p *LogParser) parseArray(reader io.Reader) error {
+	dec := json.NewDecoder(reader)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("error reading JSON array: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected JSON array, found %v", tok)
+	}
+
+	elements := 0
+	defer func() { p.aggregation.TotalLines += elements }()
+
+	for dec.More() {
+		if p.lineLimitReached(elements) {
+			return nil
+		}
+
+		// Decode the raw element first so a syntax error (the stream itself
+		// is broken) can be told apart from a type mismatch, such as a number
+		// instead of an object, which only spoils this element
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("truncated or invalid JSON array at element %d: %w", elements+1, err)
+		}
+		elements++
+
+		entry, err := p.decodeJSON(raw)
+		if err := p.record(raw, &entry, err); err != nil {
+			return err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("truncated JSON array after element %d: %w", elements, err)
+	}
+	return nil
+}
+
+// lineLimitReached reports whether ParserConfig.MaxLines lines have been
+// read, counting read lines of the current file on top of earlier ones.
+// Callers check it with another line in hand, so reaching it marks the
+// aggregation truncated.
+func (p *LogParser) lineLimitReached(read int) bool {
+	if p.config.MaxLines <= 0 || p.aggregation.TotalLines+read < p.config.MaxLines {
+		return false
+	}
+	p.aggregation.Truncated = true
+	return true
+}
 
-		p.processEntry(&entry)
+// record aggregates a decoded entry, or counts it as malformed when decoding
+// failed or invalid when a required field is missing, and applies the
+// malformed-ratio check to the leading sample
+func (p *LogParser) record(line []byte, entry *models.LogEntry, decodeErr error) error {
+	p.aggregation.MaxLineBytes = max(p.aggregation.MaxLineBytes, len(line))
+
+	// Duplicates are only counted; they are still aggregated
+	if p.duplicates != nil && p.duplicates.seen(line) {
+		p.aggregation.DuplicateLineCount++
+	}
+
+	if errors.Is(decodeErr, errResponseTimeOutOfRange) || (decodeErr == nil && !p.responseTimeInRange(entry.ResponseTimeMs)) {
+		// Negative or absurd times would skew every response-time statistic
+		p.aggregation.BadResponseTimeCount++
+	} else if errors.Is(decodeErr, errUnknownSchema) {
+		// Valid JSON from a producer nobody registered a mapping for
+		p.aggregation.UnknownSchemaCount++
+	} else if decodeErr != nil {
+		// Track parse failures separately from real WARN entries
+		p.aggregation.MalformedLineCount++
+	} else if !p.hasRequiredFields(entry) {
+		// Well-formed but incomplete entries don't count as malformed
+		p.aggregation.InvalidEntryCount++
+	} else {
+		p.processEntry(entry)
 		p.aggregation.ProcessedLines++
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error scanning file: %w", err)
+	if p.sampled < p.config.MalformedSampleSize {
+		p.sampled++
+		return p.checkMalformed(p.sampled, p.config.MalformedSampleSize)
 	}
+	return nil
+}
 
-	p.aggregation.TotalLines = lineNum
-	return p.aggregation, nil
+// detectFormat peeks past leading whitespace to see whether the input is a
+// JSON array. Nothing is consumed, so line counts are unaffected.
+func detectFormat(br *bufio.Reader) string {
+	for i := 1; i <= br.Size(); i++ {
+		buf, _ := br.Peek(i)
+		if len(buf) < i {
+			break
+		}
+		switch buf[i-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return FormatJSONArray
+		}
+		break
+	}
+	return FormatNDJSON
+}
+
+// checkMalformed returns ErrTooManyMalformed once sampled reaches sampleSize
+// and the malformed share of those lines exceeds MaxMalformedRatio
+func (p *LogParser) checkMalformed(sampled, sampleSize int) error {
+	if p.config.MaxMalformedRatio <= 0 || sampled != sampleSize {
+		return nil
+	}
+
+	ratio := float64(p.aggregation.MalformedLineCount) / float64(sampled)
+	if ratio > p.config.MaxMalformedRatio {
+		return fmt.Errorf("%w: %d of the first %d lines failed to parse", ErrTooManyMalformed, p.aggregation.MalformedLineCount, sampled)
+	}
+	return nil
+}
+
+// decodeLine converts a raw line into a LogEntry using the configured format
+func (p *LogParser) decodeLine(line []byte) (models.LogEntry, error) {
+	if p.delimited {
+		return p.decodeDelimited(line)
+	}
+	if p.config.LinePattern != nil {
+		return p.parseRegexLine(line)
+	}
+
+	return p.decodeJSON(line)
 }
 
 // processEntry updates aggregation with a single log entry
 func (p *LogParser) processEntry(entry *models.LogEntry) {
+	// Normalize so "error", "Error" and "ERROR" all count the same
+	entry.Level = strings.ToUpper(strings.TrimSpace(entry.Level))
+
 	// Count by log level
 	switch entry.Level {
 	case "ERROR":
 		p.aggregation.ErrorCount++
+		p.trackErrorMessage(entry.Message)
 	case "WARN":
 		p.aggregation.WarnCount++
 	case "INFO":
 		p.aggregation.InfoCount++
 	case "DEBUG":
 		p.aggregation.DebugCount++
+	default:
+		p.aggregation.UnknownLevelCount++
+	}
+
+	// Record which levels appeared, bounded in case the field holds garbage
+	if _, seen := p.aggregation.LevelCounts[entry.Level]; seen || len(p.aggregation.LevelCounts) < maxDistinctLevels {
+		p.aggregation.LevelCounts[entry.Level]++
+	}
+
+	// Level counts stay exact; the rest only covers the DEBUG sample
+	if entry.Level == "DEBUG" && !p.sampleDebug() {
+		p.aggregation.SampledOutDebugCount++
+		return
+	}
+
+	// Track the time window covered by the file
+	if entry.Timestamp != "" {
+		if ts, ok := p.trackTimestamp(entry.Timestamp); ok && entry.Level == "ERROR" {
+			p.trackErrorWindow(ts)
+		}
 	}
 
 	// Track response times
 	p.aggregation.TotalResponseMs += int64(entry.ResponseTimeMs)
+	// ProcessedLines is incremented after processEntry, so zero means first entry
+	if p.aggregation.AggregatedLines() == 0 || entry.ResponseTimeMs < p.aggregation.MinResponseMs {
+		p.aggregation.MinResponseMs = entry.ResponseTimeMs
+	}
 	if entry.ResponseTimeMs > p.aggregation.MaxResponseMs {
 		p.aggregation.MaxResponseMs = entry.ResponseTimeMs
 	}
+	p.responseTimes.add(entry.ResponseTimeMs)
+	p.trackAnomaly(entry.ResponseTimeMs)
+
+	// Entries without bytes_sent decode as 0 and simply add nothing
+	p.aggregation.TotalBytesSent += int64(entry.BytesSent)
+	p.slowest.add(entry)
+	p.aggregation.ResponseTimeBuckets[p.bucketLabels[p.bucketIndex(entry.ResponseTimeMs)]]++
 
 	// Track unique users
 	if entry.UserID != "" {
-		p.aggregation.UniqueUsers[entry.UserID] = struct{}{}
+		p.trackUser(entry.UserID)
+		if p.userSketch != nil {
+			p.userSketch.add(entry.UserID)
+		} else {
+			p.aggregation.UniqueUsers[entry.UserID] = struct{}{}
+		}
 	}
 
 	// Track unique endpoints
 	if entry.Endpoint != "" {
-		p.aggregation.UniqueEndpoints[entry.Endpoint] = struct{}{}
+		if p.endpointSketch != nil {
+			p.endpointSketch.add(entry.Endpoint)
+		} else {
+			p.aggregation.UniqueEndpoints[entry.Endpoint] = struct{}{}
+		}
+		p.trackEndpoint(entry)
 	}
 
 	// Track status codes
 	if entry.StatusCode > 0 {
 		p.aggregation.StatusCodeCounts[entry.StatusCode]++
 	}
+	p.trackStatusClass(entry.StatusCode)
+}
+
+// trackTimestamp parses a timestamp and widens the earliest/latest window.
+// It reports false when the timestamp doesn't parse.
+func (p *LogParser) trackTimestamp(raw string) (time.Time, bool) {
+	ts, err := time.Parse(p.config.TimestampLayout, raw)
+	if err != nil {
+		p.aggregation.MalformedTimestampCount++
+		return ts, false
+	}
+	p.trackMinute(ts)
+
+	if p.aggregation.EarliestTimestamp.IsZero() || ts.Before(p.aggregation.EarliestTimestamp) {
+		p.aggregation.EarliestTimestamp = ts
+	}
+	if ts.After(p.aggregation.LatestTimestamp) {
+		p.aggregation.LatestTimestamp = ts
+	}
+	return ts, true
 }
 
 // GetAverageResponseTime calculates average response time
 func (p *LogParser) GetAverageResponseTime() float64 {
-	// Use ProcessedLines for an accurate average, as some lines might be skipped.
-	if p.aggregation.ProcessedLines == 0 {
+	// Use AggregatedLines for an accurate average, as some lines might be
+	// skipped or sampled out
+	if p.aggregation.AggregatedLines() == 0 {
 		return 0
 	}
-	return float64(p.aggregation.TotalResponseMs) / float64(p.aggregation.ProcessedLines)
+	return float64(p.aggregation.TotalResponseMs) / float64(p.aggregation.AggregatedLines())
+}
+
+// GetAverageBytesSent returns the mean bytes_sent per aggregated entry,
+// counting entries without the field as 0
+func (p *LogParser) GetAverageBytesSent() float64 {
+	if p.aggregation.AggregatedLines() == 0 {
+		return 0
+	}
+	return float64(p.aggregation.TotalBytesSent) / float64(p.aggregation.AggregatedLines())
+}
+
+// UniqueUserCount returns the number of distinct user IDs (estimated in approximate mode)
+func (p *LogParser) UniqueUserCount() int {
+	if p.userSketch != nil {
+		return p.userSketch.estimate()
+	}
+	return len(p.aggregation.UniqueUsers)
+}
+
+// UniqueEndpointCount returns the number of distinct endpoints (estimated in approximate mode)
+func (p *LogParser) UniqueEndpointCount() int {
+	if p.endpointSketch != nil {
+		return p.endpointSketch.estimate()
+	}
+	return len(p.aggregation.UniqueEndpoints)
+}
+
+// GetPercentile returns the estimated p-th percentile (0-100) response time.
+// See reservoir for the accuracy characteristics on large files.
+func (p *LogParser) GetPercentile(pct float64) int {
+	return p.responseTimes.percentile(pct)
 }
@@ -0,0 +1,345 @@
+// internal/processor/logparser_test.go
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"event-pipeline/internal/models"
+)
+
+// parseString runs a new parser over input and fails the test on error
+func parseString(t *testing.T, cfg ParserConfig, input string) *LogParser {
+	t.Helper()
+	p := NewLogParser(cfg)
+	if _, err := p.Parse(strings.NewReader(input)); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return p
+}
+
+func TestMinResponseTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantMin int
+		wantMax int
+	}{
+		{
+			name:    "single line",
+			input:   `{"level":"INFO","endpoint":"/a","response_time_ms":42}` + "\n",
+			wantMin: 42,
+			wantMax: 42,
+		},
+		{
+			name: "first value is not the minimum",
+			input: `{"level":"INFO","endpoint":"/a","response_time_ms":300}
+{"level":"INFO","endpoint":"/a","response_time_ms":7}
+{"level":"INFO","endpoint":"/a","response_time_ms":90}
+`,
+			wantMin: 7,
+			wantMax: 300,
+		},
+		{
+			// Nothing aggregated must leave zero, not a sentinel
+			name:  "all skipped",
+			input: "not json\n{\"level\":\"INFO\",\"response_time_ms\":-5}\n\n",
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, ParserConfig{}, tt.input).Result("job")
+			if result.MinResponseTimeMs != tt.wantMin || result.MaxResponseTimeMs != tt.wantMax {
+				t.Errorf("min %d max %d, want min %d max %d", result.MinResponseTimeMs, result.MaxResponseTimeMs, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestMalformedLinesCountedSeparately(t *testing.T) {
+	input := `{"level":"INFO","endpoint":"/a","response_time_ms":10}
+this is not json
+{"level":"WARN","endpoint":"/a","response_time_ms":20}
+{"level":"ERROR","endpoint":"/b","response_time_ms":30
+{"level":"ERROR","endpoint":"/b","response_time_ms":40}
+`
+	result := parseString(t, ParserConfig{}, input).Result("job")
+
+	if result.MalformedLineCount != 2 {
+		t.Errorf("MalformedLineCount = %d, want 2", result.MalformedLineCount)
+	}
+	if result.WarnCount != 1 || result.InfoCount != 1 || result.ErrorCount != 1 {
+		t.Errorf("levels warn=%d info=%d error=%d, want 1 each", result.WarnCount, result.InfoCount, result.ErrorCount)
+	}
+	if result.LineCount != 5 {
+		t.Errorf("LineCount = %d, want 5", result.LineCount)
+	}
+}
+
+func TestLevelsCaseInsensitive(t *testing.T) {
+	input := `{"level":"info","endpoint":"/a","response_time_ms":10}
+{"level":"Error","endpoint":"/a","response_time_ms":10}
+{"level":"WARN","endpoint":"/a","response_time_ms":10}
+{"level":" warn ","endpoint":"/a","response_time_ms":10}
+{"level":"eRRoR","endpoint":"/a","response_time_ms":10}
+{"level":"Debug","endpoint":"/a","response_time_ms":10}
+{"level":"Trace","endpoint":"/a","response_time_ms":10}
+`
+	result := parseString(t, ParserConfig{}, input).Result("job")
+
+	if result.InfoCount != 1 || result.ErrorCount != 2 || result.WarnCount != 2 || result.DebugCount != 1 {
+		t.Errorf("levels info=%d error=%d warn=%d debug=%d, want 1 2 2 1",
+			result.InfoCount, result.ErrorCount, result.WarnCount, result.DebugCount)
+	}
+	if result.UnknownLevelCount != 1 {
+		t.Errorf("UnknownLevelCount = %d, want 1", result.UnknownLevelCount)
+	}
+}
+
+func TestInputFormats(t *testing.T) {
+	const (
+		line1 = `{"level":"INFO","endpoint":"/a","response_time_ms":10}`
+		line2 = `{"level":"ERROR","endpoint":"/b","response_time_ms":20}`
+		line3 = `{"level":"WARN","endpoint":"/c","response_time_ms":30}`
+	)
+
+	tests := []struct {
+		name          string
+		format        string
+		input         string
+		wantErr       bool
+		wantLines     int
+		wantProcessed int
+		wantMalformed int
+	}{
+		{
+			name:          "well-formed array",
+			input:         "\n  [" + line1 + ",\n" + line2 + ",\n" + line3 + "]\n",
+			wantLines:     3,
+			wantProcessed: 3,
+		},
+		{
+			// Elements before the break are kept for a partial result
+			name:          "truncated array",
+			input:         "[" + line1 + "," + line2 + `,{"level":"WA`,
+			wantErr:       true,
+			wantLines:     2,
+			wantProcessed: 2,
+		},
+		{
+			name:          "unterminated array",
+			input:         "[" + line1 + "," + line2,
+			wantErr:       true,
+			wantLines:     2,
+			wantProcessed: 2,
+		},
+		{
+			name:          "array element of the wrong type",
+			input:         "[" + line1 + `,42,` + line2 + "]",
+			wantLines:     3,
+			wantProcessed: 2,
+			wantMalformed: 1,
+		},
+		{
+			name:          "NDJSON by default",
+			input:         line1 + "\n" + line2 + "\n" + line3 + "\n",
+			wantLines:     3,
+			wantProcessed: 3,
+		},
+		{
+			// Forced NDJSON reads an array as one malformed line
+			name:          "forced NDJSON",
+			format:        FormatNDJSON,
+			input:         "[" + line1 + "," + line2 + "]\n",
+			wantLines:     1,
+			wantMalformed: 1,
+		},
+		{
+			name:    "forced array on NDJSON",
+			format:  FormatJSONArray,
+			input:   line1 + "\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewLogParser(ParserConfig{InputFormat: tt.format})
+			agg, err := p.Parse(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+			if agg.TotalLines != tt.wantLines || agg.ProcessedLines != tt.wantProcessed || agg.MalformedLineCount != tt.wantMalformed {
+				t.Errorf("lines %d processed %d malformed %d, want %d %d %d",
+					agg.TotalLines, agg.ProcessedLines, agg.MalformedLineCount, tt.wantLines, tt.wantProcessed, tt.wantMalformed)
+			}
+		})
+	}
+}
+
+func TestOversizedLineSkipped(t *testing.T) {
+	const (
+		before = `{"level":"INFO","endpoint":"/a","response_time_ms":10}`
+		after  = `{"level":"ERROR","endpoint":"/b","response_time_ms":30}`
+	)
+	// Longer than the bufio buffer too, so the line is read in several chunks
+	oversized := `{"level":"WARN","endpoint":"/c","message":"` + strings.Repeat("x", 8192) + `"}`
+
+	result := parseString(t, ParserConfig{MaxLineBytes: 100}, before+"\n"+oversized+"\r\n"+after+"\n").Result("job")
+
+	if result.OversizedLineCount != 1 {
+		t.Errorf("OversizedLineCount = %d, want 1", result.OversizedLineCount)
+	}
+	if result.LineCount != 3 || result.MalformedLineCount != 0 {
+		t.Errorf("LineCount = %d, MalformedLineCount = %d, want 3 and 0", result.LineCount, result.MalformedLineCount)
+	}
+	if result.InfoCount != 1 || result.ErrorCount != 1 || result.WarnCount != 0 {
+		t.Errorf("levels info=%d error=%d warn=%d, want the lines around the oversized one only",
+			result.InfoCount, result.ErrorCount, result.WarnCount)
+	}
+	if result.MaxLineBytes != len(after) {
+		t.Errorf("MaxLineBytes = %d, want %d", result.MaxLineBytes, len(after))
+	}
+}
+
+func TestParseErrorKeepsPartialAggregation(t *testing.T) {
+	const (
+		line1 = `{"level":"INFO","endpoint":"/a","response_time_ms":10}`
+		line2 = `{"level":"ERROR","endpoint":"/b","response_time_ms":30}`
+	)
+	readErr := errors.New("connection reset")
+
+	tests := []struct {
+		name    string
+		reader  io.Reader
+		wantErr error
+	}{
+		{
+			// The stream breaks inside the third line
+			name:    "read error",
+			reader:  io.MultiReader(strings.NewReader(line1+"\n"+line2+"\n"+`{"level":"WA`), iotest.ErrReader(readErr)),
+			wantErr: readErr,
+		},
+		{
+			name:   "truncated array",
+			reader: strings.NewReader("[" + line1 + "," + line2 + `,{"level":"WA`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewLogParser(ParserConfig{})
+			agg, err := p.Parse(tt.reader)
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("Parse error = %v, want %v", err, tt.wantErr)
+			}
+			if agg == nil {
+				t.Fatal("Parse returned no aggregation with the error")
+			}
+
+			result := p.Result("job")
+			if result.LineCount != 2 || result.InfoCount != 1 || result.ErrorCount != 1 || result.MaxResponseTimeMs != 30 {
+				t.Errorf("lines %d info %d error %d max %dms, want the 2 lines before the failure",
+					result.LineCount, result.InfoCount, result.ErrorCount, result.MaxResponseTimeMs)
+			}
+		})
+	}
+}
+
+func TestLineAtMaxLineBytesIsParsed(t *testing.T) {
+	line := `{"level":"INFO","endpoint":"/a","response_time_ms":10}`
+	result := parseString(t, ParserConfig{MaxLineBytes: len(line)}, line+"\r\n").Result("job")
+	if result.OversizedLineCount != 0 || result.InfoCount != 1 {
+		t.Errorf("OversizedLineCount = %d, InfoCount = %d, want 0 and 1", result.OversizedLineCount, result.InfoCount)
+	}
+}
+
+func TestMaxLinesTruncates(t *testing.T) {
+	const line = `{"level":"INFO","endpoint":"/a","response_time_ms":10}`
+
+	tests := []struct {
+		name       string
+		input      string
+		maxLines   int
+		wantLines  int
+		wantStatus models.Status
+	}{
+		{
+			name:       "under the cap",
+			input:      strings.Repeat(line+"\n", 3),
+			maxLines:   5,
+			wantLines:  3,
+			wantStatus: models.StatusCompleted,
+		},
+		{
+			// Nothing is left unread, so the result is complete
+			name:       "exactly the cap",
+			input:      strings.Repeat(line+"\n", 5),
+			maxLines:   5,
+			wantLines:  5,
+			wantStatus: models.StatusCompleted,
+		},
+		{
+			name:       "NDJSON over the cap",
+			input:      strings.Repeat(line+"\n", 8),
+			maxLines:   5,
+			wantLines:  5,
+			wantStatus: models.StatusTruncated,
+		},
+		{
+			name:       "array over the cap",
+			input:      "[" + strings.Repeat(line+",", 7) + line + "]",
+			maxLines:   5,
+			wantLines:  5,
+			wantStatus: models.StatusTruncated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, ParserConfig{MaxLines: tt.maxLines}, tt.input).Result("job")
+			if result.Status != tt.wantStatus || result.LineCount != tt.wantLines {
+				t.Errorf("status %q with %d lines, want %q with %d", result.Status, result.LineCount, tt.wantStatus, tt.wantLines)
+			}
+			if result.InfoCount != tt.wantLines {
+				t.Errorf("InfoCount = %d, want only the lines before the cap (%d)", result.InfoCount, tt.wantLines)
+			}
+		})
+	}
+}
+
+// benchmarkLogs is 10,000 flat NDJSON lines in the shape producers send
+var benchmarkLogs = func() string {
+	levels := []string{"INFO", "INFO", "INFO", "WARN", "ERROR", "DEBUG"}
+	var b strings.Builder
+	for i := range 10000 {
+		fmt.Fprintf(&b, `{"timestamp":"2024-01-15T10:%02d:%02dZ","level":"%s","endpoint":"/api/v1/resource/%d","user_id":"user-%d","message":"request handled","response_time_ms":%d,"status_code":%d,"bytes_sent":%d}`+"\n",
+			i/60%60, i%60, levels[i%len(levels)], i%40, i%500, 5+i*7%900, 200+i%5*100, 256+i%4096)
+	}
+	return b.String()
+}()
+
+func BenchmarkParse(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		cfg  ParserConfig
+	}{
+		{name: "encoding/json", cfg: ParserConfig{}},
+		{name: "FastJSON", cfg: ParserConfig{FastJSON: true}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(benchmarkLogs)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := NewLogParser(bm.cfg).Parse(strings.NewReader(benchmarkLogs)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
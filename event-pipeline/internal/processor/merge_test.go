@@ -0,0 +1,109 @@
+// internal/processor/merge_test.go
+package processor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// mergeConfig fills every map Merge folds, so the comparison covers them
+var mergeConfig = ParserConfig{
+	MaxTrackedUsers:      100,
+	MaxTimeSeriesMinutes: 100,
+	MaxSampleErrors:      10,
+}
+
+// aggregate parses input with mergeConfig and returns its aggregation
+func aggregate(t *testing.T, input string) *models.LogAggregation {
+	t.Helper()
+	agg, err := NewLogParser(mergeConfig).Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return agg
+}
+
+func TestMergeMatchesConcatenatedParse(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{
+			name: "overlapping users",
+			a: `{"timestamp":"2024-01-15T10:00:00Z","level":"INFO","endpoint":"/a","user_id":"u1","response_time_ms":40,"status_code":200}
+{"timestamp":"2024-01-15T10:01:00Z","level":"ERROR","endpoint":"/b","user_id":"u2","response_time_ms":900,"status_code":500,"message":"boom"}
+`,
+			b: `{"timestamp":"2024-01-15T10:01:30Z","level":"WARN","endpoint":"/a","user_id":"u2","response_time_ms":15,"status_code":404}
+{"timestamp":"2024-01-15T10:03:00Z","level":"ERROR","endpoint":"/c","user_id":"u1","response_time_ms":300,"status_code":503,"message":"boom"}
+not json
+`,
+		},
+		{
+			name: "disjoint users",
+			a: `{"timestamp":"2024-01-15T09:00:00Z","level":"INFO","endpoint":"/a","user_id":"u1","response_time_ms":5}
+{"timestamp":"2024-01-15T09:00:10Z","level":"DEBUG","endpoint":"/a","user_id":"u2","response_time_ms":70}
+`,
+			b: `{"timestamp":"2024-01-15T11:00:00Z","level":"INFO","endpoint":"/d","user_id":"u3","response_time_ms":2000}
+{"timestamp":"2024-01-15T11:00:20Z","level":"ERROR","endpoint":"/a","user_id":"u4","response_time_ms":1,"message":"timeout"}
+`,
+		},
+		{
+			// An empty side must not pull the minimum down to its zero value
+			name: "empty side",
+			a:    "",
+			b: `{"level":"INFO","endpoint":"/a","user_id":"u1","response_time_ms":250}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := aggregate(t, tt.a)
+			merged.Merge(aggregate(t, tt.b))
+
+			whole := NewLogParser(mergeConfig)
+			want, err := whole.Parse(strings.NewReader(tt.a + tt.b))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if !reflect.DeepEqual(merged, want) {
+				t.Errorf("merged aggregation differs from parsing both inputs\nmerged: %+v\nwhole:  %+v", merged, want)
+			}
+
+			// The whole parse's reservoir holds every value, so its extremes
+			// are exactly the merged minimum and maximum
+			if lo, hi := whole.GetPercentile(0), whole.GetPercentile(100); merged.MinResponseMs != lo || merged.MaxResponseMs != hi {
+				t.Errorf("merged min %d max %d, want p0 %d p100 %d", merged.MinResponseMs, merged.MaxResponseMs, lo, hi)
+			}
+		})
+	}
+}
+
+func TestMergeBoundsSampledPercentiles(t *testing.T) {
+	// Together the inputs overflow the reservoir, so percentiles come
+	// from a sample but must still fall within the merged range
+	var a, b strings.Builder
+	for i := range DefaultReservoirSize {
+		fmt.Fprintf(&a, `{"level":"INFO","endpoint":"/a","user_id":"u%d","response_time_ms":%d}`+"\n", i%50, 10+i%400)
+		fmt.Fprintf(&b, `{"level":"INFO","endpoint":"/b","user_id":"u%d","response_time_ms":%d}`+"\n", 25+i%50, 300+i%900)
+	}
+
+	merged := aggregate(t, a.String())
+	merged.Merge(aggregate(t, b.String()))
+	if merged.MinResponseMs != 10 || merged.MaxResponseMs != 1199 {
+		t.Fatalf("merged min %d max %d, want 10 and 1199", merged.MinResponseMs, merged.MaxResponseMs)
+	}
+	if got := len(merged.UniqueUsers); got != 75 {
+		t.Errorf("merged %d unique users, want 75", got)
+	}
+
+	whole := parseString(t, mergeConfig, a.String()+b.String())
+	for _, pct := range []float64{0, 1, 50, 95, 99, 100} {
+		if got := whole.GetPercentile(pct); got < merged.MinResponseMs || got > merged.MaxResponseMs {
+			t.Errorf("p%v = %d, outside merged range [%d, %d]", pct, got, merged.MinResponseMs, merged.MaxResponseMs)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// internal/processor/parser.go
+package processor
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"event-pipeline/internal/models"
+)
+
+// Parser aggregates log streams into statistics and a result. LogParser is
+// the built-in implementation; others (CSV, protobuf, fakes) can be added
+// with RegisterParser.
+type Parser interface {
+	// Parse reads one file into the aggregation. It may be called once per
+	// file to combine several files, and returns the partial aggregation
+	// alongside any error.
+	Parse(reader io.Reader) (*models.LogAggregation, error)
+
+	GetAverageResponseTime() float64
+
+	// Result summarizes everything parsed so far as a completed result
+	Result(jobID string) models.ProcessingResult
+}
+
+var _ Parser = (*LogParser)(nil)
+
+// ParserFactory creates a Parser for one job
+type ParserFactory func(cfg ParserConfig) Parser
+
+// Built-in parsers: DefaultParser names LogParser, and CSVParser the
+// delimited-row parser from NewDelimitedParser
+const (
+	DefaultParser = "log"
+	CSVParser     = "csv"
+)
+
+// parsers holds the registered factories by name. It is only written by
+// RegisterParser from init functions, so reads need no locking.
+var parsers = map[string]ParserFactory{
+	DefaultParser: func(cfg ParserConfig) Parser { return NewLogParser(cfg) },
+	CSVParser:     func(cfg ParserConfig) Parser { return NewDelimitedParser(cfg) },
+}
+
+// RegisterParser makes factory available under name for ParserFor. Call it
+// from an init function; registering a name twice replaces the first.
+func RegisterParser(name string, factory ParserFactory) {
+	parsers[name] = factory
+}
+
+// ParserFor returns the factory registered under name, or the LogParser
+// factory when name is empty
+func ParserFor(name string) (ParserFactory, error) {
+	if name == "" {
+		name = DefaultParser
+	}
+	factory, ok := parsers[name]
+	if !ok {
+		names := make([]string, 0, len(parsers))
+		for n := range parsers {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("unknown parser %q, want one of %s", name, strings.Join(names, ", "))
+	}
+	return factory, nil
+}
@@ -0,0 +1,78 @@
+// internal/processor/percentile.go
+package processor
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// DefaultReservoirSize is the number of response times kept for percentile estimation
+const DefaultReservoirSize = 10000
+
+// reservoir holds a fixed-size uniform random sample of observed values
+// (Vitter's Algorithm R), so memory stays constant regardless of file size.
+//
+// Accuracy tradeoff: while fewer than size values have been observed the
+// sample holds every value and percentiles are exact. Past that point each
+// percentile is estimated from the sample, with a rank error of roughly
+// sqrt(p*(1-p)/size). With the default size of 10,000 that is about
+// ±0.5 percentile points at p50 and ±0.1 at p99. Extreme tails (p99.9+)
+// of very large files are the least reliable.
+type reservoir struct {
+	size    int
+	seen    int64
+	samples []int
+	sorted  bool
+	rng     *rand.Rand
+}
+
+// newReservoir creates a reservoir holding at most size samples
+func newReservoir(size int) *reservoir {
+	if size <= 0 {
+		size = DefaultReservoirSize
+	}
+	return &reservoir{
+		size:    size,
+		samples: make([]int, 0, min(size, 1024)),
+		// Fixed seed keeps results reproducible for the same input
+		rng: rand.New(rand.NewPCG(1, 2)),
+	}
+}
+
+// add records a value in the sample
+func (r *reservoir) add(v int) {
+	r.seen++
+	r.sorted = false
+
+	if len(r.samples) < r.size {
+		r.samples = append(r.samples, v)
+		return
+	}
+
+	// Replace an existing sample with probability size/seen
+	if j := r.rng.Int64N(r.seen); j < int64(r.size) {
+		r.samples[j] = v
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile (0-100) of the sample
+func (r *reservoir) percentile(p float64) int {
+	if len(r.samples) == 0 {
+		return 0
+	}
+	if !r.sorted {
+		sort.Ints(r.samples)
+		r.sorted = true
+	}
+
+	if p <= 0 {
+		return r.samples[0]
+	}
+	if p >= 100 {
+		return r.samples[len(r.samples)-1]
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(r.samples))))
+	return r.samples[rank-1]
+}
@@ -0,0 +1,81 @@
+// internal/processor/regex.go
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"event-pipeline/internal/models"
+)
+
+// ValidatePattern checks that a line pattern has at least one usable named
+// capture group. Recognized groups: timestamp, level, endpoint,
+// response_time_ms, status_code, user_id, bytes_sent and message.
+func ValidatePattern(re *regexp.Regexp) error {
+	for _, name := range re.SubexpNames() {
+		switch name {
+		case "timestamp", "level", "endpoint", "response_time_ms", "status_code", "user_id", "bytes_sent", "message":
+			return nil
+		}
+	}
+	return fmt.Errorf("pattern %q has no recognized named capture groups", re.String())
+}
+
+// parseRegexLine maps the named captures of a matching line into a LogEntry.
+// The response time is read from the group named by
+// ParserConfig.ResponseTimeField and converted from ResponseTimeUnit, as
+// for JSON input.
+func (p *LogParser) parseRegexLine(line []byte) (models.LogEntry, error) {
+	var entry models.LogEntry
+
+	re := p.config.LinePattern
+	match := re.FindSubmatch(line)
+	if match == nil {
+		return entry, fmt.Errorf("line does not match pattern")
+	}
+
+	for i, name := range re.SubexpNames() {
+		if i == 0 || name == "" || match[i] == nil {
+			continue
+		}
+		value := string(match[i])
+
+		switch name {
+		case "timestamp":
+			entry.Timestamp = value
+		case "level":
+			entry.Level = value
+		case "endpoint":
+			entry.Endpoint = value
+		case "user_id":
+			entry.UserID = value
+		case "message":
+			entry.Message = value
+		case p.config.ResponseTimeField:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return entry, fmt.Errorf("invalid %s %q: %w", name, value, err)
+			}
+			ms, ok := p.toMilliseconds(n)
+			if !ok {
+				return entry, fmt.Errorf("%w: %s %s", errResponseTimeOutOfRange, name, value)
+			}
+			entry.ResponseTimeMs = ms
+		case "status_code":
+			code, err := strconv.Atoi(value)
+			if err != nil {
+				return entry, fmt.Errorf("invalid status_code %q: %w", value, err)
+			}
+			entry.StatusCode = code
+		case "bytes_sent":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return entry, fmt.Errorf("invalid bytes_sent %q: %w", value, err)
+			}
+			entry.BytesSent = n
+		}
+	}
+
+	return entry, nil
+}
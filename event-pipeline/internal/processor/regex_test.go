@@ -0,0 +1,78 @@
+// internal/processor/regex_test.go
+package processor
+
+import (
+	"regexp"
+	"testing"
+)
+
+// logfmtPattern is the README's example pattern for logfmt lines
+var logfmtPattern = regexp.MustCompile(`ts=(?P<timestamp>\S+) level=(?P<level>\S+) endpoint=(?P<endpoint>\S+) response_time_ms=(?P<response_time_ms>\S+) status_code=(?P<status_code>\S+) user_id=(?P<user_id>\S+)`)
+
+func TestLogfmtPattern(t *testing.T) {
+	input := `ts=2024-01-15T10:00:00Z level=INFO endpoint=/api/users response_time_ms=45 status_code=200 user_id=user_1
+ts=2024-01-15T10:00:01Z level=ERROR endpoint=/api/orders response_time_ms=900 status_code=500 user_id=user_2
+ts=2024-01-15T10:00:02Z level=WARN endpoint=/api/users response_time_ms=120 status_code=404 user_id=user_1
+ts=2024-01-15T10:00:03Z level=INFO endpoint=/api/users response_time_ms=fast status_code=200 user_id=user_3
+ts=2024-01-15T10:00:04Z level=INFO endpoint=/api/users response_time_ms=30 status_code=OK user_id=user_3
+{"level":"INFO","endpoint":"/api/users","response_time_ms":45}
+`
+	result := parseString(t, ParserConfig{LinePattern: logfmtPattern}, input).Result("job")
+
+	// A non-numeric response time or status, and a line the pattern
+	// doesn't match, are malformed
+	if result.LineCount != 6 || result.MalformedLineCount != 3 {
+		t.Errorf("lines %d malformed %d, want 6 and 3", result.LineCount, result.MalformedLineCount)
+	}
+	if result.InfoCount != 1 || result.ErrorCount != 1 || result.WarnCount != 1 {
+		t.Errorf("INFO %d ERROR %d WARN %d, want 1 each", result.InfoCount, result.ErrorCount, result.WarnCount)
+	}
+	if result.MaxResponseTimeMs != 900 || result.Status5xx != 1 || result.UniqueUsers != 2 || result.UniqueEndpoints != 2 {
+		t.Errorf("max %dms, 5xx %d, users %d, endpoints %d; want 900ms, 1, 2, 2",
+			result.MaxResponseTimeMs, result.Status5xx, result.UniqueUsers, result.UniqueEndpoints)
+	}
+}
+
+func TestParseRegexLine(t *testing.T) {
+	p := NewLogParser(ParserConfig{LinePattern: logfmtPattern})
+
+	tests := []struct {
+		name    string
+		line    string
+		wantErr bool
+	}{
+		{name: "match", line: "ts=2024-01-15T10:00:00Z level=INFO endpoint=/a response_time_ms=45 status_code=200 user_id=u1"},
+		{name: "non-numeric response time", line: "ts=2024-01-15T10:00:00Z level=INFO endpoint=/a response_time_ms=slow status_code=200 user_id=u1", wantErr: true},
+		{name: "non-numeric status", line: "ts=2024-01-15T10:00:00Z level=INFO endpoint=/a response_time_ms=45 status_code=OK user_id=u1", wantErr: true},
+		{name: "no match", line: "level=INFO endpoint=/a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := p.parseRegexLine([]byte(tt.line))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRegexLine = %+v, want error", entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRegexLine: %v", err)
+			}
+			if entry.Timestamp != "2024-01-15T10:00:00Z" || entry.Level != "INFO" || entry.Endpoint != "/a" ||
+				entry.ResponseTimeMs != 45 || entry.StatusCode != 200 || entry.UserID != "u1" {
+				t.Errorf("entry = %+v, want every group mapped", entry)
+			}
+		})
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	if err := ValidatePattern(logfmtPattern); err != nil {
+		t.Errorf("ValidatePattern(logfmt): %v", err)
+	}
+	for _, pattern := range []string{`level=(\S+)`, `(?P<severity>\S+)`} {
+		if err := ValidatePattern(regexp.MustCompile(pattern)); err == nil {
+			t.Errorf("ValidatePattern(%q) accepted a pattern without recognized groups", pattern)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// internal/processor/required.go
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"event-pipeline/internal/models"
+)
+
+// fieldPresent reports whether a LogEntry field holds a value. Numeric
+// fields count as missing when zero, since JSON decoding can't tell an
+// absent number from 0.
+var fieldPresent = map[string]func(*models.LogEntry) bool{
+	"timestamp":        func(e *models.LogEntry) bool { return e.Timestamp != "" },
+	"level":            func(e *models.LogEntry) bool { return strings.TrimSpace(e.Level) != "" },
+	"endpoint":         func(e *models.LogEntry) bool { return e.Endpoint != "" },
+	"response_time_ms": func(e *models.LogEntry) bool { return e.ResponseTimeMs != 0 },
+	"status_code":      func(e *models.LogEntry) bool { return e.StatusCode != 0 },
+	"user_id":          func(e *models.LogEntry) bool { return e.UserID != "" },
+	"bytes_sent":       func(e *models.LogEntry) bool { return e.BytesSent != 0 },
+	"message":          func(e *models.LogEntry) bool { return e.Message != "" },
+}
+
+// ValidateRequiredFields checks that every name is a known LogEntry field,
+// using the JSON field names (user_id, status_code, ...)
+func ValidateRequiredFields(names []string) error {
+	for _, name := range names {
+		if _, ok := fieldPresent[name]; !ok {
+			return fmt.Errorf("unknown required field %q", name)
+		}
+	}
+	return nil
+}
+
+// hasRequiredFields reports whether entry has every ParserConfig.RequiredFields
+// field. Unknown names are ignored; see ValidateRequiredFields.
+func (p *LogParser) hasRequiredFields(entry *models.LogEntry) bool {
+	for _, name := range p.config.RequiredFields {
+		if present, ok := fieldPresent[name]; ok && !present(entry) {
+			return false
+		}
+	}
+	return true
+}
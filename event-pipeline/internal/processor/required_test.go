@@ -0,0 +1,55 @@
+// internal/processor/required_test.go
+package processor
+
+import "testing"
+
+func TestRequiredUserID(t *testing.T) {
+	// Half the lines have no user_id; three distinct users among the rest
+	input := `{"level":"INFO","endpoint":"/a","response_time_ms":10,"user_id":"u1"}
+{"level":"INFO","endpoint":"/a","response_time_ms":10}
+{"level":"INFO","endpoint":"/b","response_time_ms":10,"user_id":"u2"}
+{"level":"ERROR","endpoint":"/b","response_time_ms":10,"user_id":""}
+{"level":"INFO","endpoint":"/a","response_time_ms":10,"user_id":"u1"}
+{"level":"WARN","endpoint":"/c","response_time_ms":10}
+{"level":"INFO","endpoint":"/c","response_time_ms":10,"user_id":"u3"}
+{"level":"INFO","endpoint":"/d","response_time_ms":10}
+`
+
+	tests := []struct {
+		name           string
+		cfg            ParserConfig
+		wantInvalid    int
+		wantAggregated int
+		wantEndpoints  int
+	}{
+		// Entries without a user are still aggregated, but add no user
+		{name: "optional", cfg: ParserConfig{}, wantAggregated: 8, wantEndpoints: 4},
+		{name: "required", cfg: ParserConfig{RequiredFields: []string{"user_id"}}, wantInvalid: 4, wantAggregated: 4, wantEndpoints: 3},
+		{name: "required approximate", cfg: ParserConfig{RequiredFields: []string{"user_id"}, ApproximateUniques: true}, wantInvalid: 4, wantAggregated: 4, wantEndpoints: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, tt.cfg, input).Result("job")
+
+			if result.LineCount != 8 || result.MalformedLineCount != 0 {
+				t.Errorf("LineCount = %d, MalformedLineCount = %d, want 8 and 0", result.LineCount, result.MalformedLineCount)
+			}
+			if result.InvalidEntryCount != tt.wantInvalid || result.AggregatedLineCount != tt.wantAggregated {
+				t.Errorf("invalid %d aggregated %d, want %d and %d",
+					result.InvalidEntryCount, result.AggregatedLineCount, tt.wantInvalid, tt.wantAggregated)
+			}
+			if result.UniqueUsers != 3 || result.UniqueEndpoints != tt.wantEndpoints {
+				t.Errorf("UniqueUsers = %d, UniqueEndpoints = %d, want 3 and %d", result.UniqueUsers, result.UniqueEndpoints, tt.wantEndpoints)
+			}
+		})
+	}
+}
+
+func TestValidateRequiredFields(t *testing.T) {
+	if err := ValidateRequiredFields([]string{"user_id", "status_code", "bytes_sent"}); err != nil {
+		t.Errorf("ValidateRequiredFields(known fields) = %v", err)
+	}
+	if err := ValidateRequiredFields([]string{"user_id", "userId"}); err == nil {
+		t.Error("ValidateRequiredFields accepted the unknown field userId")
+	}
+}
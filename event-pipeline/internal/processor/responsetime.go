@@ -0,0 +1,87 @@
+// internal/processor/responsetime.go
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Units for ParserConfig.ResponseTimeUnit
+const (
+	UnitMilliseconds = "ms"
+	UnitMicroseconds = "us"
+	UnitSeconds      = "s"
+)
+
+// DefaultMaxResponseTimeMs is the longest plausible response time (1 hour)
+const DefaultMaxResponseTimeMs = 60 * 60 * 1000
+
+// responseTimeKey is where LogEntry normally reads its response time
+const responseTimeKey = "response_time_ms"
+
+// errResponseTimeOutOfRange marks an entry whose response time is negative
+// or above MaxResponseTimeMs; it is counted and left out of aggregation
+var errResponseTimeOutOfRange = errors.New("response time out of range")
+
+// msPerUnit converts each unit to milliseconds
+var msPerUnit = map[string]float64{
+	UnitMilliseconds: 1,
+	UnitMicroseconds: 0.001,
+	UnitSeconds:      1000,
+}
+
+// ValidateResponseTimeUnit checks that unit is empty or a known unit
+func ValidateResponseTimeUnit(unit string) error {
+	if _, ok := msPerUnit[unit]; unit != "" && !ok {
+		return fmt.Errorf("unknown response time unit %q, want ms, us or s", unit)
+	}
+	return nil
+}
+
+// convertsResponseTime reports whether JSON response times need more than
+// LogEntry's own decoding: a different key or unit
+func (c ParserConfig) convertsResponseTime() bool {
+	return c.ResponseTimeField != responseTimeKey || c.ResponseTimeUnit != UnitMilliseconds
+}
+
+// normalizeResponseTime moves the configured response-time value out of
+// fields, converted to whole milliseconds. mapped is true when a schema
+// already moved the value to responseTimeKey. A missing value is 0.
+func (p *LogParser) normalizeResponseTime(fields map[string]json.RawMessage, mapped bool) (int, error) {
+	key := p.config.ResponseTimeField
+	if mapped {
+		key = responseTimeKey
+	}
+	value, ok := fields[key]
+	delete(fields, responseTimeKey)
+	if !ok || string(value) == "null" {
+		return 0, nil
+	}
+
+	var n float64
+	if err := json.Unmarshal(value, &n); err != nil {
+		return 0, fmt.Errorf("invalid %s %s: %w", key, value, err)
+	}
+	ms, ok := p.toMilliseconds(n)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s %s", errResponseTimeOutOfRange, key, value)
+	}
+	return ms, nil
+}
+
+// toMilliseconds converts n, in ResponseTimeUnit, to whole milliseconds.
+// It reports false when the result lies outside [0, MaxResponseTimeMs].
+func (p *LogParser) toMilliseconds(n float64) (int, bool) {
+	ms := n * msPerUnit[p.config.ResponseTimeUnit]
+	if math.IsNaN(ms) || ms < 0 || ms > float64(p.config.MaxResponseTimeMs) {
+		return 0, false
+	}
+	return int(math.Round(ms)), true
+}
+
+// responseTimeInRange reports whether ms lies within [0, MaxResponseTimeMs]
+func (p *LogParser) responseTimeInRange(ms int) bool {
+	return ms >= 0 && ms <= p.config.MaxResponseTimeMs
+}
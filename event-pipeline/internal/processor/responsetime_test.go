@@ -0,0 +1,97 @@
+// internal/processor/responsetime_test.go
+package processor
+
+import (
+	"regexp"
+	"testing"
+)
+
+// durationPattern reads logfmt lines timing requests under "duration"
+var durationPattern = regexp.MustCompile(`level=(?P<level>\w+) path=(?P<endpoint>\S+) duration=(?P<duration>-?[0-9.]+)`)
+
+func TestResponseTimeConversion(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ParserConfig
+		input   string
+		wantMax int // largest converted response time, in ms
+		wantBad int
+	}{
+		{
+			name: "json seconds",
+			cfg:  ParserConfig{ResponseTimeField: "duration", ResponseTimeUnit: UnitSeconds},
+			input: `{"level":"INFO","endpoint":"/a","duration":0.25}
+{"level":"INFO","endpoint":"/a","duration":1.5}
+`,
+			wantMax: 1500,
+		},
+		{
+			name:    "json microseconds",
+			cfg:     ParserConfig{ResponseTimeField: "duration", ResponseTimeUnit: UnitMicroseconds},
+			input:   `{"level":"INFO","endpoint":"/a","duration":2600}` + "\n",
+			wantMax: 3,
+		},
+		{
+			// Over an hour, and negative, are skipped rather than aggregated
+			name: "json out of range",
+			cfg:  ParserConfig{ResponseTimeField: "duration", ResponseTimeUnit: UnitSeconds},
+			input: `{"level":"INFO","endpoint":"/a","duration":0.1}
+{"level":"INFO","endpoint":"/a","duration":3601}
+{"level":"INFO","endpoint":"/a","duration":-1}
+`,
+			wantMax: 100,
+			wantBad: 2,
+		},
+		{
+			name:    "pattern seconds",
+			cfg:     ParserConfig{LinePattern: durationPattern, ResponseTimeField: "duration", ResponseTimeUnit: UnitSeconds},
+			input:   "level=INFO path=/a duration=0.25\nlevel=INFO path=/a duration=1.5\n",
+			wantMax: 1500,
+		},
+		{
+			name:    "pattern out of range",
+			cfg:     ParserConfig{LinePattern: durationPattern, ResponseTimeField: "duration", ResponseTimeUnit: UnitSeconds},
+			input:   "level=INFO path=/a duration=0.1\nlevel=INFO path=/a duration=7200\nlevel=INFO path=/a duration=-0.5\n",
+			wantMax: 100,
+			wantBad: 2,
+		},
+		{
+			name: "pattern custom limit",
+			cfg: ParserConfig{
+				LinePattern:       regexp.MustCompile(`level=(?P<level>\w+) path=(?P<endpoint>\S+) ms=(?P<response_time_ms>\d+)`),
+				MaxResponseTimeMs: 1000,
+			},
+			input:   "level=INFO path=/a ms=999\nlevel=INFO path=/a ms=1001\n",
+			wantMax: 999,
+			wantBad: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, tt.cfg, tt.input).Result("job")
+			if result.MaxResponseTimeMs != tt.wantMax {
+				t.Errorf("max response time %dms, want %dms", result.MaxResponseTimeMs, tt.wantMax)
+			}
+			if result.BadResponseTimeCount != tt.wantBad {
+				t.Errorf("BadResponseTimeCount = %d, want %d", result.BadResponseTimeCount, tt.wantBad)
+			}
+			if result.MalformedLineCount != 0 {
+				t.Errorf("MalformedLineCount = %d, want 0", result.MalformedLineCount)
+			}
+		})
+	}
+}
+
+func TestPatternIgnoresDefaultGroupForOtherField(t *testing.T) {
+	// With another field configured, a response_time_ms group is not read
+	re := regexp.MustCompile(`level=(?P<level>\w+) ms=(?P<response_time_ms>\d+) duration=(?P<duration>[0-9.]+)`)
+	p := NewLogParser(ParserConfig{LinePattern: re, ResponseTimeField: "duration", ResponseTimeUnit: UnitSeconds})
+
+	entry, err := p.parseRegexLine([]byte("level=INFO ms=5 duration=2"))
+	if err != nil {
+		t.Fatalf("parseRegexLine: %v", err)
+	}
+	if entry.ResponseTimeMs != 2000 {
+		t.Errorf("ResponseTimeMs = %d, want 2000 from duration", entry.ResponseTimeMs)
+	}
+}
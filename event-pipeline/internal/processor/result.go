@@ -0,0 +1,75 @@
+// internal/processor/result.go
+package processor
+
+import "event-pipeline/internal/models"
+
+// resultListSize is how many top endpoints and slowest requests a result keeps
+const resultListSize = 10
+
+// Result summarizes everything parsed so far as a completed ProcessingResult.
+// Callers fill in what the parser can't know: file size, source ETag,
+// timing (see ProcessingResult.SetTiming) and expiry.
+func (p *LogParser) Result(jobID string) models.ProcessingResult {
+	agg := p.aggregation
+	result := models.ProcessingResult{
+		JobID:                 jobID,
+		Status:                models.StatusCompleted,
+		LineCount:             agg.TotalLines,
+		ErrorCount:            agg.ErrorCount,
+		WarnCount:             agg.WarnCount,
+		InfoCount:             agg.InfoCount,
+		DebugCount:            agg.DebugCount,
+		UnknownLevelCount:     agg.UnknownLevelCount,
+		MalformedLineCount:    agg.MalformedLineCount,
+		InvalidEntryCount:     agg.InvalidEntryCount,
+		DuplicateLineCount:    agg.DuplicateLineCount,
+		OversizedLineCount:    agg.OversizedLineCount,
+		UnknownSchemaCount:    agg.UnknownSchemaCount,
+		BadResponseTimeCount:  agg.BadResponseTimeCount,
+		AvgResponseTimeMs:     p.GetAverageResponseTime(),
+		TotalResponseTimeMs:   agg.TotalResponseMs,
+		AggregatedLineCount:   agg.AggregatedLines(),
+		MinResponseTimeMs:     agg.MinResponseMs,
+		MaxResponseTimeMs:     agg.MaxResponseMs,
+		P50ResponseTimeMs:     p.GetPercentile(50),
+		P90ResponseTimeMs:     p.GetPercentile(90),
+		P95ResponseTimeMs:     p.GetPercentile(95),
+		P99ResponseTimeMs:     p.GetPercentile(99),
+		StdDevResponseTimeMs:  p.GetResponseTimeStdDev(),
+		AnomalousRequestCount: agg.AnomalousRequestCount,
+		SampledOutDebugCount:  agg.SampledOutDebugCount,
+		TotalBytesSent:        agg.TotalBytesSent,
+		AvgBytesSent:          p.GetAverageBytesSent(),
+		HTTPErrorRate:         p.GetErrorRate(),
+		HTTP4xxRate:           p.Get4xxRate(),
+		HTTP5xxRate:           p.Get5xxRate(),
+		Status1xx:             agg.Status1xx,
+		Status2xx:             agg.Status2xx,
+		Status3xx:             agg.Status3xx,
+		Status4xx:             agg.Status4xx,
+		Status5xx:             agg.Status5xx,
+		StatusOther:           agg.StatusOther,
+		UniqueUsers:           p.UniqueUserCount(),
+		UniqueEndpoints:       p.UniqueEndpointCount(),
+		MalformedTimestamps:   agg.MalformedTimestampCount,
+		TopEndpoints:          p.TopEndpointsByTraffic(resultListSize),
+		SlowestEndpoints:      p.TopEndpointsByLatency(resultListSize),
+		TopUsers:              p.TopUsers(resultListSize),
+		RequestsPerMinute:     p.GetTimeSeries(),
+		SlowestRequests:       p.GetSlowest(resultListSize),
+		SampleErrors:          agg.SampleErrors,
+		PeakErrorsPerWindow:   agg.PeakErrorsPerWindow,
+		ResponseTimeBuckets:   agg.ResponseTimeBuckets,
+		MaxLineBytes:          agg.MaxLineBytes,
+	}
+
+	if agg.Truncated {
+		result.Status = models.StatusTruncated
+	}
+	if !agg.EarliestTimestamp.IsZero() {
+		earliest, latest := agg.EarliestTimestamp, agg.LatestTimestamp
+		result.EarliestTimestamp = &earliest
+		result.LatestTimestamp = &latest
+	}
+	return result
+}
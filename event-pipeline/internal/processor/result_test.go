@@ -0,0 +1,70 @@
+// internal/processor/result_test.go
+package processor
+
+import "testing"
+
+func TestResultResponseTotals(t *testing.T) {
+	// Half the DEBUG entries are sampled out, so the average covers fewer
+	// entries than line_count
+	input := `{"level":"INFO","endpoint":"/a","response_time_ms":100}
+{"level":"INFO","endpoint":"/a","response_time_ms":200}
+{"level":"DEBUG","endpoint":"/a","response_time_ms":10}
+{"level":"DEBUG","endpoint":"/a","response_time_ms":10}
+{"level":"DEBUG","endpoint":"/a","response_time_ms":10}
+{"level":"DEBUG","endpoint":"/a","response_time_ms":10}
+`
+	result := parseString(t, ParserConfig{DebugSampleRate: 0.5}, input).Result("job")
+
+	if result.LineCount != 6 || result.AggregatedLineCount != 4 {
+		t.Errorf("lines %d aggregated %d, want 6 and 4", result.LineCount, result.AggregatedLineCount)
+	}
+	if result.TotalResponseTimeMs != 320 || result.AvgResponseTimeMs != 80 {
+		t.Errorf("total %dms average %vms, want 320ms and 80ms", result.TotalResponseTimeMs, result.AvgResponseTimeMs)
+	}
+}
+
+func TestResultBytesSent(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantTotal     int64
+		wantAvg       float64
+		wantMalformed int
+	}{
+		{
+			// Lines without bytes_sent add nothing but still count toward the average
+			name: "mixed",
+			input: `{"level":"INFO","endpoint":"/a","response_time_ms":10,"bytes_sent":100}
+{"level":"INFO","endpoint":"/a","response_time_ms":10}
+{"level":"INFO","endpoint":"/a","response_time_ms":10,"bytes_sent":200}
+{"level":"INFO","endpoint":"/a","response_time_ms":10,"bytes_sent":300}
+`,
+			wantTotal: 600,
+			wantAvg:   150,
+		},
+		{
+			name: "absent",
+			input: `{"level":"INFO","endpoint":"/a","response_time_ms":10}
+{"level":"ERROR","endpoint":"/b","response_time_ms":20}
+`,
+		},
+		{
+			name:          "nothing aggregated",
+			input:         "not json\n",
+			wantMalformed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseString(t, ParserConfig{}, tt.input).Result("job")
+			if result.TotalBytesSent != tt.wantTotal || result.AvgBytesSent != tt.wantAvg {
+				t.Errorf("total %d average %v, want %d and %v", result.TotalBytesSent, result.AvgBytesSent, tt.wantTotal, tt.wantAvg)
+			}
+			// A missing bytes_sent is not an error
+			if result.MalformedLineCount != tt.wantMalformed || result.InvalidEntryCount != 0 {
+				t.Errorf("%d malformed and %d invalid lines, want %d and 0",
+					result.MalformedLineCount, result.InvalidEntryCount, tt.wantMalformed)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// internal/processor/sampling.go
+package processor
+
+// sampleDebug reports whether the current DEBUG entry should be fully
+// aggregated under ParserConfig.DebugSampleRate. Every entry is kept when
+// the rate is unset or at least 1.
+//
+// The choice depends only on how many DEBUG entries came before, keeping
+// evenly spaced entries (every 10th at 0.1), so the same input always
+// yields the same sample.
+func (p *LogParser) sampleDebug() bool {
+	rate := p.config.DebugSampleRate
+	if rate <= 0 || rate >= 1 {
+		return true
+	}
+
+	seen := float64(p.debugSeen)
+	p.debugSeen++
+	return int64((seen+1)*rate) > int64(seen*rate)
+}
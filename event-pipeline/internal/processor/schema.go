@@ -0,0 +1,125 @@
+// internal/processor/schema.go
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"event-pipeline/internal/models"
+)
+
+// errUnknownSchema marks a JSON line whose discriminator selects no
+// registered schema; it is counted as UnknownSchemaCount, not malformed
+var errUnknownSchema = errors.New("unregistered schema")
+
+// SchemaMapping renames the JSON keys of one log schema. It maps LogEntry
+// field names (level, endpoint, response_time_ms, ...) to the key holding
+// that field in the schema's lines; unmapped fields keep their usual key.
+type SchemaMapping map[string]string
+
+// ParseSchemas reads schemas from JSON keyed by discriminator value, e.g.
+// {"api": {"response_time_ms": "latency"}, "web": {}}, and validates them
+func ParseSchemas(raw string) (map[string]SchemaMapping, error) {
+	var schemas map[string]SchemaMapping
+	if err := json.Unmarshal([]byte(raw), &schemas); err != nil {
+		return nil, fmt.Errorf("invalid schemas JSON: %w", err)
+	}
+	if err := ValidateSchemas(schemas); err != nil {
+		return nil, err
+	}
+	return schemas, nil
+}
+
+// ValidateSchemas checks that every mapping targets a known LogEntry
+// field, using the JSON field names as in ValidateRequiredFields
+func ValidateSchemas(schemas map[string]SchemaMapping) error {
+	for name, mapping := range schemas {
+		for field, key := range mapping {
+			if _, ok := fieldPresent[field]; !ok {
+				return fmt.Errorf("schema %q maps unknown field %q", name, field)
+			}
+			if key == "" {
+				return fmt.Errorf("schema %q maps %q to an empty key", name, field)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeJSON decodes one JSON entry, applying the schema selected by
+// ParserConfig.SchemaField, the nested ParserConfig.FieldPaths and the
+// response-time key and unit when they differ from LogEntry's own
+func (p *LogParser) decodeJSON(data []byte) (models.LogEntry, error) {
+	if p.config.SchemaField == "" && len(p.config.FieldPaths) == 0 && !p.config.convertsResponseTime() {
+		return p.decodeEntry(data)
+	}
+
+	var entry models.LogEntry
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return entry, err
+	}
+
+	mapped := false
+	if p.config.SchemaField != "" {
+		var err error
+		if fields, mapped, err = p.applySchema(fields); err != nil {
+			return entry, err
+		}
+	}
+	if len(p.config.FieldPaths) > 0 && p.applyFieldPaths(fields) {
+		mapped = true
+	}
+
+	ms := 0
+	if p.config.convertsResponseTime() {
+		var err error
+		if ms, err = p.normalizeResponseTime(fields, mapped); err != nil {
+			return entry, err
+		}
+	}
+
+	remapped, err := json.Marshal(fields)
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(remapped, &entry); err != nil {
+		return entry, err
+	}
+	if p.config.convertsResponseTime() {
+		entry.ResponseTimeMs = ms
+	}
+	return entry, nil
+}
+
+// applySchema renames the keys of raw according to the schema its
+// discriminator selects. mapped reports whether the schema maps the
+// response time.
+func (p *LogParser) applySchema(raw map[string]json.RawMessage) (renamed map[string]json.RawMessage, mapped bool, err error) {
+	var name string
+	if err := json.Unmarshal(raw[p.config.SchemaField], &name); err != nil {
+		return nil, false, fmt.Errorf("%w: %s is missing or not a string", errUnknownSchema, p.config.SchemaField)
+	}
+	mapping, ok := p.config.Schemas[name]
+	if !ok {
+		return nil, false, fmt.Errorf("%w %q", errUnknownSchema, name)
+	}
+
+	// Move each mapped key to the field name LogEntry decodes from. Keys
+	// are read from the original line, so mappings can swap names.
+	renamed = make(map[string]json.RawMessage, len(raw))
+	for key, value := range raw {
+		renamed[key] = value
+	}
+	for field, key := range mapping {
+		if value, ok := raw[key]; ok {
+			renamed[field] = value
+		} else {
+			delete(renamed, field)
+		}
+	}
+	_, mapped = mapping[responseTimeKey]
+	return renamed, mapped, nil
+}
@@ -0,0 +1,88 @@
+// internal/processor/schema_test.go
+package processor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMixedSchemas(t *testing.T) {
+	schemas, err := ParseSchemas(`{
+		"api": {"level": "severity", "endpoint": "path", "response_time_ms": "latency"},
+		"web": {}
+	}`)
+	if err != nil {
+		t.Fatalf("ParseSchemas: %v", err)
+	}
+
+	input := `{"source":"api","severity":"ERROR","path":"/api/orders","latency":480,"status_code":500}
+{"source":"web","level":"INFO","endpoint":"/home","response_time_ms":35,"status_code":200}
+{"source":"api","severity":"INFO","path":"/api/users","latency":120,"level":"WARN"}
+{"source":"web","level":"WARN","endpoint":"/login","response_time_ms":90}
+{"source":"batch","level":"ERROR","endpoint":"/jobs","response_time_ms":9000}
+{"level":"ERROR","endpoint":"/none","response_time_ms":10}
+{"source":7,"level":"ERROR","endpoint":"/seven","response_time_ms":10}
+{"source":"api",
+`
+	p := parseString(t, ParserConfig{SchemaField: "source", Schemas: schemas}, input)
+	result := p.Result("job")
+
+	// Unregistered, missing and non-string discriminators are not malformed
+	if result.UnknownSchemaCount != 3 || result.MalformedLineCount != 1 {
+		t.Errorf("unknown schema %d malformed %d, want 3 and 1", result.UnknownSchemaCount, result.MalformedLineCount)
+	}
+	if result.LineCount != 8 || result.AggregatedLineCount != 4 {
+		t.Errorf("lines %d aggregated %d, want 8 and 4", result.LineCount, result.AggregatedLineCount)
+	}
+	// The api line's own "level" key is replaced by its mapped severity
+	if result.ErrorCount != 1 || result.InfoCount != 2 || result.WarnCount != 1 {
+		t.Errorf("levels error=%d info=%d warn=%d, want 1 2 1", result.ErrorCount, result.InfoCount, result.WarnCount)
+	}
+	if result.MinResponseTimeMs != 35 || result.MaxResponseTimeMs != 480 || result.TotalResponseTimeMs != 725 {
+		t.Errorf("response times min %d max %d total %d, want 35 480 725",
+			result.MinResponseTimeMs, result.MaxResponseTimeMs, result.TotalResponseTimeMs)
+	}
+	var endpoints []string
+	for _, e := range p.TopEndpointsByTraffic(10) {
+		endpoints = append(endpoints, e.Endpoint)
+	}
+	slices.Sort(endpoints)
+	if want := []string{"/api/orders", "/api/users", "/home", "/login"}; !slices.Equal(endpoints, want) {
+		t.Errorf("endpoints = %v, want %v", endpoints, want)
+	}
+}
+
+func TestSchemaMappingSwapsKeys(t *testing.T) {
+	// Each key is read from the original line, so two fields can trade names
+	schemas := map[string]SchemaMapping{"swapped": {"level": "endpoint", "endpoint": "level"}}
+	input := `{"schema":"swapped","level":"/api/users","endpoint":"WARN","response_time_ms":10}` + "\n"
+
+	p := parseString(t, ParserConfig{SchemaField: "schema", Schemas: schemas}, input)
+	result := p.Result("job")
+	if result.WarnCount != 1 || result.UnknownLevelCount != 0 {
+		t.Errorf("warn %d unknown level %d, want 1 and 0", result.WarnCount, result.UnknownLevelCount)
+	}
+	if top := p.TopEndpointsByTraffic(1); len(top) != 1 || top[0].Endpoint != "/api/users" {
+		t.Errorf("top endpoints = %v, want /api/users", top)
+	}
+}
+
+func TestParseSchemasValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{name: "valid", raw: `{"api":{"response_time_ms":"latency"},"web":{}}`},
+		{name: "unknown field", raw: `{"api":{"latency_ms":"latency"}}`, wantErr: true},
+		{name: "empty key", raw: `{"api":{"level":""}}`, wantErr: true},
+		{name: "not JSON", raw: `api=latency`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSchemas(tt.raw); (err != nil) != tt.wantErr {
+				t.Errorf("ParseSchemas(%s) error = %v, want error %v", tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+}
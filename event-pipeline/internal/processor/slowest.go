@@ -0,0 +1,79 @@
+// internal/processor/slowest.go
+package processor
+
+import (
+	"container/heap"
+	"sort"
+
+	"event-pipeline/internal/models"
+)
+
+// DefaultSlowestSize is how many of the slowest entries are kept
+const DefaultSlowestSize = 10
+
+// slowestHeap keeps the size slowest entries seen so far. It is a min-heap on
+// response time, so the root is the fastest entry that still qualifies and
+// is the one evicted when a slower entry arrives.
+type slowestHeap struct {
+	size    int
+	entries []models.LogEntry
+}
+
+func newSlowestHeap(size int) *slowestHeap {
+	if size <= 0 {
+		size = DefaultSlowestSize
+	}
+	return &slowestHeap{size: size, entries: make([]models.LogEntry, 0, size)}
+}
+
+// heap.Interface
+func (h *slowestHeap) Len() int { return len(h.entries) }
+func (h *slowestHeap) Less(i, j int) bool {
+	return h.entries[i].ResponseTimeMs < h.entries[j].ResponseTimeMs
+}
+func (h *slowestHeap) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *slowestHeap) Push(x any)    { h.entries = append(h.entries, x.(models.LogEntry)) }
+func (h *slowestHeap) Pop() any {
+	last := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return last
+}
+
+// add records entry if it is among the slowest seen. Only the fields needed
+// to investigate the request are kept, so memory stays bounded.
+func (h *slowestHeap) add(entry *models.LogEntry) {
+	if len(h.entries) == h.size && entry.ResponseTimeMs <= h.entries[0].ResponseTimeMs {
+		return
+	}
+
+	kept := models.LogEntry{
+		Timestamp:      entry.Timestamp,
+		Endpoint:       entry.Endpoint,
+		ResponseTimeMs: entry.ResponseTimeMs,
+		StatusCode:     entry.StatusCode,
+	}
+	if len(h.entries) < h.size {
+		heap.Push(h, kept)
+		return
+	}
+	h.entries[0] = kept
+	heap.Fix(h, 0)
+}
+
+// GetSlowest returns up to n of the slowest entries, slowest first
+func (p *LogParser) GetSlowest(n int) []models.LogEntry {
+	if n <= 0 {
+		return nil
+	}
+
+	sorted := make([]models.LogEntry, len(p.slowest.entries))
+	copy(sorted, p.slowest.entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ResponseTimeMs > sorted[j].ResponseTimeMs
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
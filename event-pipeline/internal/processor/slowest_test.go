@@ -0,0 +1,52 @@
+// internal/processor/slowest_test.go
+package processor
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func TestGetSlowestKeepsSlowestEntries(t *testing.T) {
+	// 1..200ms in shuffled order, so the slowest arrive at random points
+	times := rand.New(rand.NewPCG(1, 2)).Perm(200)
+	var b strings.Builder
+	for _, i := range times {
+		fmt.Fprintf(&b, `{"level":"INFO","endpoint":"/e%d","response_time_ms":%d,"status_code":200,"user_id":"u"}`+"\n", i+1, i+1)
+	}
+
+	p := parseString(t, ParserConfig{SlowestSize: 5}, b.String())
+
+	got := p.GetSlowest(10)
+	if len(got) != 5 {
+		t.Fatalf("got %d entries, want the 5 kept", len(got))
+	}
+	for i, entry := range got {
+		want := 200 - i
+		if entry.ResponseTimeMs != want || entry.Endpoint != fmt.Sprintf("/e%d", want) || entry.StatusCode != 200 {
+			t.Errorf("entry %d = %+v, want /e%d at %dms", i, entry, want, want)
+		}
+		// Only the fields needed to investigate are kept
+		if entry.UserID != "" || entry.Level != "" {
+			t.Errorf("entry %d kept user %q level %q", i, entry.UserID, entry.Level)
+		}
+	}
+
+	if top := p.GetSlowest(2); len(top) != 2 || top[0].ResponseTimeMs != 200 || top[1].ResponseTimeMs != 199 {
+		t.Errorf("GetSlowest(2) = %+v, want 200ms and 199ms", top)
+	}
+	if none := p.GetSlowest(0); none != nil {
+		t.Errorf("GetSlowest(0) = %+v, want nil", none)
+	}
+}
+
+func TestGetSlowestFewerThanSize(t *testing.T) {
+	p := parseString(t, ParserConfig{}, `{"level":"INFO","response_time_ms":5}
+{"level":"INFO","response_time_ms":50}
+`)
+	got := p.GetSlowest(DefaultSlowestSize)
+	if len(got) != 2 || got[0].ResponseTimeMs != 50 || got[1].ResponseTimeMs != 5 {
+		t.Errorf("GetSlowest = %+v, want 50ms then 5ms", got)
+	}
+}
@@ -0,0 +1,54 @@
+// internal/processor/status.go
+package processor
+
+// GetErrorRate returns the fraction of requests with a status code >= 400
+func (p *LogParser) GetErrorRate() float64 {
+	return p.statusRate(400, 600)
+}
+
+// Get4xxRate returns the fraction of requests with a 4xx status code
+func (p *LogParser) Get4xxRate() float64 {
+	return p.statusRate(400, 500)
+}
+
+// Get5xxRate returns the fraction of requests with a 5xx status code
+func (p *LogParser) Get5xxRate() float64 {
+	return p.statusRate(500, 600)
+}
+
+// trackStatusClass counts code under its class. Codes outside 100-599,
+// including 0 for an entry without one, count as StatusOther.
+func (p *LogParser) trackStatusClass(code int) {
+	agg := p.aggregation
+	switch {
+	case code < 100 || code > 599:
+		agg.StatusOther++
+	case code < 200:
+		agg.Status1xx++
+	case code < 300:
+		agg.Status2xx++
+	case code < 400:
+		agg.Status3xx++
+	case code < 500:
+		agg.Status4xx++
+	default:
+		agg.Status5xx++
+	}
+}
+
+// statusRate returns the fraction of requests with a status code in [lo, hi).
+// Only entries that carried a status code count toward the total.
+func (p *LogParser) statusRate(lo, hi int) float64 {
+	total, matched := 0, 0
+	for code, count := range p.aggregation.StatusCodeCounts {
+		total += count
+		if code >= lo && code < hi {
+			matched += count
+		}
+	}
+
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
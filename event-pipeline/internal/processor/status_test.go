@@ -0,0 +1,54 @@
+// internal/processor/status_test.go
+package processor
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStatusClassBoundaries(t *testing.T) {
+	// Counts per class: 1xx, 2xx, 3xx, 4xx, 5xx, other
+	tests := []struct {
+		code int
+		want [6]int
+	}{
+		{code: 0, want: [6]int{0, 0, 0, 0, 0, 1}},
+		{code: 99, want: [6]int{0, 0, 0, 0, 0, 1}},
+		{code: 100, want: [6]int{1, 0, 0, 0, 0, 0}},
+		{code: 199, want: [6]int{1, 0, 0, 0, 0, 0}},
+		{code: 200, want: [6]int{0, 1, 0, 0, 0, 0}},
+		{code: 299, want: [6]int{0, 1, 0, 0, 0, 0}},
+		{code: 300, want: [6]int{0, 0, 1, 0, 0, 0}},
+		{code: 399, want: [6]int{0, 0, 1, 0, 0, 0}},
+		{code: 400, want: [6]int{0, 0, 0, 1, 0, 0}},
+		{code: 499, want: [6]int{0, 0, 0, 1, 0, 0}},
+		{code: 500, want: [6]int{0, 0, 0, 0, 1, 0}},
+		{code: 599, want: [6]int{0, 0, 0, 0, 1, 0}},
+		{code: 600, want: [6]int{0, 0, 0, 0, 0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			line := fmt.Sprintf(`{"level":"INFO","endpoint":"/a","response_time_ms":10,"status_code":%d}`+"\n", tt.code)
+			result := parseString(t, ParserConfig{}, line).Result("job")
+
+			got := [6]int{result.Status1xx, result.Status2xx, result.Status3xx, result.Status4xx, result.Status5xx, result.StatusOther}
+			if got != tt.want {
+				t.Errorf("status %d counted as %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusRates(t *testing.T) {
+	var input string
+	for _, code := range []int{200, 201, 302, 404, 429, 500, 503, 599} {
+		input += fmt.Sprintf(`{"level":"INFO","endpoint":"/a","response_time_ms":10,"status_code":%d}`+"\n", code)
+	}
+	// No status code: left out of the rates entirely
+	input += `{"level":"INFO","endpoint":"/a","response_time_ms":10}` + "\n"
+
+	result := parseString(t, ParserConfig{}, input).Result("job")
+	if result.HTTP4xxRate != 0.25 || result.HTTP5xxRate != 0.375 || result.HTTPErrorRate != 0.625 {
+		t.Errorf("rates 4xx %v 5xx %v error %v, want 0.25 0.375 0.625", result.HTTP4xxRate, result.HTTP5xxRate, result.HTTPErrorRate)
+	}
+}
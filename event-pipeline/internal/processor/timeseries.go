@@ -0,0 +1,43 @@
+// internal/processor/timeseries.go
+package processor
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"event-pipeline/internal/models"
+)
+
+// trackMinute counts a request in the minute of ts when the time series is
+// enabled. Once ParserConfig.MaxTimeSeriesMinutes distinct minutes are
+// tracked, requests in new minutes are left out.
+func (p *LogParser) trackMinute(ts time.Time) {
+	if p.config.MaxTimeSeriesMinutes <= 0 {
+		return
+	}
+
+	minute := ts.Truncate(time.Minute).Unix() / 60
+	counts := p.aggregation.RequestsPerMinute
+	if _, ok := counts[minute]; !ok && len(counts) >= p.config.MaxTimeSeriesMinutes {
+		return
+	}
+	counts[minute]++
+}
+
+// GetTimeSeries returns request counts per minute in ascending order,
+// omitting minutes without requests, or nil when the time series is disabled
+func (p *LogParser) GetTimeSeries() []models.MinuteCount {
+	if len(p.aggregation.RequestsPerMinute) == 0 {
+		return nil
+	}
+
+	series := make([]models.MinuteCount, 0, len(p.aggregation.RequestsPerMinute))
+	for minute, count := range p.aggregation.RequestsPerMinute {
+		series = append(series, models.MinuteCount{Minute: minute, Count: count})
+	}
+	slices.SortFunc(series, func(a, b models.MinuteCount) int {
+		return cmp.Compare(a.Minute, b.Minute)
+	})
+	return series
+}
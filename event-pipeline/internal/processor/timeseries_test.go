@@ -0,0 +1,79 @@
+// internal/processor/timeseries_test.go
+package processor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"event-pipeline/internal/models"
+)
+
+// minuteLogs is one INFO line per timestamp
+func minuteLogs(timestamps ...string) string {
+	var b strings.Builder
+	for _, ts := range timestamps {
+		fmt.Fprintf(&b, `{"timestamp":"%s","level":"INFO","endpoint":"/a","response_time_ms":10}`+"\n", ts)
+	}
+	return b.String()
+}
+
+// minute is the Unix minute of an RFC 3339 time
+func minute(t *testing.T, ts string) int64 {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Unix() / 60
+}
+
+func TestGetTimeSeries(t *testing.T) {
+	// Out of order, with a gap at 10:02 and one line in another time zone
+	input := minuteLogs(
+		"2024-01-15T10:03:10Z",
+		"2024-01-15T10:00:59Z",
+		"2024-01-15T10:01:00Z",
+		"2024-01-15T10:00:00Z",
+		"2024-01-15T11:03:45+01:00",
+		"2024-01-15T10:01:30Z",
+		"2024-01-15T10:00:30Z",
+	)
+
+	tests := []struct {
+		name       string
+		maxMinutes int
+		want       []models.MinuteCount
+	}{
+		{name: "disabled"},
+		{
+			name:       "every minute",
+			maxMinutes: 60,
+			want: []models.MinuteCount{
+				{Minute: minute(t, "2024-01-15T10:00:00Z"), Count: 3},
+				{Minute: minute(t, "2024-01-15T10:01:00Z"), Count: 2},
+				{Minute: minute(t, "2024-01-15T10:03:00Z"), Count: 2},
+			},
+		},
+		{
+			// 10:03 and 10:00 are seen first, so 10:01 is left out
+			name:       "capped",
+			maxMinutes: 2,
+			want: []models.MinuteCount{
+				{Minute: minute(t, "2024-01-15T10:00:00Z"), Count: 3},
+				{Minute: minute(t, "2024-01-15T10:03:00Z"), Count: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ParserConfig{TimestampLayout: time.RFC3339, MaxTimeSeriesMinutes: tt.maxMinutes}
+			result := parseString(t, cfg, input).Result("job")
+			if !slices.Equal(result.RequestsPerMinute, tt.want) {
+				t.Errorf("RequestsPerMinute = %v, want %v", result.RequestsPerMinute, tt.want)
+			}
+		})
+	}
+}
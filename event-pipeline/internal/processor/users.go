@@ -0,0 +1,53 @@
+// internal/processor/users.go
+package processor
+
+import (
+	"sort"
+
+	"event-pipeline/internal/models"
+)
+
+// OtherUser is the bucket that absorbs users beyond ParserConfig.MaxTrackedUsers
+const OtherUser = "__other__"
+
+// trackUser counts a request for userID when per-user tracking is enabled
+func (p *LogParser) trackUser(userID string) {
+	if p.config.MaxTrackedUsers <= 0 {
+		return
+	}
+
+	stats := p.aggregation.UserStats
+	if _, ok := stats[userID]; !ok && len(stats) >= p.config.MaxTrackedUsers {
+		userID = OtherUser
+	}
+	stats[userID]++
+}
+
+// TopUsers returns up to n users with the most requests, or nil when
+// per-user tracking is disabled. The overflow bucket is excluded.
+func (p *LogParser) TopUsers(n int) []models.UserSummary {
+	if n <= 0 || len(p.aggregation.UserStats) == 0 {
+		return nil
+	}
+
+	users := make([]models.UserSummary, 0, len(p.aggregation.UserStats))
+	for id, count := range p.aggregation.UserStats {
+		if id == OtherUser {
+			continue
+		}
+		users = append(users, models.UserSummary{UserID: id, RequestCount: count})
+	}
+
+	// Break ties by ID so output is deterministic across map iteration orders
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].RequestCount != users[j].RequestCount {
+			return users[i].RequestCount > users[j].RequestCount
+		}
+		return users[i].UserID < users[j].UserID
+	})
+
+	if len(users) > n {
+		users = users[:n]
+	}
+	return users
+}
@@ -0,0 +1,71 @@
+// internal/sink/dynamodb.go
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// resultStore is the part of *store.ResultStore the sink uses, so tests
+// can substitute a fake
+type resultStore interface {
+	PutResult(ctx context.Context, result models.ProcessingResult) error
+	PutResultUnlessCompleted(ctx context.Context, result models.ProcessingResult) error
+	PutResults(ctx context.Context, results []models.ProcessingResult) error
+	TableName() string
+	DescribeTable(ctx context.Context) error
+}
+
+// DynamoDB saves results to the results table
+type DynamoDB struct {
+	results         resultStore
+	unlessCompleted bool
+}
+
+// NewDynamoDB creates a sink writing through results. With unlessCompleted,
+// Save returns store.ErrAlreadyCompleted instead of replacing a completed
+// result.
+func NewDynamoDB(results *store.ResultStore, unlessCompleted bool) *DynamoDB {
+	return &DynamoDB{results: results, unlessCompleted: unlessCompleted}
+}
+
+// Save writes result, guarded against replacing a completed one when
+// configured
+func (d *DynamoDB) Save(ctx context.Context, result models.ProcessingResult) error {
+	if d.unlessCompleted {
+		return d.results.PutResultUnlessCompleted(ctx, result)
+	}
+	return d.results.PutResult(ctx, result)
+}
+
+// SaveAll writes results with BatchWriteItem. Batch writes can't be
+// conditional, so completed results may be overwritten.
+func (d *DynamoDB) SaveAll(ctx context.Context, results []models.ProcessingResult) error {
+	return d.results.PutResults(ctx, results)
+}
+
+// Check confirms the results table exists
+func (d *DynamoDB) Check(ctx context.Context) error {
+	table := d.results.TableName()
+	if table == "" {
+		return errors.New("DYNAMODB_TABLE is not set")
+	}
+
+	err := d.results.DescribeTable(ctx)
+	var missing *types.ResourceNotFoundException
+	if errors.As(err, &missing) {
+		return fmt.Errorf("DynamoDB table %s does not exist", table)
+	}
+	return err
+}
+
+// CheckAction implements Checker
+func (d *DynamoDB) CheckAction() string {
+	return "dynamodb:DescribeTable"
+}
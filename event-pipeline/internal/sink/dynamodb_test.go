@@ -0,0 +1,110 @@
+// internal/sink/dynamodb_test.go
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// fakeResultStore records puts by kind and answers DescribeTable with
+// describeErr
+type fakeResultStore struct {
+	table       string
+	describeErr error
+	putErr      error
+	puts        []models.ProcessingResult
+	guarded     []models.ProcessingResult
+	batches     [][]models.ProcessingResult
+}
+
+func (f *fakeResultStore) PutResult(ctx context.Context, result models.ProcessingResult) error {
+	f.puts = append(f.puts, result)
+	return f.putErr
+}
+
+func (f *fakeResultStore) PutResultUnlessCompleted(ctx context.Context, result models.ProcessingResult) error {
+	f.guarded = append(f.guarded, result)
+	return f.putErr
+}
+
+func (f *fakeResultStore) PutResults(ctx context.Context, results []models.ProcessingResult) error {
+	f.batches = append(f.batches, results)
+	return f.putErr
+}
+
+func (f *fakeResultStore) TableName() string { return f.table }
+
+func (f *fakeResultStore) DescribeTable(ctx context.Context) error { return f.describeErr }
+
+func TestDynamoDBCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       string
+		describeErr error
+		wantErr     string // empty when the check passes
+	}{
+		{name: "table exists", table: "results"},
+		{
+			name:        "missing table",
+			table:       "results",
+			describeErr: fmt.Errorf("failed to describe table results: %w", &types.ResourceNotFoundException{}),
+			wantErr:     "DynamoDB table results does not exist",
+		},
+		{
+			// Other failures, such as a missing permission, are passed on
+			name:        "access denied",
+			table:       "results",
+			describeErr: errors.New("AccessDeniedException"),
+			wantErr:     "AccessDeniedException",
+		},
+		{name: "no table configured", wantErr: "DYNAMODB_TABLE is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &DynamoDB{results: &fakeResultStore{table: tt.table, describeErr: tt.describeErr}}
+			err := d.Check(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDynamoDBSave(t *testing.T) {
+	for _, unlessCompleted := range []bool{false, true} {
+		results := &fakeResultStore{table: "results"}
+		d := &DynamoDB{results: results, unlessCompleted: unlessCompleted}
+
+		if err := d.Save(context.Background(), models.ProcessingResult{JobID: "job-1"}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		plain, guarded := len(results.puts), len(results.guarded)
+		if unlessCompleted && (plain != 0 || guarded != 1) {
+			t.Errorf("unlessCompleted: %d plain and %d guarded puts, want only a guarded one", plain, guarded)
+		}
+		if !unlessCompleted && (plain != 1 || guarded != 0) {
+			t.Errorf("%d plain and %d guarded puts, want only a plain one", plain, guarded)
+		}
+	}
+}
+
+func TestDynamoDBSaveAlreadyCompleted(t *testing.T) {
+	d := &DynamoDB{results: &fakeResultStore{putErr: store.ErrAlreadyCompleted}, unlessCompleted: true}
+	if err := d.Save(context.Background(), models.ProcessingResult{JobID: "job-1"}); !errors.Is(err, store.ErrAlreadyCompleted) {
+		t.Errorf("Save = %v, want ErrAlreadyCompleted", err)
+	}
+}
@@ -0,0 +1,75 @@
+// internal/sink/http.go
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// defaultHTTPTimeout bounds each POST when RESULT_SINK_TIMEOUT is unset
+const defaultHTTPTimeout = 10 * time.Second
+
+// maxErrorBody caps how much of a failed response is quoted in the error
+const maxErrorBody = 512
+
+// HTTP POSTs each result as JSON to an endpoint. Any 2xx status is success.
+// 409 Conflict means the endpoint already holds a completed result and is
+// reported as store.ErrAlreadyCompleted.
+type HTTP struct {
+	client *http.Client
+	url    string
+	auth   string
+}
+
+// NewHTTP creates a sink posting to url, sending auth as the Authorization
+// header when it is not empty
+func NewHTTP(url, auth string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		client: &http.Client{Timeout: timeout},
+		url:    url,
+		auth:   auth,
+	}
+}
+
+// Save POSTs result to the endpoint
+func (h *HTTP) Save(ctx context.Context, result models.ProcessingResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.auth != "" {
+		req.Header.Set("Authorization", h.auth)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post result %s: %w", result.JobID, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// Drain so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	case resp.StatusCode == http.StatusConflict:
+		return store.ErrAlreadyCompleted
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("result endpoint returned %s for %s: %s", resp.Status, result.JobID, bytes.TrimSpace(msg))
+	}
+}
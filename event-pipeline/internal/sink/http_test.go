@@ -0,0 +1,116 @@
+// internal/sink/http_test.go
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// request is what the test endpoint saw
+type request struct {
+	method      string
+	contentType string
+	auth        string
+	result      models.ProcessingResult
+}
+
+// endpoint starts a server answering every POST with status and body,
+// recording the requests it gets
+func endpoint(t *testing.T, status int, body string) (*httptest.Server, *[]request) {
+	t.Helper()
+	var seen []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := request{
+			method:      r.Method,
+			contentType: r.Header.Get("Content-Type"),
+			auth:        r.Header.Get("Authorization"),
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req.result); err != nil {
+			t.Errorf("request body is not a result: %v", err)
+		}
+		seen = append(seen, req)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func TestHTTPSave(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+		wantMsg string // part of the error message, when Save fails
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "conflict", status: http.StatusConflict, wantErr: store.ErrAlreadyCompleted},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			body:    "  database unavailable\n",
+			wantMsg: "500 Internal Server Error for job-1: database unavailable",
+		},
+		{
+			// Only the start of a long body is quoted
+			name:    "long error body",
+			status:  http.StatusBadRequest,
+			body:    strings.Repeat("x", 2*maxErrorBody),
+			wantMsg: "400 Bad Request for job-1: " + strings.Repeat("x", maxErrorBody),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, seen := endpoint(t, tt.status, tt.body)
+			sink := NewHTTP(srv.URL, "", time.Second)
+
+			err := sink.Save(context.Background(), models.ProcessingResult{JobID: "job-1", Status: models.StatusCompleted})
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Save = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMsg != "":
+				if err == nil || !strings.HasSuffix(err.Error(), tt.wantMsg) {
+					t.Errorf("Save = %v, want an error ending %q", err, tt.wantMsg)
+				}
+			case err != nil:
+				t.Errorf("Save: %v", err)
+			}
+
+			if len(*seen) != 1 {
+				t.Fatalf("endpoint got %d requests, want 1", len(*seen))
+			}
+			req := (*seen)[0]
+			if req.method != http.MethodPost || req.contentType != "application/json" {
+				t.Errorf("got %s with Content-Type %q, want a JSON POST", req.method, req.contentType)
+			}
+			if req.result.JobID != "job-1" || req.result.Status != models.StatusCompleted {
+				t.Errorf("posted %+v, want the completed job-1 result", req.result)
+			}
+		})
+	}
+}
+
+func TestHTTPAuthorization(t *testing.T) {
+	for _, auth := range []string{"", "Bearer secret"} {
+		srv, seen := endpoint(t, http.StatusOK, "")
+		if err := NewHTTP(srv.URL, auth, time.Second).Save(context.Background(), models.ProcessingResult{JobID: "job-1", Status: models.StatusCompleted}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if got := (*seen)[0].auth; got != auth {
+			t.Errorf("Authorization = %q, want %q", got, auth)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// internal/sink/s3.go
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"event-pipeline/internal/models"
+)
+
+// s3API is the part of *s3.Client the sink uses, so tests can substitute
+// a fake
+type s3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+}
+
+// S3 saves each result as a JSON object named prefix + job ID + ".json", so
+// like the default DynamoDB key schema a job keeps only its latest result
+type S3 struct {
+	client s3API
+	bucket string
+	prefix string
+}
+
+// NewS3 creates a sink writing to bucket under prefix
+func NewS3(client *s3.Client, bucket, prefix string) *S3 {
+	return &S3{client: client, bucket: bucket, prefix: prefix}
+}
+
+// Save writes result as JSON, replacing any earlier result for the job
+func (s *S3) Save(ctx context.Context, result models.ProcessingResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	key := s.prefix + result.JobID + ".json"
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put result %s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// Check confirms the bucket exists and is reachable
+func (s *S3) Check(ctx context.Context) error {
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.bucket)})
+	if err != nil {
+		return fmt.Errorf("failed to head bucket %s: %w", s.bucket, err)
+	}
+	return nil
+}
+
+// CheckAction implements Checker
+func (s *S3) CheckAction() string {
+	return "s3:ListBucket (HeadBucket)"
+}
@@ -0,0 +1,99 @@
+// internal/sink/s3_test.go
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"event-pipeline/internal/models"
+)
+
+// fakeS3 records each PutObject and HeadBucket, failing them with putErr
+// and headErr
+type fakeS3 struct {
+	putErr  error
+	headErr error
+	puts    []*s3.PutObjectInput
+	bodies  [][]byte
+	heads   []string
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.puts = append(f.puts, params)
+	f.bodies = append(f.bodies, body)
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error) {
+	f.heads = append(f.heads, aws.ToString(params.Bucket))
+	if f.headErr != nil {
+		return nil, f.headErr
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+func TestS3Save(t *testing.T) {
+	fs3 := &fakeS3{}
+	sink := &S3{client: fs3, bucket: "results-bucket", prefix: "results/"}
+	result := models.ProcessingResult{JobID: "job-1", Status: models.StatusCompleted, LineCount: 3}
+
+	if err := sink.Save(context.Background(), result); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if len(fs3.puts) != 1 {
+		t.Fatalf("made %d puts, want 1", len(fs3.puts))
+	}
+	put := fs3.puts[0]
+	if aws.ToString(put.Bucket) != "results-bucket" || aws.ToString(put.Key) != "results/job-1.json" {
+		t.Errorf("put to %s/%s, want results-bucket/results/job-1.json", aws.ToString(put.Bucket), aws.ToString(put.Key))
+	}
+	if got := aws.ToString(put.ContentType); got != "application/json" {
+		t.Errorf("ContentType = %q, want application/json", got)
+	}
+	var saved models.ProcessingResult
+	if err := json.Unmarshal(fs3.bodies[0], &saved); err != nil {
+		t.Fatalf("body is not a result: %v", err)
+	}
+	if saved.JobID != result.JobID || saved.Status != result.Status || saved.LineCount != result.LineCount {
+		t.Errorf("saved %+v, want %+v", saved, result)
+	}
+}
+
+func TestS3SaveError(t *testing.T) {
+	putErr := errors.New("SlowDown")
+	sink := &S3{client: &fakeS3{putErr: putErr}, bucket: "results-bucket", prefix: "results/"}
+
+	err := sink.Save(context.Background(), models.ProcessingResult{JobID: "job-1"})
+	if !errors.Is(err, putErr) || !strings.Contains(err.Error(), "results-bucket/results/job-1.json") {
+		t.Errorf("Save = %v, want the put error naming the object", err)
+	}
+}
+
+func TestS3Check(t *testing.T) {
+	for _, headErr := range []error{nil, errors.New("NotFound")} {
+		fs3 := &fakeS3{headErr: headErr}
+		sink := &S3{client: fs3, bucket: "results-bucket"}
+
+		err := sink.Check(context.Background())
+		if !errors.Is(err, headErr) {
+			t.Errorf("Check = %v, want %v", err, headErr)
+		}
+		if len(fs3.heads) != 1 || fs3.heads[0] != "results-bucket" {
+			t.Errorf("headed %q, want results-bucket", fs3.heads)
+		}
+	}
+}
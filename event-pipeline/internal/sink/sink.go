@@ -0,0 +1,108 @@
+// internal/sink/sink.go
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"event-pipeline/internal/awsconfig"
+	"event-pipeline/internal/envconfig"
+	"event-pipeline/internal/models"
+	"event-pipeline/internal/store"
+)
+
+// ResultSink persists processing results. Save may return
+// store.ErrAlreadyCompleted when the destination refuses to replace a
+// completed result.
+type ResultSink interface {
+	Save(ctx context.Context, result models.ProcessingResult) error
+}
+
+// BatchSink is implemented by sinks that write many results more cheaply
+// than one Save per result
+type BatchSink interface {
+	SaveAll(ctx context.Context, results []models.ProcessingResult) error
+}
+
+// Checker is implemented by sinks that can confirm their destination
+// exists with one read-only call
+type Checker interface {
+	Check(ctx context.Context) error
+
+	// CheckAction names the IAM action Check needs, e.g. dynamodb:DescribeTable
+	CheckAction() string
+}
+
+// Sink names accepted by RESULT_SINK
+const (
+	SinkDynamoDB = "dynamodb"
+	SinkS3       = "s3"
+	SinkHTTP     = "http"
+)
+
+// defaultS3Prefix is used when RESULT_SINK_PREFIX is unset
+const defaultS3Prefix = "results/"
+
+// NewFromEnv creates the sink selected by RESULT_SINK, DynamoDB by default:
+//
+//   - dynamodb writes to DYNAMODB_TABLE, keyed as store.EnvOptions says;
+//     with unlessCompleted a completed result is never replaced
+//   - s3 writes JSON to RESULT_SINK_BUCKET under RESULT_SINK_PREFIX
+//   - http POSTs JSON to RESULT_SINK_URL, sending RESULT_SINK_AUTH as the
+//     Authorization header when set
+func NewFromEnv(ctx context.Context, unlessCompleted bool) (ResultSink, error) {
+	switch kind := Kind(); kind {
+	case SinkDynamoDB:
+		results, err := store.NewResultStore(ctx, os.Getenv("DYNAMODB_TABLE"), store.EnvOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create result store: %w", err)
+		}
+		return NewDynamoDB(results, unlessCompleted), nil
+	case SinkS3:
+		bucket := os.Getenv("RESULT_SINK_BUCKET")
+		if bucket == "" {
+			return nil, fmt.Errorf("RESULT_SINK_BUCKET is required for the s3 sink")
+		}
+		prefix := os.Getenv("RESULT_SINK_PREFIX")
+		if prefix == "" {
+			prefix = defaultS3Prefix
+		}
+		cfg, err := awsconfig.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3(awsconfig.NewS3(cfg), bucket, prefix), nil
+	case SinkHTTP:
+		url := os.Getenv("RESULT_SINK_URL")
+		if url == "" {
+			return nil, fmt.Errorf("RESULT_SINK_URL is required for the http sink")
+		}
+		return NewHTTP(url, os.Getenv("RESULT_SINK_AUTH"), envconfig.Duration("RESULT_SINK_TIMEOUT", defaultHTTPTimeout)), nil
+	default:
+		return nil, fmt.Errorf("unknown RESULT_SINK %q", kind)
+	}
+}
+
+// Kind returns the sink named by RESULT_SINK, lowercased, or SinkDynamoDB
+func Kind() string {
+	if kind := strings.ToLower(strings.TrimSpace(os.Getenv("RESULT_SINK"))); kind != "" {
+		return kind
+	}
+	return SinkDynamoDB
+}
+
+// SaveAll writes results through sink's BatchSink when it has one, else
+// one Save at a time, stopping at the first error
+func SaveAll(ctx context.Context, sink ResultSink, results []models.ProcessingResult) error {
+	if batch, ok := sink.(BatchSink); ok {
+		return batch.SaveAll(ctx, results)
+	}
+	for _, result := range results {
+		if err := sink.Save(ctx, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
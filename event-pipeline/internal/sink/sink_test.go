@@ -0,0 +1,69 @@
+// internal/sink/sink_test.go
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"event-pipeline/internal/models"
+)
+
+// plainSink saves one result at a time, failing the failAt'th Save
+type plainSink struct {
+	saved  []string
+	failAt int
+}
+
+func (s *plainSink) Save(ctx context.Context, result models.ProcessingResult) error {
+	if len(s.saved)+1 == s.failAt {
+		return errors.New("boom")
+	}
+	s.saved = append(s.saved, result.JobID)
+	return nil
+}
+
+// numberedResults returns n results with distinct job IDs
+func numberedResults(n int) []models.ProcessingResult {
+	results := make([]models.ProcessingResult, n)
+	for i := range results {
+		results[i] = models.ProcessingResult{JobID: fmt.Sprintf("job-%02d", i), Status: models.StatusCompleted}
+	}
+	return results
+}
+
+func TestSaveAllUsesBatchSink(t *testing.T) {
+	results := &fakeResultStore{table: "results"}
+	d := &DynamoDB{results: results, unlessCompleted: true}
+
+	if err := SaveAll(context.Background(), d, numberedResults(30)); err != nil {
+		t.Fatalf("SaveAll: %v", err)
+	}
+	// The store chunks the batch; the sink hands it over whole
+	if len(results.batches) != 1 || len(results.batches[0]) != 30 {
+		t.Errorf("got %d batches, want one of 30 results", len(results.batches))
+	}
+	if len(results.puts)+len(results.guarded) != 0 {
+		t.Errorf("made %d single puts, want none", len(results.puts)+len(results.guarded))
+	}
+}
+
+func TestSaveAllFallsBackToSave(t *testing.T) {
+	s := &plainSink{}
+	if err := SaveAll(context.Background(), s, numberedResults(3)); err != nil {
+		t.Fatalf("SaveAll: %v", err)
+	}
+	if len(s.saved) != 3 {
+		t.Errorf("saved %q, want all three results", s.saved)
+	}
+
+	// The first failure stops the rest
+	s = &plainSink{failAt: 2}
+	if err := SaveAll(context.Background(), s, numberedResults(3)); err == nil {
+		t.Fatal("SaveAll succeeded past a failed Save")
+	}
+	if len(s.saved) != 1 || s.saved[0] != "job-00" {
+		t.Errorf("saved %q, want only job-00", s.saved)
+	}
+}
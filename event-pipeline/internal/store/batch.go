@@ -0,0 +1,106 @@
+// internal/store/batch.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/models"
+)
+
+const (
+	// maxBatchWriteItems is the DynamoDB limit for BatchWriteItem
+	maxBatchWriteItems = 25
+
+	// maxBatchAttempts bounds how often unprocessed items are re-sent before
+	// falling back to individual puts
+	maxBatchAttempts = 4
+
+	// batchRetryBaseDelay is the initial backoff, doubled on each attempt
+	batchRetryBaseDelay = 50 * time.Millisecond
+)
+
+// PutResults writes many results with BatchWriteItem in chunks of
+// maxBatchWriteItems, replacing existing items like PutResult. Items
+// DynamoDB leaves unprocessed are re-sent with backoff, and any still
+// unprocessed after maxBatchAttempts are written one at a time.
+//
+// BatchWriteItem can't carry a condition, so unlike
+// PutResultUnlessCompleted this may overwrite a completed result.
+func (s *ResultStore) PutResults(ctx context.Context, results []models.ProcessingResult) error {
+	requests := make([]types.WriteRequest, 0, len(results))
+	for _, result := range results {
+		item, err := s.marshal(result)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: item},
+		})
+	}
+
+	for i := 0; i < len(requests); i += maxBatchWriteItems {
+		end := min(i+maxBatchWriteItems, len(requests))
+		if err := s.writeChunk(ctx, requests[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeChunk sends one BatchWriteItem call, re-sending unprocessed items
+// until none remain or attempts run out
+func (s *ResultStore) writeChunk(ctx context.Context, pending []types.WriteRequest) error {
+	for attempt := 1; attempt <= maxBatchAttempts && len(pending) > 0; attempt++ {
+		if attempt > 1 {
+			if err := sleepWithContext(ctx, rand.N(batchRetryBaseDelay<<(attempt-2)+1)); err != nil {
+				return err
+			}
+		}
+
+		out, err := s.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{s.tableName: pending},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to batch write %d results: %w", len(pending), err)
+		}
+		pending = out.UnprocessedItems[s.tableName]
+	}
+
+	if len(pending) > 0 {
+		fmt.Printf("%d results still unprocessed after %d batch attempts, writing individually\n", len(pending), maxBatchAttempts)
+	}
+	for _, req := range pending {
+		_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.tableName),
+			Item:      req.PutRequest.Item,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put unprocessed result: %w", err)
+		}
+	}
+	return nil
+}
+
+// sleepWithContext waits for d or until ctx is done
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
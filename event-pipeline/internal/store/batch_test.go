@@ -0,0 +1,86 @@
+// internal/store/batch_test.go
+package store
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/models"
+)
+
+// batchJobIDs returns the job IDs written by one BatchWriteItem call
+func batchJobIDs(params *dynamodb.BatchWriteItemInput) []string {
+	var ids []string
+	for _, req := range params.RequestItems["results"] {
+		ids = append(ids, stringAttr(req.PutRequest.Item, "job_id"))
+	}
+	return ids
+}
+
+func TestPutResultsChunksAndRetriesUnprocessed(t *testing.T) {
+	client := &fakeDynamoDB{
+		// The first chunk leaves its last two items unprocessed once
+		batchWriteItem: func(call int, params *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			out := &dynamodb.BatchWriteItemOutput{}
+			if call == 1 {
+				reqs := params.RequestItems["results"]
+				out.UnprocessedItems = map[string][]types.WriteRequest{"results": reqs[len(reqs)-2:]}
+			}
+			return out, nil
+		},
+	}
+
+	results := make([]models.ProcessingResult, 30)
+	for i := range results {
+		results[i] = completedResult(fmt.Sprintf("job-%02d", i))
+	}
+	if err := newTestResultStore(client).PutResults(context.Background(), results); err != nil {
+		t.Fatalf("PutResults: %v", err)
+	}
+
+	var sizes []int
+	for _, batch := range client.batches {
+		sizes = append(sizes, len(batch.RequestItems["results"]))
+	}
+	if want := []int{maxBatchWriteItems, 2, 5}; !slices.Equal(sizes, want) {
+		t.Fatalf("batch sizes = %v, want %v", sizes, want)
+	}
+	if got, want := batchJobIDs(client.batches[1]), []string{"job-23", "job-24"}; !slices.Equal(got, want) {
+		t.Errorf("retried %v, want the unprocessed %v", got, want)
+	}
+	if got := batchJobIDs(client.batches[2]); got[0] != "job-25" || got[4] != "job-29" {
+		t.Errorf("second chunk = %v, want job-25 to job-29", got)
+	}
+	if len(client.puts) != 0 {
+		t.Errorf("%d individual puts, want none", len(client.puts))
+	}
+}
+
+func TestPutResultsFallsBackToPutItem(t *testing.T) {
+	// One item is never processed by a batch
+	client := &fakeDynamoDB{
+		batchWriteItem: func(call int, params *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			reqs := params.RequestItems["results"]
+			return &dynamodb.BatchWriteItemOutput{
+				UnprocessedItems: map[string][]types.WriteRequest{"results": reqs[len(reqs)-1:]},
+			}, nil
+		},
+	}
+
+	results := []models.ProcessingResult{completedResult("job-a"), completedResult("job-b")}
+	if err := newTestResultStore(client).PutResults(context.Background(), results); err != nil {
+		t.Fatalf("PutResults: %v", err)
+	}
+
+	if len(client.batches) != maxBatchAttempts {
+		t.Errorf("%d batch calls, want %d", len(client.batches), maxBatchAttempts)
+	}
+	if len(client.puts) != 1 || stringAttr(client.puts[0].Item, "job_id") != "job-b" {
+		t.Errorf("individual puts = %d, want one for job-b", len(client.puts))
+	}
+}
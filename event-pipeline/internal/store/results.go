@@ -0,0 +1,283 @@
+// internal/store/results.go
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/awsconfig"
+	"event-pipeline/internal/models"
+)
+
+// StatusIndexName is the GSI keyed on status (hash) and completed_at (range)
+const StatusIndexName = "status-index"
+
+// ErrNotFound is returned by GetResult when no result exists for the job
+var ErrNotFound = errors.New("result not found")
+
+// ErrAlreadyCompleted is returned by PutResultUnlessCompleted when a
+// completed result for the job is already stored
+var ErrAlreadyCompleted = errors.New("job already completed")
+
+// dynamoDBAPI is the part of *dynamodb.Client the stores use, so tests
+// can substitute a fake
+type dynamoDBAPI interface {
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+// ResultStore reads and writes ProcessingResult items in DynamoDB
+type ResultStore struct {
+	client    dynamoDBAPI
+	tableName string
+	schema    KeySchema
+}
+
+// NewResultStore creates a store for the given results table, keyed by
+// DefaultKeySchema unless WithKeySchema says otherwise
+func NewResultStore(ctx context.Context, tableName string, opts ...Option) (*ResultStore, error) {
+	cfg, err := awsconfig.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ResultStore{
+		client:    awsconfig.NewDynamoDB(cfg),
+		tableName: tableName,
+		schema:    DefaultKeySchema,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.schema.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid key schema: %w", err)
+	}
+	return s, nil
+}
+
+// TableName returns the results table's name
+func (s *ResultStore) TableName() string {
+	return s.tableName
+}
+
+// DescribeTable checks that the results table exists and that the caller
+// may describe it
+func (s *ResultStore) DescribeTable(ctx context.Context) error {
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(s.tableName),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to describe table %s: %w", s.tableName, err)
+	}
+	return nil
+}
+
+// GetResult fetches the result for jobID, or ErrNotFound. The partition key
+// must hold the job ID; with a sort key the item that sorts last is returned.
+func (s *ResultStore) GetResult(ctx context.Context, jobID string) (*models.ProcessingResult, error) {
+	item, err := s.latestItem(ctx, &types.AttributeValueMemberS{Value: jobID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get result %s: %w", jobID, err)
+	}
+	if item == nil {
+		return nil, fmt.Errorf("job %s: %w", jobID, ErrNotFound)
+	}
+
+	result, err := decodeResult(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result %s: %w", jobID, err)
+	}
+	return &result, nil
+}
+
+// latestItem reads the item with partition key value key directly, or
+// queries for the last one when the table has a sort key. It returns nil
+// when there is none.
+func (s *ResultStore) latestItem(ctx context.Context, key types.AttributeValue) (map[string]types.AttributeValue, error) {
+	if s.schema.SortKey == "" {
+		out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: aws.String(s.tableName),
+			Key:       map[string]types.AttributeValue{s.schema.PartitionKey: key},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return out.Item, nil
+	}
+
+	out, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(s.tableName),
+		KeyConditionExpression:    aws.String("#pk = :pk"),
+		ExpressionAttributeNames:  map[string]string{"#pk": s.schema.PartitionKey},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":pk": key},
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Items) == 0 {
+		return nil, nil
+	}
+	return out.Items[0], nil
+}
+
+// PutResult writes result, replacing any existing item for the job
+func (s *ResultStore) PutResult(ctx context.Context, result models.ProcessingResult) error {
+	return s.put(ctx, result, false)
+}
+
+// PutResultUnlessCompleted writes result unless a completed result already
+// exists, so retries can overwrite failures but never a success. With a sort
+// key each run is its own item, so the latest item for the partition key is
+// read first and a completed one refuses the write. Two runs racing between
+// that read and the put can still both be written.
+func (s *ResultStore) PutResultUnlessCompleted(ctx context.Context, result models.ProcessingResult) error {
+	return s.put(ctx, result, true)
+}
+
+// put marshals and writes result, optionally guarded by a condition
+func (s *ResultStore) put(ctx context.Context, result models.ProcessingResult, unlessCompleted bool) error {
+	item, err := s.marshal(result)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      item,
+	}
+	if unlessCompleted {
+		if s.schema.SortKey != "" {
+			latest, err := s.latestItem(ctx, item[s.schema.PartitionKey])
+			if err != nil {
+				return fmt.Errorf("failed to read latest result %s: %w", result.JobID, err)
+			}
+			if status, ok := latest["status"].(*types.AttributeValueMemberS); ok && status.Value == string(models.StatusCompleted) {
+				return ErrAlreadyCompleted
+			}
+		}
+		input.ConditionExpression = aws.String("attribute_not_exists(#pk) OR #status <> :completed")
+		input.ExpressionAttributeNames = map[string]string{"#pk": s.schema.PartitionKey, "#status": "status"}
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":completed": &types.AttributeValueMemberS{Value: string(models.StatusCompleted)},
+		}
+	}
+
+	if _, err := s.client.PutItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrAlreadyCompleted
+		}
+		return err
+	}
+	return nil
+}
+
+// marshal converts result into an item stamped with ResultSchemaVersion,
+// checking it carries every key attribute since omitempty fields may be
+// left out
+func (s *ResultStore) marshal(result models.ProcessingResult) (map[string]types.AttributeValue, error) {
+	if !result.Status.Valid() {
+		return nil, fmt.Errorf("result %s has unknown status %q", result.JobID, result.Status)
+	}
+	result.SchemaVersion = models.ResultSchemaVersion
+	item, err := attributevalue.MarshalMap(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	for _, key := range []string{s.schema.PartitionKey, s.schema.SortKey} {
+		if _, ok := item[key]; key != "" && !ok {
+			return nil, fmt.Errorf("result %s has no value for key attribute %s", result.JobID, key)
+		}
+	}
+	return item, nil
+}
+
+// ListByStatus returns every result with the given status, oldest first,
+// querying StatusIndexName and following pagination
+func (s *ResultStore) ListByStatus(ctx context.Context, status models.Status) ([]models.ProcessingResult, error) {
+	return s.queryStatus(ctx, status, "#status = :status", nil)
+}
+
+// ListByStatusBetween is ListByStatus limited to results whose completed_at
+// falls within [from, to]
+func (s *ResultStore) ListByStatusBetween(ctx context.Context, status models.Status, from, to time.Time) ([]models.ProcessingResult, error) {
+	bounds := map[string]types.AttributeValue{}
+	for name, t := range map[string]time.Time{":from": from, ":to": to} {
+		av, err := attributevalue.Marshal(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal time bound: %w", err)
+		}
+		bounds[name] = av
+	}
+	return s.queryStatus(ctx, status, "#status = :status AND completed_at BETWEEN :from AND :to", bounds)
+}
+
+// queryStatus runs a StatusIndexName query with the given key condition,
+// which may reference values beyond :status, and follows pagination
+func (s *ResultStore) queryStatus(ctx context.Context, status models.Status, keyCondition string, values map[string]types.AttributeValue) ([]models.ProcessingResult, error) {
+	exprValues := map[string]types.AttributeValue{
+		":status": &types.AttributeValueMemberS{Value: string(status)},
+	}
+	for name, v := range values {
+		exprValues[name] = v
+	}
+
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:                 aws.String(s.tableName),
+		IndexName:                 aws.String(StatusIndexName),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeNames:  map[string]string{"#status": "status"},
+		ExpressionAttributeValues: exprValues,
+	})
+
+	var results []models.ProcessingResult
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s results: %w", status, err)
+		}
+
+		// One unreadable item, e.g. from an incompatible schema version,
+		// shouldn't hide the rest
+		for _, item := range page.Items {
+			result, err := decodeResult(item)
+			if err != nil {
+				fmt.Printf("Warning: skipping unreadable %s result: %v\n", status, err)
+				continue
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// decodeResult unmarshals a stored result of any schema version. Unknown
+// attributes from newer writers are ignored and missing ones left zero, so
+// only a retyped attribute fails to decode.
+func decodeResult(item map[string]types.AttributeValue) (models.ProcessingResult, error) {
+	var result models.ProcessingResult
+	if err := attributevalue.UnmarshalMap(item, &result); err != nil {
+		return result, err
+	}
+	// attributevalue doesn't use Status.UnmarshalText, so check it here
+	if !result.Status.Valid() {
+		return result, fmt.Errorf("result %s has unknown status %q", result.JobID, result.Status)
+	}
+	if result.SchemaVersion > models.ResultSchemaVersion {
+		fmt.Printf("Warning: result %s has schema version %d, newer than %d; some fields may be missing\n", result.JobID, result.SchemaVersion, models.ResultSchemaVersion)
+	}
+	return result, nil
+}
@@ -0,0 +1,298 @@
+// internal/store/results_test.go
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/models"
+)
+
+// fakeDynamoDB records every call. Each hook, when set, answers its method;
+// otherwise reads find nothing and writes succeed in full. DescribeTable
+// fails with describeErr when set.
+type fakeDynamoDB struct {
+	dynamoDBAPI
+
+	mu       sync.Mutex
+	gets     []*dynamodb.GetItemInput
+	puts     []*dynamodb.PutItemInput
+	queries  []*dynamodb.QueryInput
+	batches  []*dynamodb.BatchWriteItemInput
+	transact []*dynamodb.TransactWriteItemsInput
+
+	getItem        func(call int, params *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	putItem        func(call int, params *dynamodb.PutItemInput) error
+	query          func(call int, params *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	batchWriteItem func(call int, params *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	describeErr    error
+}
+
+func (f *fakeDynamoDB) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if f.describeErr != nil {
+		return nil, f.describeErr
+	}
+	return &dynamodb.DescribeTableOutput{}, nil
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets = append(f.gets, params)
+	if f.getItem != nil {
+		return f.getItem(len(f.gets), params)
+	}
+	return &dynamodb.GetItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.puts = append(f.puts, params)
+	if f.putItem != nil {
+		if err := f.putItem(len(f.puts), params); err != nil {
+			return nil, err
+		}
+	}
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, params)
+	if f.query != nil {
+		return f.query(len(f.queries), params)
+	}
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, params)
+	if f.batchWriteItem != nil {
+		return f.batchWriteItem(len(f.batches), params)
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transact = append(f.transact, params)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// newTestResultStore creates a ResultStore on the "results" table writing
+// through client
+func newTestResultStore(client dynamoDBAPI, opts ...Option) *ResultStore {
+	s := &ResultStore{client: client, tableName: "results", schema: DefaultKeySchema}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// stringAttr returns item's string attribute name, or "" when missing
+func stringAttr(item map[string]types.AttributeValue, name string) string {
+	if s, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
+
+// completedResult is a minimal result that marshals with every default key
+func completedResult(jobID string) models.ProcessingResult {
+	return models.ProcessingResult{JobID: jobID, Status: models.StatusCompleted}
+}
+
+func TestPutResultUnlessCompleted(t *testing.T) {
+	sortKey := WithKeySchema(KeySchema{PartitionKey: "job_id", SortKey: "completed_at"})
+	latestRun := func(status models.Status) func(int, *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+		return func(int, *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{{
+				"job_id":       &types.AttributeValueMemberS{Value: "job-a"},
+				"completed_at": &types.AttributeValueMemberS{Value: "2024-01-15T10:00:00Z"},
+				"status":       &types.AttributeValueMemberS{Value: string(status)},
+			}}}, nil
+		}
+	}
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		client      *fakeDynamoDB
+		wantErr     error
+		wantQueries int
+		wantPuts    int
+	}{
+		{
+			name: "partition key only, completed",
+			client: &fakeDynamoDB{putItem: func(int, *dynamodb.PutItemInput) error {
+				return &types.ConditionalCheckFailedException{}
+			}},
+			wantErr:  ErrAlreadyCompleted,
+			wantPuts: 1,
+		},
+		{
+			name:     "partition key only, not completed",
+			client:   &fakeDynamoDB{},
+			wantPuts: 1,
+		},
+		{
+			// The new run has its own completed_at, so only the read can
+			// see the completed run
+			name:        "sort key, latest run completed",
+			opts:        []Option{sortKey},
+			client:      &fakeDynamoDB{query: latestRun(models.StatusCompleted)},
+			wantErr:     ErrAlreadyCompleted,
+			wantQueries: 1,
+		},
+		{
+			name:        "sort key, latest run failed",
+			opts:        []Option{sortKey},
+			client:      &fakeDynamoDB{query: latestRun(models.StatusFailed)},
+			wantQueries: 1,
+			wantPuts:    1,
+		},
+		{
+			name:        "sort key, first run",
+			opts:        []Option{sortKey},
+			client:      &fakeDynamoDB{},
+			wantQueries: 1,
+			wantPuts:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := completedResult("job-a")
+			result.CompletedAt = time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC)
+
+			err := newTestResultStore(tt.client, tt.opts...).PutResultUnlessCompleted(context.Background(), result)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PutResultUnlessCompleted = %v, want %v", err, tt.wantErr)
+			}
+			if len(tt.client.queries) != tt.wantQueries || len(tt.client.puts) != tt.wantPuts {
+				t.Fatalf("%d queries and %d puts, want %d and %d", len(tt.client.queries), len(tt.client.puts), tt.wantQueries, tt.wantPuts)
+			}
+			for _, q := range tt.client.queries {
+				if pk := stringAttr(q.ExpressionAttributeValues, ":pk"); pk != "job-a" {
+					t.Errorf("queried partition %q, want job-a", pk)
+				}
+			}
+			for _, put := range tt.client.puts {
+				if put.ConditionExpression == nil {
+					t.Error("put has no condition")
+				}
+			}
+		})
+	}
+}
+
+func TestSavedResultsCarrySchemaVersion(t *testing.T) {
+	want := strconv.Itoa(models.ResultSchemaVersion)
+	// A result read back from an older record is restamped when rewritten
+	stale := completedResult("job-old")
+	stale.SchemaVersion = models.ResultSchemaVersion - 1
+
+	tests := []struct {
+		name  string
+		write func(*ResultStore) error
+		items func(*fakeDynamoDB) []map[string]types.AttributeValue
+	}{
+		{
+			name:  "PutResult",
+			write: func(s *ResultStore) error { return s.PutResult(context.Background(), stale) },
+			items: putItems,
+		},
+		{
+			name: "PutResultUnlessCompleted",
+			write: func(s *ResultStore) error {
+				return s.PutResultUnlessCompleted(context.Background(), completedResult("job-a"))
+			},
+			items: putItems,
+		},
+		{
+			name: "PutResults",
+			write: func(s *ResultStore) error {
+				return s.PutResults(context.Background(), []models.ProcessingResult{completedResult("job-a"), stale})
+			},
+			items: func(f *fakeDynamoDB) []map[string]types.AttributeValue {
+				var items []map[string]types.AttributeValue
+				for _, batch := range f.batches {
+					for _, req := range batch.RequestItems["results"] {
+						items = append(items, req.PutRequest.Item)
+					}
+				}
+				return items
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeDynamoDB{}
+			if err := tt.write(newTestResultStore(client)); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			items := tt.items(client)
+			if len(items) == 0 {
+				t.Fatal("nothing written")
+			}
+			for _, item := range items {
+				got, _ := item["schema_version"].(*types.AttributeValueMemberN)
+				if got == nil || got.Value != want {
+					t.Errorf("%s schema_version = %v, want %s", stringAttr(item, "job_id"), item["schema_version"], want)
+				}
+			}
+		})
+	}
+}
+
+// putItems returns the items written with PutItem
+func putItems(f *fakeDynamoDB) []map[string]types.AttributeValue {
+	var items []map[string]types.AttributeValue
+	for _, put := range f.puts {
+		items = append(items, put.Item)
+	}
+	return items
+}
+
+func TestGetResultRejectsUnknownStatus(t *testing.T) {
+	for _, status := range []string{"completed", "done"} {
+		client := &fakeDynamoDB{getItem: func(int, *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+				"job_id": &types.AttributeValueMemberS{Value: "job-a"},
+				"status": &types.AttributeValueMemberS{Value: status},
+			}}, nil
+		}}
+
+		result, err := newTestResultStore(client).GetResult(context.Background(), "job-a")
+		if valid := models.Status(status).Valid(); (err == nil) != valid {
+			t.Errorf("GetResult with status %q = %v, want an error only for an unknown status", status, err)
+		} else if valid && result.Status != models.StatusCompleted {
+			t.Errorf("GetResult status = %q, want completed", result.Status)
+		}
+	}
+}
+
+func TestDescribeTableMissing(t *testing.T) {
+	client := &fakeDynamoDB{describeErr: &types.ResourceNotFoundException{}}
+	err := newTestResultStore(client).DescribeTable(context.Background())
+
+	// Wrapped, so the sink can tell a missing table from other failures
+	var missing *types.ResourceNotFoundException
+	if !errors.As(err, &missing) {
+		t.Errorf("DescribeTable = %v, want a ResourceNotFoundException", err)
+	}
+	if err := newTestResultStore(&fakeDynamoDB{}).DescribeTable(context.Background()); err != nil {
+		t.Errorf("DescribeTable on an existing table = %v", err)
+	}
+}
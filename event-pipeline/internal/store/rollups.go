@@ -0,0 +1,151 @@
+// internal/store/rollups.go
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/awsconfig"
+)
+
+const (
+	// rollupKey is the rollups table's partition key. Hourly rollups use
+	// the hour (see HourKey); dedup markers use eventMarkerPrefix.
+	rollupKey = "rollup_key"
+
+	// eventMarkerPrefix starts the key of an applied stream event's marker
+	eventMarkerPrefix = "event#"
+
+	// eventMarkerTTL outlives the 24-hour stream retention, so a record
+	// can't be redelivered after its marker expires
+	eventMarkerTTL = 48 * time.Hour
+)
+
+// ErrDuplicateEvent is returned by RollupStore.Apply when the stream event
+// was already applied
+var ErrDuplicateEvent = errors.New("stream event already applied")
+
+// RollupDelta is the change one result makes to an hour's rollup. Counts
+// are negative when a replaced result is taken back out.
+type RollupDelta struct {
+	Hour            time.Time // truncated to the hour, in UTC
+	Files           int       // results that parsed a file (any status but failed)
+	FailedFiles     int
+	Lines           int
+	Errors          int
+	ResponseLines   int     // entries behind TotalResponseMs
+	TotalResponseMs float64 // sum of response times
+}
+
+// Add folds other into d. Both must be for the same hour.
+func (d *RollupDelta) Add(other RollupDelta) {
+	d.Files += other.Files
+	d.FailedFiles += other.FailedFiles
+	d.Lines += other.Lines
+	d.Errors += other.Errors
+	d.ResponseLines += other.ResponseLines
+	d.TotalResponseMs += other.TotalResponseMs
+}
+
+// IsZero reports whether the delta changes nothing
+func (d RollupDelta) IsZero() bool {
+	return d.Files == 0 && d.FailedFiles == 0 && d.Lines == 0 && d.Errors == 0 &&
+		d.ResponseLines == 0 && d.TotalResponseMs == 0
+}
+
+// HourKey returns the rollup key of the hour containing t, e.g.
+// "2024-01-15T10"
+func HourKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15")
+}
+
+// RollupStore accumulates hourly totals across results, one item per hour
+type RollupStore struct {
+	client    dynamoDBAPI
+	tableName string
+}
+
+// NewRollupStore creates a store for the given rollups table
+func NewRollupStore(ctx context.Context, tableName string) (*RollupStore, error) {
+	cfg, err := awsconfig.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RollupStore{
+		client:    awsconfig.NewDynamoDB(cfg),
+		tableName: tableName,
+	}, nil
+}
+
+// Apply adds deltas to their hours' rollups with atomic ADD updates, in one
+// transaction with a marker for eventID. A redelivered event finds its
+// marker, changes nothing and returns ErrDuplicateEvent.
+func (s *RollupStore) Apply(ctx context.Context, eventID string, deltas []RollupDelta, now time.Time) error {
+	items := []types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName: aws.String(s.tableName),
+			Item: map[string]types.AttributeValue{
+				rollupKey:    &types.AttributeValueMemberS{Value: eventMarkerPrefix + eventID},
+				"expires_at": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(eventMarkerTTL).Unix(), 10)},
+			},
+			ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+			ExpressionAttributeNames: map[string]string{"#key": rollupKey},
+		},
+	}}
+	for _, delta := range deltas {
+		expr, names, values := rollupUpdate(delta, now)
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                 aws.String(s.tableName),
+				Key:                       map[string]types.AttributeValue{rollupKey: &types.AttributeValueMemberS{Value: HourKey(delta.Hour)}},
+				UpdateExpression:          aws.String(expr),
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			},
+		})
+	}
+
+	_, err := s.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err == nil {
+		return nil
+	}
+
+	// The marker is the first item, so its condition failing means the
+	// event was applied before
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) && len(canceled.CancellationReasons) > 0 &&
+		aws.ToString(canceled.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return ErrDuplicateEvent
+	}
+	return fmt.Errorf("failed to apply rollup for event %s: %w", eventID, err)
+}
+
+// rollupUpdate builds the UpdateItem expression for delta. The counters
+// use ADD, which creates missing attributes at zero, so concurrent updates
+// of the same hour never lose one another's counts. The average response
+// time is total_response_ms / response_line_count, left to readers since
+// an update expression can't divide.
+func rollupUpdate(delta RollupDelta, now time.Time) (string, map[string]string, map[string]types.AttributeValue) {
+	expr := "ADD file_count :files, failed_count :failed, line_count :lines, error_count :errors, " +
+		"response_line_count :responseLines, total_response_ms :response " +
+		"SET #hour = :hour, updated_at = :now"
+	names := map[string]string{"#hour": "hour"}
+	values := map[string]types.AttributeValue{
+		":files":         &types.AttributeValueMemberN{Value: strconv.Itoa(delta.Files)},
+		":failed":        &types.AttributeValueMemberN{Value: strconv.Itoa(delta.FailedFiles)},
+		":lines":         &types.AttributeValueMemberN{Value: strconv.Itoa(delta.Lines)},
+		":errors":        &types.AttributeValueMemberN{Value: strconv.Itoa(delta.Errors)},
+		":responseLines": &types.AttributeValueMemberN{Value: strconv.Itoa(delta.ResponseLines)},
+		":response":      &types.AttributeValueMemberN{Value: strconv.FormatFloat(delta.TotalResponseMs+0, 'f', -1, 64)}, // +0 turns -0 into 0
+		":hour":          &types.AttributeValueMemberS{Value: delta.Hour.UTC().Truncate(time.Hour).Format(time.RFC3339)},
+		":now":           &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339Nano)},
+	}
+	return expr, names, values
+}
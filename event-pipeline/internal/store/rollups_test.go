@@ -0,0 +1,80 @@
+// internal/store/rollups_test.go
+package store
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestRollupUpdate(t *testing.T) {
+	hour := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	now := hour.Add(42 * time.Minute)
+
+	tests := []struct {
+		name  string
+		delta RollupDelta
+		want  map[string]string // ADD attribute to its value
+	}{
+		{
+			name:  "insert",
+			delta: RollupDelta{Hour: hour, Files: 1, Lines: 100, Errors: 3, ResponseLines: 90, TotalResponseMs: 4500},
+			want: map[string]string{
+				"file_count": "1", "failed_count": "0", "line_count": "100", "error_count": "3",
+				"response_line_count": "90", "total_response_ms": "4500",
+			},
+		},
+		{
+			name:  "removal",
+			delta: RollupDelta{Hour: hour, Files: -1, FailedFiles: 1, Lines: -100, Errors: -3, ResponseLines: -90, TotalResponseMs: -4500.5},
+			want: map[string]string{
+				"file_count": "-1", "failed_count": "1", "line_count": "-100", "error_count": "-3",
+				"response_line_count": "-90", "total_response_ms": "-4500.5",
+			},
+		},
+		{
+			// -0 is not a valid DynamoDB number
+			name:  "negative zero",
+			delta: RollupDelta{Hour: hour, FailedFiles: -1, TotalResponseMs: math.Copysign(0, -1)},
+			want:  map[string]string{"failed_count": "-1", "total_response_ms": "0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, names, values := rollupUpdate(tt.delta, now)
+
+			adds, sets, _ := strings.Cut(strings.TrimPrefix(expr, "ADD "), " SET ")
+			got := map[string]string{}
+			for _, add := range strings.Split(adds, ", ") {
+				attr, placeholder, _ := strings.Cut(add, " ")
+				n, ok := values[placeholder].(*types.AttributeValueMemberN)
+				if !ok {
+					t.Fatalf("%s has no number value for %s", attr, placeholder)
+				}
+				got[attr] = n.Value
+			}
+			for attr, want := range tt.want {
+				if got[attr] != want {
+					t.Errorf("ADD %s %s, want %s", attr, got[attr], want)
+				}
+			}
+
+			if sets != "#hour = :hour, updated_at = :now" || names["#hour"] != "hour" {
+				t.Errorf("SET %q with names %v, want the hour and update time", sets, names)
+			}
+			if got := values[":hour"].(*types.AttributeValueMemberS).Value; got != "2024-01-15T10:00:00Z" {
+				t.Errorf(":hour = %q, want 2024-01-15T10:00:00Z", got)
+			}
+		})
+	}
+}
+
+func TestHourKey(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 59, 59, 0, time.FixedZone("CET", 3600))
+	if got := HourKey(at); got != "2024-01-15T09" {
+		t.Errorf("HourKey = %q, want the UTC hour 2024-01-15T09", got)
+	}
+}
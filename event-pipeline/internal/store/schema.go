@@ -0,0 +1,81 @@
+// internal/store/schema.go
+package store
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"event-pipeline/internal/models"
+)
+
+// KeySchema names the results table's key attributes
+type KeySchema struct {
+	PartitionKey string
+	SortKey      string // empty for a table keyed on the partition key alone
+}
+
+// DefaultKeySchema is a table keyed on job_id only, one result per job
+var DefaultKeySchema = KeySchema{PartitionKey: "job_id"}
+
+// Option configures a ResultStore
+type Option func(*ResultStore)
+
+// WithKeySchema sets the table's key attributes. With a sort key such as
+// completed_at, every run of a job is kept as its own item.
+func WithKeySchema(schema KeySchema) Option {
+	return func(s *ResultStore) {
+		s.schema = schema
+	}
+}
+
+// EnvOptions returns options configured through environment variables.
+// DYNAMODB_PARTITION_KEY and DYNAMODB_SORT_KEY set WithKeySchema.
+func EnvOptions() []Option {
+	schema := DefaultKeySchema
+	if pk := os.Getenv("DYNAMODB_PARTITION_KEY"); pk != "" {
+		schema.PartitionKey = pk
+	}
+	schema.SortKey = os.Getenv("DYNAMODB_SORT_KEY")
+	return []Option{WithKeySchema(schema)}
+}
+
+// Validate checks that every key attribute is stored on ProcessingResult,
+// so each written item carries its full key
+func (k KeySchema) Validate() error {
+	if k.PartitionKey == "" {
+		return fmt.Errorf("partition key is empty")
+	}
+
+	attrs := resultAttributes()
+	if !attrs[k.PartitionKey] {
+		return fmt.Errorf("partition key %q is not an attribute of ProcessingResult", k.PartitionKey)
+	}
+	if k.SortKey != "" && !attrs[k.SortKey] {
+		return fmt.Errorf("sort key %q is not an attribute of ProcessingResult", k.SortKey)
+	}
+	if k.SortKey == k.PartitionKey {
+		return fmt.Errorf("sort key %q is also the partition key", k.SortKey)
+	}
+	return nil
+}
+
+// resultAttributes returns the DynamoDB attribute names ProcessingResult
+// marshals to, taken from its dynamodbav tags
+func resultAttributes() map[string]bool {
+	t := reflect.TypeFor[models.ProcessingResult]()
+	attrs := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("dynamodbav"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		attrs[name] = true
+	}
+	return attrs
+}
@@ -0,0 +1,161 @@
+// internal/store/trends.go
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"event-pipeline/internal/awsconfig"
+)
+
+const (
+	// DefaultTrendAlpha is the weight of each new file's average in the EMA
+	DefaultTrendAlpha = 0.2
+
+	// maxTrendAttempts bounds how often an update is retried after losing
+	// a race with another writer
+	maxTrendAttempts = 5
+
+	// trendRetryBaseDelay is the initial backoff, doubled on each attempt
+	trendRetryBaseDelay = 20 * time.Millisecond
+)
+
+// ErrTrendConflict is returned by TrendStore.Update when every attempt lost
+// the race with a concurrent update of the same endpoint
+var ErrTrendConflict = errors.New("trend update conflicted with concurrent writers")
+
+// EndpointTrend is an endpoint's latency trend across files, stored as one
+// item keyed by endpoint. Version increases with every write and guards
+// against lost updates.
+type EndpointTrend struct {
+	Endpoint          string    `json:"endpoint" dynamodbav:"endpoint"`
+	EMAResponseTimeMs float64   `json:"ema_response_time_ms" dynamodbav:"ema_response_time_ms"`
+	Observations      int       `json:"observations" dynamodbav:"observations"`
+	Version           int64     `json:"version" dynamodbav:"version"`
+	UpdatedAt         time.Time `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// TrendStore keeps an exponential moving average of each endpoint's
+// average response time, updated once per processed file
+type TrendStore struct {
+	client    dynamoDBAPI
+	tableName string
+	alpha     float64
+}
+
+// NewTrendStore creates a store for the given trends table. alpha (0-1] is
+// the weight of each new observation; out-of-range values use
+// DefaultTrendAlpha.
+func NewTrendStore(ctx context.Context, tableName string, alpha float64) (*TrendStore, error) {
+	cfg, err := awsconfig.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultTrendAlpha
+	}
+	return &TrendStore{
+		client:    awsconfig.NewDynamoDB(cfg),
+		tableName: tableName,
+		alpha:     alpha,
+	}, nil
+}
+
+// Update folds avgMs, one file's average response time for endpoint, into
+// the endpoint's EMA and returns the stored trend. The first observation
+// becomes the EMA as is. Each write is conditional on the version read, so
+// a concurrent update makes this one re-read and retry with backoff,
+// returning ErrTrendConflict after maxTrendAttempts.
+func (s *TrendStore) Update(ctx context.Context, endpoint string, avgMs float64, at time.Time) (EndpointTrend, error) {
+	for attempt := 1; attempt <= maxTrendAttempts; attempt++ {
+		if attempt > 1 {
+			if err := sleepWithContext(ctx, rand.N(trendRetryBaseDelay<<(attempt-2)+1)); err != nil {
+				return EndpointTrend{}, err
+			}
+		}
+
+		prev, found, err := s.get(ctx, endpoint)
+		if err != nil {
+			return EndpointTrend{}, err
+		}
+
+		next := EndpointTrend{
+			Endpoint:          endpoint,
+			EMAResponseTimeMs: avgMs,
+			Observations:      1,
+			Version:           1,
+			UpdatedAt:         at.UTC(),
+		}
+		if found {
+			next.EMAResponseTimeMs = s.alpha*avgMs + (1-s.alpha)*prev.EMAResponseTimeMs
+			next.Observations = prev.Observations + 1
+			next.Version = prev.Version + 1
+		}
+
+		err = s.put(ctx, next, found, prev.Version)
+		if err == nil {
+			return next, nil
+		}
+		var condErr *types.ConditionalCheckFailedException
+		if !errors.As(err, &condErr) {
+			return EndpointTrend{}, fmt.Errorf("failed to write trend for %s: %w", endpoint, err)
+		}
+	}
+	return EndpointTrend{}, fmt.Errorf("endpoint %s: %w after %d attempts", endpoint, ErrTrendConflict, maxTrendAttempts)
+}
+
+// get reads the endpoint's trend, reporting false when none is stored yet
+func (s *TrendStore) get(ctx context.Context, endpoint string) (EndpointTrend, bool, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            map[string]types.AttributeValue{"endpoint": &types.AttributeValueMemberS{Value: endpoint}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return EndpointTrend{}, false, fmt.Errorf("failed to get trend for %s: %w", endpoint, err)
+	}
+	if out.Item == nil {
+		return EndpointTrend{}, false, nil
+	}
+
+	var trend EndpointTrend
+	if err := attributevalue.UnmarshalMap(out.Item, &trend); err != nil {
+		return EndpointTrend{}, false, fmt.Errorf("failed to unmarshal trend for %s: %w", endpoint, err)
+	}
+	return trend, true, nil
+}
+
+// put writes trend if the stored item is still at prevVersion, or, when
+// none existed, if no other writer created one in the meantime
+func (s *TrendStore) put(ctx context.Context, trend EndpointTrend, existed bool, prevVersion int64) error {
+	item, err := attributevalue.MarshalMap(trend)
+	if err != nil {
+		return fmt.Errorf("failed to marshal trend: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:                aws.String(s.tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#endpoint)"),
+		ExpressionAttributeNames: map[string]string{"#endpoint": "endpoint"},
+	}
+	if existed {
+		input.ConditionExpression = aws.String("#version = :version")
+		input.ExpressionAttributeNames = map[string]string{"#version": "version"}
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: fmt.Sprint(prevVersion)},
+		}
+	}
+
+	_, err = s.client.PutItem(ctx, input)
+	return err
+}
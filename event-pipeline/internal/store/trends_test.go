@@ -0,0 +1,130 @@
+// internal/store/trends_test.go
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var trendTime = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+// storedTrend answers GetItem with trend
+func storedTrend(t *testing.T, trend EndpointTrend) *dynamodb.GetItemOutput {
+	t.Helper()
+	item, err := attributevalue.MarshalMap(trend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dynamodb.GetItemOutput{Item: item}
+}
+
+// writtenTrend decodes the trend a PutItem call wrote
+func writtenTrend(t *testing.T, put *dynamodb.PutItemInput) EndpointTrend {
+	t.Helper()
+	var trend EndpointTrend
+	if err := attributevalue.UnmarshalMap(put.Item, &trend); err != nil {
+		t.Fatal(err)
+	}
+	return trend
+}
+
+func TestTrendUpdateFirstObservation(t *testing.T) {
+	client := &fakeDynamoDB{}
+	s := &TrendStore{client: client, tableName: "trends", alpha: 0.5}
+
+	trend, err := s.Update(context.Background(), "/a", 120, trendTime)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if trend.EMAResponseTimeMs != 120 || trend.Observations != 1 || trend.Version != 1 {
+		t.Errorf("trend = %+v, want the average as is at version 1", trend)
+	}
+
+	if len(client.puts) != 1 {
+		t.Fatalf("%d puts, want 1", len(client.puts))
+	}
+	put := client.puts[0]
+	if got := aws.ToString(put.ConditionExpression); got != "attribute_not_exists(#endpoint)" {
+		t.Errorf("condition = %q, want the item not to exist", got)
+	}
+	if written := writtenTrend(t, put); written != trend {
+		t.Errorf("wrote %+v, want %+v", written, trend)
+	}
+}
+
+func TestTrendUpdateFoldsIntoEMA(t *testing.T) {
+	client := &fakeDynamoDB{getItem: func(int, *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+		return storedTrend(t, EndpointTrend{Endpoint: "/a", EMAResponseTimeMs: 100, Observations: 4, Version: 3}), nil
+	}}
+	s := &TrendStore{client: client, tableName: "trends", alpha: 0.5}
+
+	trend, err := s.Update(context.Background(), "/a", 200, trendTime)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if trend.EMAResponseTimeMs != 150 || trend.Observations != 5 || trend.Version != 4 {
+		t.Errorf("trend = %+v, want ema 150 after 5 observations at version 4", trend)
+	}
+
+	put := client.puts[0]
+	if got := aws.ToString(put.ConditionExpression); got != "#version = :version" {
+		t.Errorf("condition = %q, want the version read", got)
+	}
+	if v, ok := put.ExpressionAttributeValues[":version"].(*types.AttributeValueMemberN); !ok || v.Value != "3" {
+		t.Errorf(":version = %v, want 3", put.ExpressionAttributeValues[":version"])
+	}
+}
+
+func TestTrendUpdateRetriesConflict(t *testing.T) {
+	// Another writer moves the trend to version 2 between the first read
+	// and write
+	client := &fakeDynamoDB{
+		getItem: func(call int, _ *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			if call == 1 {
+				return storedTrend(t, EndpointTrend{Endpoint: "/a", EMAResponseTimeMs: 100, Observations: 1, Version: 1}), nil
+			}
+			return storedTrend(t, EndpointTrend{Endpoint: "/a", EMAResponseTimeMs: 120, Observations: 2, Version: 2}), nil
+		},
+		putItem: func(call int, _ *dynamodb.PutItemInput) error {
+			if call == 1 {
+				return &types.ConditionalCheckFailedException{}
+			}
+			return nil
+		},
+	}
+	s := &TrendStore{client: client, tableName: "trends", alpha: 0.5}
+
+	trend, err := s.Update(context.Background(), "/a", 200, trendTime)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(client.gets) != 2 || len(client.puts) != 2 {
+		t.Errorf("%d gets and %d puts, want 2 each", len(client.gets), len(client.puts))
+	}
+	// Folded into the other writer's trend, not the stale one
+	if trend.EMAResponseTimeMs != 160 || trend.Observations != 3 || trend.Version != 3 {
+		t.Errorf("trend = %+v, want ema 160 after 3 observations at version 3", trend)
+	}
+}
+
+func TestTrendUpdateGivesUpAfterConflicts(t *testing.T) {
+	client := &fakeDynamoDB{putItem: func(int, *dynamodb.PutItemInput) error {
+		return &types.ConditionalCheckFailedException{}
+	}}
+	s := &TrendStore{client: client, tableName: "trends", alpha: 0.5}
+
+	_, err := s.Update(context.Background(), "/a", 200, trendTime)
+	if !errors.Is(err, ErrTrendConflict) {
+		t.Fatalf("Update = %v, want ErrTrendConflict", err)
+	}
+	if len(client.puts) != maxTrendAttempts {
+		t.Errorf("%d puts, want %d", len(client.puts), maxTrendAttempts)
+	}
+}